@@ -0,0 +1,182 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/markusressel/fan2go/cmd/global"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/generate"
+	"github.com/markusressel/fan2go/internal/hwmon"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	generateFile        string
+	generateForce       bool
+	generateInteractive bool
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generates a starter configuration from the detected hardware",
+	Long: `Generates a configuration containing one sensor per detected temperature input, one fan per pwm controllable
+fan channel and a linear curve controlling all fans based on the CPU temperature.
+
+Use --interactive to select and name the entries, otherwise all detected devices are used.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if global.IsStructuredOutput() {
+			return fmt.Errorf("--output %s is not supported, the configuration is always generated as commented yaml", global.Output)
+		}
+		if len(generateFile) > 0 && !generateForce {
+			if _, err := os.Stat(generateFile); err == nil {
+				return fmt.Errorf("file %s already exists, use --force to overwrite it", generateFile)
+			}
+		}
+		if len(generateFile) <= 0 && !generateInteractive {
+			// keep stdout clean for the generated config
+			pterm.DisableOutput()
+			defer pterm.EnableOutput()
+		}
+
+		configuration.LoadConfig()
+		sensorList, fanList := generate.Candidates(hwmon.GetChips())
+
+		var err error
+		if generateInteractive {
+			sensorList, fanList, err = selectEntries(sensorList, fanList)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(sensorList) <= 0 {
+			return fmt.Errorf("no temperature sensors found")
+		}
+		curveSensor := generate.DefaultCurveSensor(sensorList)
+		if generateInteractive {
+			curveSensor, err = selectCurveSensor(sensorList, curveSensor)
+			if err != nil {
+				return err
+			}
+		}
+
+		data, err := generate.Render(sensorList, fanList, *curveSensor)
+		if err != nil {
+			return err
+		}
+
+		if len(generateFile) <= 0 {
+			fmt.Print(string(data))
+			return nil
+		}
+		err = os.WriteFile(generateFile, data, 0644)
+		if err != nil {
+			return err
+		}
+		ui.Success("Generated configuration with %d fan(s) and %d sensor(s) at %s", len(fanList), len(sensorList), generateFile)
+		return nil
+	},
+}
+
+// selectEntries lets the user choose and name the sensors and fans that are added to the configuration
+func selectEntries(sensorList []generate.Sensor, fanList []generate.Fan) ([]generate.Sensor, []generate.Fan, error) {
+	sensorOptions := make([]string, len(sensorList))
+	for idx, sensor := range sensorList {
+		sensorOptions[idx] = fmt.Sprintf("%s (%s, %.1f°C)", sensor.ID, sensor.Label, sensor.Value/1000)
+	}
+	selectedSensors, err := pterm.DefaultInteractiveMultiselect.
+		WithOptions(sensorOptions).
+		WithDefaultOptions(sensorOptions).
+		WithMaxHeight(15).
+		Show("Select the sensors to add")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fanOptions := make([]string, len(fanList))
+	for idx, fan := range fanList {
+		fanOptions[idx] = fmt.Sprintf("%s (%s, %d rpm)", fan.ID, fan.Label, int(fan.Rpm))
+	}
+	selectedFans, err := pterm.DefaultInteractiveMultiselect.
+		WithOptions(fanOptions).
+		WithDefaultOptions(fanOptions).
+		WithMaxHeight(15).
+		Show("Select the fans to add")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resultSensors []generate.Sensor
+	for idx, sensor := range sensorList {
+		if !slices.Contains(selectedSensors, sensorOptions[idx]) {
+			continue
+		}
+		sensor.ID, err = askForId(sensor.ID, sensor.Label)
+		if err != nil {
+			return nil, nil, err
+		}
+		resultSensors = append(resultSensors, sensor)
+	}
+
+	var resultFans []generate.Fan
+	for idx, fan := range fanList {
+		if !slices.Contains(selectedFans, fanOptions[idx]) {
+			continue
+		}
+		fan.ID, err = askForId(fan.ID, fan.Label)
+		if err != nil {
+			return nil, nil, err
+		}
+		resultFans = append(resultFans, fan)
+	}
+
+	return resultSensors, resultFans, nil
+}
+
+func selectCurveSensor(sensorList []generate.Sensor, defaultSensor *generate.Sensor) (*generate.Sensor, error) {
+	options := make([]string, len(sensorList))
+	for idx, sensor := range sensorList {
+		options[idx] = sensor.ID
+	}
+	selected, err := pterm.DefaultInteractiveSelect.
+		WithOptions(options).
+		WithDefaultOption(defaultSensor.ID).
+		Show("Select the sensor used by the default curve")
+	if err != nil {
+		return nil, err
+	}
+	for idx := range sensorList {
+		if sensorList[idx].ID == selected {
+			return &sensorList[idx], nil
+		}
+	}
+	return defaultSensor, nil
+}
+
+func askForId(defaultId string, label string) (string, error) {
+	id, err := pterm.DefaultInteractiveTextInput.
+		WithDefaultValue(defaultId).
+		Show(fmt.Sprintf("ID of %s", label))
+	if err != nil {
+		return "", err
+	}
+	id = strings.TrimSpace(id)
+	if len(id) <= 0 {
+		return defaultId, nil
+	}
+	return id, nil
+}
+
+func init() {
+	generateCmd.Flags().StringVarP(&generateFile, "file", "f", "", "File to write the configuration to (default: stdout)")
+	generateCmd.Flags().BoolVar(&generateForce, "force", false, "Overwrite an existing file")
+	generateCmd.Flags().BoolVarP(&generateInteractive, "interactive", "i", false, "Select and name the sensors and fans to add")
+
+	Command.AddCommand(generateCmd)
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Prints a JSON Schema of the configuration file",
+	Long: `Prints a JSON Schema of the configuration file, which editors can use to validate
+and complete the configuration while typing.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := json.MarshalIndent(configuration.Schema(), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+func init() {
+	Command.AddCommand(schemaCmd)
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set <path>=<value>...",
+	Short: "Changes values in the configuration file",
+	Long: `Changes values in the configuration file, keeping comments and ordering as is.
+
+Entries of the fans, sensors and curves lists are addressed by their id, f.ex.:
+
+  fan2go config set curves.cpu_curve.linear.steps.60=180 fans.cpu_fan.neverStop=true
+
+Every value is changed in the file defining it, which can also be an included file or a drop-in.
+The resulting configuration is validated before any file is written. Restart fan2go,
+or use the REST api, to apply the changes.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		values := map[string]string{}
+		for _, arg := range args {
+			path, value, found := strings.Cut(arg, "=")
+			if !found || len(path) <= 0 {
+				return fmt.Errorf("invalid argument '%s', expected <path>=<value>", arg)
+			}
+			values[path] = value
+		}
+
+		configPath := configuration.DetectAndReadConfigFile()
+		edit, err := configuration.EditConfig(configPath, values)
+		if err != nil {
+			return err
+		}
+		if err = edit.Write(); err != nil {
+			return err
+		}
+
+		for _, file := range edit.Files() {
+			ui.Success("Updated %s", file)
+		}
+		return nil
+	},
+}
+
+func init() {
+	Command.AddCommand(setCmd)
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/hwmon"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	showResolved bool
+	showSecrets  bool
+)
+
+// redactedValue replaces secrets in the printed configuration
+const redactedValue = "***"
+
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Prints the configuration merged from the config file, its includes and drop-ins",
+	Long: `Prints the configuration merged from the config file, all files it includes and all drop-ins
+of the conf.d directory next to it.
+
+Use --resolved to print the effective configuration instead, including default values
+and the sysfs paths the hwmon sensors and fans resolve to. Secrets, like the mqtt password
+and the headers of http sensors, are replaced with "***" unless --show-secrets is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// keep stdout clean for the printed config
+		pterm.SetDefaultOutput(os.Stderr)
+
+		configPath := configuration.DetectAndReadConfigFile()
+
+		if !showResolved {
+			data, files, err := configuration.ReadMergedConfigFile(configPath)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("# merged from: %s\n", strings.Join(files, ", "))
+			fmt.Print(string(data))
+			return nil
+		}
+
+		configuration.LoadConfig()
+		config := resolveHwMonConfigs(configuration.CurrentConfig)
+		if !showSecrets {
+			config = redactSecrets(config)
+		}
+		data, err := configuration.Marshal(config)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+		return nil
+	},
+}
+
+// resolveHwMonConfigs returns a copy of the given configuration, with the sysfs paths of all hwmon sensors and fans resolved
+func resolveHwMonConfigs(config configuration.Configuration) configuration.Configuration {
+	controllers := hwmon.GetChips()
+
+	config.Sensors = append([]configuration.SensorConfig{}, config.Sensors...)
+	for idx := range config.Sensors {
+		sensorConfig := &config.Sensors[idx]
+		if sensorConfig.HwMon == nil {
+			continue
+		}
+		hwMonConfig := *sensorConfig.HwMon
+		sensorConfig.HwMon = &hwMonConfig
+		if err := hwmon.UpdateSensorConfigFromHwMonControllers(controllers, sensorConfig); err != nil {
+			ui.Warning("%v", err)
+		}
+	}
+
+	config.Fans = append([]configuration.FanConfig{}, config.Fans...)
+	for idx := range config.Fans {
+		fanConfig := &config.Fans[idx]
+		if fanConfig.HwMon == nil {
+			continue
+		}
+		hwMonConfig := *fanConfig.HwMon
+		fanConfig.HwMon = &hwMonConfig
+		if err := hwmon.UpdateFanConfigFromHwMonControllers(controllers, fanConfig); err != nil {
+			ui.Warning("Couldn't update fan config from hwmon: %s", err)
+		}
+	}
+
+	return config
+}
+
+// redactSecrets returns a copy of the given configuration, with the mqtt password and the headers of all http sensors redacted
+func redactSecrets(config configuration.Configuration) configuration.Configuration {
+	if len(config.Mqtt.Password) > 0 {
+		config.Mqtt.Password = redactedValue
+	}
+
+	config.Sensors = append([]configuration.SensorConfig{}, config.Sensors...)
+	for idx := range config.Sensors {
+		sensorConfig := &config.Sensors[idx]
+		if sensorConfig.Http == nil || len(sensorConfig.Http.Headers) <= 0 {
+			continue
+		}
+		httpConfig := *sensorConfig.Http
+		httpConfig.Headers = map[string]string{}
+		for name := range sensorConfig.Http.Headers {
+			httpConfig.Headers[name] = redactedValue
+		}
+		sensorConfig.Http = &httpConfig
+	}
+
+	return config
+}
+
+func init() {
+	showCmd.Flags().BoolVar(&showResolved, "resolved", false, "Print the effective configuration, including defaults and resolved hwmon paths")
+	showCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print secrets like passwords and http headers as is, used with --resolved")
+	Command.AddCommand(showCmd)
+}
@@ -1,25 +1,69 @@
 package config
 
 import (
+	"os"
+
+	"github.com/markusressel/fan2go/cmd/global"
 	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/hardware"
+	"github.com/markusressel/fan2go/internal/hwmon"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/spf13/cobra"
-	"os"
+)
+
+var (
+	validateHardware  bool
+	validateSysfsRoot string
 )
 
 var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validates the current configuration",
-	Long:  ``,
-	Args:  cobra.NoArgs,
+	Long: `Validates the current configuration and prints all errors and warnings,
+together with their location within the config file.
+Use --output json or yaml to print the problems in a machine-readable format.
+
+Use --hardware to additionally resolve all hwmon, file and cmd references against the live system
+(or the sysfs tree at --sysfs-root) and check their permissions, without starting any fan control.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		configPath := configuration.DetectAndReadConfigFile()
 
 		ui.Info("Using configuration file at: %s", configPath)
 		configuration.LoadConfig()
 
-		if err := configuration.Validate(configPath); err != nil {
-			ui.Error("Validation failed: %v", err)
+		result := configuration.Check(configPath)
+		if validateHardware {
+			if len(validateSysfsRoot) > 0 {
+				configuration.CurrentConfig.SysfsRoot = validateSysfsRoot
+			}
+			problems := hardware.Check(&configuration.CurrentConfig, hwmon.GetChips())
+			configuration.LocateProblems(problems, configPath)
+			result.Problems = append(result.Problems, problems...)
+		}
+		if global.IsStructuredOutput() {
+			if result.Problems == nil {
+				result.Problems = []configuration.ValidationProblem{}
+			}
+			if err := global.PrintStructured(result); err != nil {
+				return err
+			}
+			if len(result.Errors()) > 0 {
+				os.Exit(1)
+			}
+			return nil
+		}
+
+		for _, problem := range result.Problems {
+			if problem.Severity == configuration.SeverityError {
+				ui.Error("%s", problem)
+			} else {
+				ui.Warning("%s", problem)
+			}
+		}
+
+		if errorCount := len(result.Errors()); errorCount > 0 {
+			ui.Error("Validation failed: %d error(s), %d warning(s)", errorCount, len(result.Warnings()))
 			os.Exit(1)
 		}
 
@@ -29,5 +73,7 @@ var validateCmd = &cobra.Command{
 }
 
 func init() {
+	validateCmd.Flags().BoolVar(&validateHardware, "hardware", false, "Resolve all hwmon, file and cmd references against the live system")
+	validateCmd.Flags().StringVar(&validateSysfsRoot, "sysfs-root", "", "Directory sysfs is mounted at, used with --hardware")
 	Command.AddCommand(validateCmd)
 }
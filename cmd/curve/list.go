@@ -16,6 +16,12 @@ import (
 	"strings"
 )
 
+type curveInfo struct {
+	// Type of the curve, one of: linear | pid | function
+	Type string `json:"type"`
+	configuration.CurveConfig
+}
+
 var curveCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Print the measured fan curve(s) to console",
@@ -40,6 +46,23 @@ var curveCmd = &cobra.Command{
 			curveConfigsToPrint = append(curveConfigsToPrint, configuration.CurrentConfig.Curves...)
 		}
 
+		if global.IsStructuredOutput() {
+			infos := []curveInfo{}
+			for _, curveConfig := range curveConfigsToPrint {
+				info := curveInfo{CurveConfig: curveConfig}
+				switch {
+				case curveConfig.Linear != nil:
+					info.Type = "linear"
+				case curveConfig.PID != nil:
+					info.Type = "pid"
+				case curveConfig.Function != nil:
+					info.Type = "function"
+				}
+				infos = append(infos, info)
+			}
+			return global.PrintStructured(infos)
+		}
+
 		for idx, curveConfig := range curveConfigsToPrint {
 			if idx > 0 {
 				ui.Printfln("")
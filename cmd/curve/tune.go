@@ -0,0 +1,196 @@
+package curve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/markusressel/fan2go/cmd/global"
+	"github.com/markusressel/fan2go/internal"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/tuning"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/markusressel/fan2go/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tuneHigh       int
+	tuneLow        int
+	tuneHysteresis float64
+	tuneCycles     int
+	tuneInterval   time.Duration
+	tuneTimeout    time.Duration
+	tuneLimit      float64
+	tuneRule       string
+	tuneWrite      bool
+)
+
+type tuneResult struct {
+	Amplitude    float64                 `json:"amplitude"`
+	Period       float64                 `json:"period"`
+	UltimateGain float64                 `json:"ultimateGain"`
+	Gains        map[string]tuning.Gains `json:"gains"`
+}
+
+var tuneCmd = &cobra.Command{
+	Use:   "tune",
+	Short: "Suggest gains for a pid curve using a relay feedback experiment",
+	Long: `Runs a relay feedback experiment (Åström–Hägglund) on the sensor and fans of a pid curve:
+the fans are switched between a high and a low speed whenever the temperature crosses the set point of the curve.
+The amplitude and period of the resulting oscillation are used to suggest gains using standard tuning rules.
+
+Make sure the fan2go daemon is stopped while running this experiment, and keep the load of the system
+constant for the whole duration.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, ok := tuning.Rules[tuneRule]; !ok {
+			return fmt.Errorf("unknown tuning rule: %s, options: %v", tuneRule, tuning.RuleNames())
+		}
+
+		configPath := configuration.DetectAndReadConfigFile()
+		ui.Info("Using configuration file at: %s", configPath)
+		configuration.LoadConfig()
+		err := configuration.Validate(configPath)
+		if err != nil {
+			return err
+		}
+
+		curveConf, err := getCurveConfig(curveId, configuration.CurrentConfig.Curves)
+		if err != nil {
+			return err
+		}
+		if curveConf.PID == nil {
+			return fmt.Errorf("curve %s is not a pid curve", curveConf.ID)
+		}
+
+		internal.InitializeDevices()
+		defer util.StopCoprocesses()
+
+		sensor, ok := sensors.SensorMap[curveConf.PID.Sensor]
+		if !ok {
+			return fmt.Errorf("sensor %s of curve %s not found", curveConf.PID.Sensor, curveConf.ID)
+		}
+		var fanList []fans.Fan
+		for _, fanConfig := range configuration.CurrentConfig.Fans {
+			if fan, ok := fans.FanMap[fanConfig.ID]; ok && fanConfig.Curve == curveConf.ID {
+				fanList = append(fanList, fan)
+			}
+		}
+		if len(fanList) <= 0 {
+			return fmt.Errorf("no fan is using curve %s directly", curveConf.ID)
+		}
+
+		limit := tuneLimit
+		if limit <= 0 {
+			limit = curveConf.PID.SetPoint + 20
+		}
+		process := tuning.NewFanProcess(sensor, fanList)
+		experiment := tuning.NewRelayExperiment(tuning.RelayConfig{
+			SetPoint:   curveConf.PID.SetPoint,
+			High:       float64(tuneHigh) / fans.MaxPwmValue,
+			Low:        float64(tuneLow) / fans.MaxPwmValue,
+			Hysteresis: tuneHysteresis,
+			Cycles:     tuneCycles,
+			Interval:   tuneInterval,
+			Timeout:    tuneTimeout,
+			Limit:      limit,
+		}, process)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+		defer signal.Stop(sig)
+		go func() {
+			select {
+			case <-sig:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		ui.Info("Running relay experiment around %.1f°C using %d fan(s), this can take a while...", curveConf.PID.SetPoint, len(fanList))
+		result, err := experiment.Run(ctx)
+		limitExceeded := errors.Is(err, tuning.ErrLimitExceeded)
+		process.Restore(limitExceeded)
+		if limitExceeded {
+			ui.Warning("Temperature limit exceeded, fans have been left at max speed")
+		}
+		if err != nil {
+			return err
+		}
+
+		if global.IsStructuredOutput() {
+			output := tuneResult{
+				Amplitude:    result.Amplitude,
+				Period:       result.Period.Seconds(),
+				UltimateGain: result.UltimateGain,
+				Gains:        map[string]tuning.Gains{},
+			}
+			for _, name := range tuning.RuleNames() {
+				output.Gains[name], _ = tuning.SuggestGains(result, name)
+			}
+			err = global.PrintStructured(output)
+			if err != nil {
+				return err
+			}
+		} else {
+			ui.Printfln("Amplitude:      %.2f°C", result.Amplitude)
+			ui.Printfln("Period:         %v", result.Period.Round(time.Second))
+			ui.Printfln("Ultimate gain:  %.4f", result.UltimateGain)
+			ui.Printfln("")
+			for _, name := range tuning.RuleNames() {
+				gains, _ := tuning.SuggestGains(result, name)
+				ui.Printfln("%-16s p: %.4g  i: %.4g  d: %.4g  (%s)", name, gains.P, gains.I, gains.D, tuning.Rules[name].Description)
+			}
+		}
+
+		if tuneWrite {
+			gains, _ := tuning.SuggestGains(result, tuneRule)
+			pidPath := fmt.Sprintf("curves.%s.pid.", curveConf.ID)
+			edit, err := configuration.EditConfig(configPath, map[string]string{
+				pidPath + "p": formatGain(gains.P),
+				pidPath + "i": formatGain(gains.I),
+				pidPath + "d": formatGain(gains.D),
+			})
+			if err != nil {
+				return err
+			}
+			if err = edit.Write(); err != nil {
+				return err
+			}
+			for _, file := range edit.Files() {
+				ui.Success("Updated gains of curve %s in %s using rule %s", curveConf.ID, file, tuneRule)
+			}
+		}
+
+		return nil
+	},
+}
+
+// formatGain formats a suggested gain with a precision that is meaningful for a relay experiment
+func formatGain(value float64) string {
+	return strconv.FormatFloat(value, 'g', 4, 64)
+}
+
+func init() {
+	tuneCmd.Flags().IntVar(&tuneHigh, "high", fans.MaxPwmValue, "Curve value (0..255) applied while the temperature is above the set point")
+	tuneCmd.Flags().IntVar(&tuneLow, "low", 0, "Curve value (0..255) applied while the temperature is below the set point")
+	tuneCmd.Flags().Float64Var(&tuneHysteresis, "hysteresis", 0.5, "Hysteresis around the set point in °C")
+	tuneCmd.Flags().IntVar(&tuneCycles, "cycles", 3, "Number of oscillations to measure")
+	tuneCmd.Flags().DurationVar(&tuneInterval, "interval", time.Second, "Interval between two measurements")
+	tuneCmd.Flags().DurationVar(&tuneTimeout, "timeout", 30*time.Minute, "Maximum duration of the experiment")
+	tuneCmd.Flags().Float64Var(&tuneLimit, "limit", 0, "Abort if the temperature exceeds this value in °C (default: set point + 20)")
+	tuneCmd.Flags().StringVar(&tuneRule, "rule", tuning.DefaultRule, fmt.Sprintf("Tuning rule used by --write, one of: %v", tuning.RuleNames()))
+	tuneCmd.Flags().BoolVarP(&tuneWrite, "write", "w", false, "Write the suggested gains to the config file")
+
+	Command.AddCommand(tuneCmd)
+}
@@ -17,14 +17,53 @@ import (
 	"github.com/tomlazar/table"
 )
 
+type detectedFan struct {
+	Index      int    `json:"index"`
+	RpmChannel int    `json:"rpmChannel"`
+	PwmChannel int    `json:"pwmChannel"`
+	Label      string `json:"label"`
+	// ConfigLabel is the label to use in the hwmon config of this fan, empty if the label is not unique
+	ConfigLabel string `json:"configLabel"`
+	RpmInput    string `json:"rpmInput"`
+	PwmOutput   string `json:"pwmOutput"`
+	Rpm         *int   `json:"rpm"`
+	Pwm         *int   `json:"pwm"`
+	Auto        bool   `json:"auto"`
+}
+
+type detectedSensor struct {
+	Index int    `json:"index"`
+	Label string `json:"label"`
+	// ConfigLabel is the label to use in the hwmon config of this sensor, empty if the label is not unique
+	ConfigLabel string   `json:"configLabel"`
+	Input       string   `json:"input"`
+	Value       *float64 `json:"value"`
+}
+
+type detectedChip struct {
+	Name       string           `json:"name"`
+	Platform   string           `json:"platform"`
+	Type       string           `json:"type"`
+	Modalias   string           `json:"modalias"`
+	Path       string           `json:"path"`
+	Driver     string           `json:"driver"`
+	BusAddress string           `json:"busAddress"`
+	Device     string           `json:"device"`
+	Fans       []detectedFan    `json:"fans"`
+	Sensors    []detectedSensor `json:"sensors"`
+}
+
 var detectCmd = &cobra.Command{
 	Use:   "detect",
 	Short: "Detect fans and sensors",
 	Long:  `Detect fans and sensors on your system and print them to console.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		configuration.LoadConfig()
 
-		controllers := hwmon.GetChips()
+		chips := detectChips(hwmon.GetChips())
+		if global.IsStructuredOutput() {
+			return global.PrintStructured(chips)
+		}
 
 		// === Print detected devices ===
 		tableConfig := &table.Config{
@@ -38,71 +77,40 @@ var detectCmd = &cobra.Command{
 			},
 		}
 
-		for _, controller := range controllers {
-			if len(controller.Name) <= 0 {
-				continue
-			}
-
-			fanSlice := controller.Fans
-			sensorMap := controller.Sensors
-
-			if len(fanSlice) <= 0 && len(sensorMap) <= 0 {
-				continue
+		for _, chip := range chips {
+			ui.Printfln("> %s", chip.Name)
+			if len(chip.Driver) > 0 || len(chip.BusAddress) > 0 {
+				ui.Printfln("  driver: %s, busAddress: %s", chip.Driver, chip.BusAddress)
 			}
 
-			ui.Printfln("> %s", controller.Name)
-
 			var fanRows [][]string
-			for _, fan := range fanSlice {
-				pwmText := "N/A"
-				pwm, err := fan.GetPwm()
-				if err == nil {
-					pwmText = strconv.Itoa(pwm)
-				}
-
-				rpmText := "N/A"
-				if fan.Supports(fans.FeatureRpmSensor) {
-					rpm, err := fan.GetRpm()
-					if err == nil {
-						rpmText = strconv.Itoa(rpm)
-					}
-				}
-
-				isAuto, _ := fan.IsPwmAuto()
+			for _, fan := range chip.Fans {
 				fanRows = append(fanRows, []string{
-					"", strconv.Itoa(fan.Index), strconv.Itoa(fan.Config.HwMon.RpmChannel), fan.Label, rpmText, pwmText, fmt.Sprintf("%v", isAuto),
+					"", strconv.Itoa(fan.Index), strconv.Itoa(fan.RpmChannel), fan.Label, formatConfigLabel(fan.ConfigLabel), formatOptional(fan.Rpm), formatOptional(fan.Pwm), fmt.Sprintf("%v", fan.Auto),
 				})
 			}
-			var fanHeaders = []string{"Fans   ", "Index", "Channel", "Label", "RPM", "PWM", "Auto"}
+			var fanHeaders = []string{"Fans   ", "Index", "Channel", "Label", "Config Label", "RPM", "PWM", "Auto"}
 
 			fanTable := table.Table{
 				Headers: fanHeaders,
 				Rows:    fanRows,
 			}
 
-			sensorMapKeys := make([]int, 0, len(sensorMap))
-			for k := range sensorMap {
-				sensorMapKeys = append(sensorMapKeys, k)
-			}
-			sort.Ints(sensorMapKeys)
-
 			var sensorRows [][]string
-			for _, index := range sensorMapKeys {
-				sensor := sensorMap[index]
-				value, err := sensor.GetValue()
+			for _, sensor := range chip.Sensors {
 				valueText := "N/A"
-				if err == nil {
-					valueText = strconv.Itoa(int(value))
+				if sensor.Value != nil {
+					valueText = strconv.Itoa(int(*sensor.Value))
 				}
 
 				_, file := filepath.Split(sensor.Input)
 				labelAndFile := fmt.Sprintf("%s (%s)", sensor.Label, file)
 
 				sensorRows = append(sensorRows, []string{
-					"", strconv.Itoa(sensor.Index), labelAndFile, valueText,
+					"", strconv.Itoa(sensor.Index), labelAndFile, formatConfigLabel(sensor.ConfigLabel), valueText,
 				})
 			}
-			var sensorHeaders = []string{"Sensors", "Index", "Label", "Value"}
+			var sensorHeaders = []string{"Sensors", "Index", "Label", "Config Label", "Value"}
 
 			sensorTable := table.Table{
 				Headers: sensorHeaders,
@@ -128,9 +136,96 @@ var detectCmd = &cobra.Command{
 				}
 			}
 		}
+		return nil
 	},
 }
 
+// detectChips reads the current state of all fans and sensors of the given controllers
+func detectChips(controllers []*hwmon.HwMonController) []detectedChip {
+	chips := []detectedChip{}
+	for _, controller := range controllers {
+		if len(controller.Name) <= 0 {
+			continue
+		}
+		if len(controller.Fans) <= 0 && len(controller.Sensors) <= 0 {
+			continue
+		}
+
+		chip := detectedChip{
+			Name:       controller.Name,
+			Platform:   controller.Platform,
+			Type:       controller.DType,
+			Modalias:   controller.Modalias,
+			Path:       controller.Path,
+			Driver:     controller.Driver,
+			BusAddress: controller.BusAddress,
+			Device:     controller.Device,
+			Fans:       []detectedFan{},
+			Sensors:    []detectedSensor{},
+		}
+
+		for _, fan := range controller.Fans {
+			detected := detectedFan{
+				Index:       fan.Index,
+				RpmChannel:  fan.Config.HwMon.RpmChannel,
+				PwmChannel:  fan.Config.HwMon.PwmChannel,
+				Label:       fan.Label,
+				ConfigLabel: controller.FanConfigLabel(fan),
+				RpmInput:    fan.Config.HwMon.RpmInputPath,
+				PwmOutput:   fan.Config.HwMon.PwmPath,
+			}
+			if pwm, err := fan.GetPwm(); err == nil {
+				detected.Pwm = &pwm
+			}
+			if fan.Supports(fans.FeatureRpmSensor) {
+				if rpm, err := fan.GetRpm(); err == nil {
+					detected.Rpm = &rpm
+				}
+			}
+			detected.Auto, _ = fan.IsPwmAuto()
+			chip.Fans = append(chip.Fans, detected)
+		}
+
+		sensorMapKeys := make([]int, 0, len(controller.Sensors))
+		for k := range controller.Sensors {
+			sensorMapKeys = append(sensorMapKeys, k)
+		}
+		sort.Ints(sensorMapKeys)
+
+		for _, index := range sensorMapKeys {
+			sensor := controller.Sensors[index]
+			detected := detectedSensor{
+				Index:       sensor.Index,
+				Label:       sensor.Label,
+				ConfigLabel: controller.SensorConfigLabel(sensor),
+				Input:       sensor.Input,
+			}
+			if value, err := sensor.GetValue(); err == nil {
+				detected.Value = &value
+			}
+			chip.Sensors = append(chip.Sensors, detected)
+		}
+
+		chips = append(chips, chip)
+	}
+	return chips
+}
+
+// formatConfigLabel shows the label used by a config, or a hint to use the index instead
+func formatConfigLabel(label string) string {
+	if len(label) <= 0 {
+		return "(use index)"
+	}
+	return label
+}
+
+func formatOptional(value *int) string {
+	if value == nil {
+		return "N/A"
+	}
+	return strconv.Itoa(*value)
+}
+
 func init() {
 	rootCmd.AddCommand(detectCmd)
 }
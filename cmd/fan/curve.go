@@ -15,10 +15,19 @@ import (
 	"strconv"
 )
 
+type fanCurveInfo struct {
+	ID       string `json:"id"`
+	MinPwm   int    `json:"minPwm"`
+	StartPwm int    `json:"startPwm"`
+	MaxPwm   int    `json:"maxPwm"`
+	// CurveData maps pwm -> rpm, nil if the fan has not been measured yet
+	CurveData map[int]float64 `json:"curveData"`
+}
+
 var curveCmd = &cobra.Command{
 	Use:   "curve",
 	Short: "Print the measured fan curve(s) to console",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		configPath := configuration.DetectAndReadConfigFile()
 		ui.Info("Using configuration file at: %s", configPath)
 		configuration.LoadConfig()
@@ -48,6 +57,19 @@ var curveCmd = &cobra.Command{
 				_ = fan.AttachFanCurveData(&pwmData)
 			}
 
+			if global.IsStructuredOutput() {
+				info := fanCurveInfo{
+					ID:       fan.GetId(),
+					MinPwm:   fan.GetMinPwm(),
+					StartPwm: fan.GetStartPwm(),
+					MaxPwm:   fan.GetMaxPwm(),
+				}
+				if fanCurveErr == nil {
+					info.CurveData = pwmData
+				}
+				return global.PrintStructured(info)
+			}
+
 			if idx > 0 {
 				ui.Printfln("")
 				ui.Printfln("")
@@ -101,6 +123,7 @@ var curveCmd = &cobra.Command{
 			graph := asciigraph.Plot(values, asciigraph.Height(15), asciigraph.Width(100), asciigraph.Caption(caption))
 			ui.Printfln(graph)
 		}
+		return nil
 	},
 }
 
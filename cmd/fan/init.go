@@ -5,7 +5,6 @@ import (
 	"github.com/markusressel/fan2go/internal/controller"
 	"github.com/markusressel/fan2go/internal/persistence"
 	"github.com/markusressel/fan2go/internal/ui"
-	"github.com/markusressel/fan2go/internal/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -31,11 +30,7 @@ var initCmd = &cobra.Command{
 		fanController := controller.NewFanController(
 			p,
 			fan,
-			*util.NewPidLoop(
-				0.03,
-				0.002,
-				0.0005,
-			),
+			controller.NewDefaultPidControlLoop(),
 			configuration.CurrentConfig.ControllerAdjustmentTickRate)
 
 		ui.Info("Deleting existing data for fan '%s'...", fan.GetId())
@@ -2,11 +2,18 @@ package fan
 
 import (
 	"fmt"
+	"github.com/markusressel/fan2go/cmd/global"
 	"github.com/markusressel/fan2go/internal/fans"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 )
 
+type fanRpm struct {
+	ID string `json:"id"`
+	// Rpm is nil if the fan has no rpm sensor
+	Rpm *int `json:"rpm"`
+}
+
 var rpmCmd = &cobra.Command{
 	Use:   "rpm",
 	Short: "Get the current RPM reading of a fan",
@@ -21,15 +28,22 @@ var rpmCmd = &cobra.Command{
 		}
 
 		if !fan.Supports(fans.FeatureRpmSensor) {
+			if global.IsStructuredOutput() {
+				return global.PrintStructured(fanRpm{ID: fan.GetId()})
+			}
 			fmt.Printf("N/A")
 			return nil
 		}
 
 		rpm, err := fan.GetRpm()
-		if err == nil {
-			fmt.Printf("RPM: %d", rpm)
+		if err != nil {
+			return err
+		}
+		if global.IsStructuredOutput() {
+			return global.PrintStructured(fanRpm{ID: fan.GetId(), Rpm: &rpm})
 		}
-		return err
+		fmt.Printf("RPM: %d", rpm)
+		return nil
 	},
 }
 
@@ -2,11 +2,17 @@ package fan
 
 import (
 	"fmt"
+	"github.com/markusressel/fan2go/cmd/global"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"strconv"
 )
 
+type fanSpeed struct {
+	ID  string `json:"id"`
+	Pwm int    `json:"pwm"`
+}
+
 var speedCmd = &cobra.Command{
 	Use:   "speed",
 	Short: "Get/Set the current speed setting of a fan to the given PWM value ([0..255])",
@@ -30,6 +36,9 @@ var speedCmd = &cobra.Command{
 		} else {
 			var pwm int
 			if pwm, err = fan.GetPwm(); err == nil {
+				if global.IsStructuredOutput() {
+					return global.PrintStructured(fanSpeed{ID: fan.GetId(), Pwm: pwm})
+				}
 				fmt.Printf("%d", pwm)
 			}
 		}
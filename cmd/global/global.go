@@ -7,4 +7,6 @@ var (
 	NoColor bool
 	NoStyle bool
 	Verbose bool
+	// Output is the format used by commands printing data, one of: table | json | yaml
+	Output string
 )
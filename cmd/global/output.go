@@ -0,0 +1,72 @@
+package global
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OutputTable = "table"
+	OutputJson  = "json"
+	OutputYaml  = "yaml"
+)
+
+// OutputFormats lists all supported values of the --output flag
+var OutputFormats = []string{OutputTable, OutputJson, OutputYaml}
+
+// IsStructuredOutput returns true if commands should print machine-readable data instead of tables and graphs
+func IsStructuredOutput() bool {
+	return Output == OutputJson || Output == OutputYaml
+}
+
+// PrintStructured prints the given value to stdout, using the output format selected by the --output flag
+func PrintStructured(value interface{}) error {
+	return WriteStructured(os.Stdout, Output, value)
+}
+
+// WriteStructured writes the given value to the writer using the given output format.
+// Field names are taken from the json tags of the value in both formats.
+func WriteStructured(w io.Writer, format string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case OutputJson:
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case OutputYaml:
+		// json is valid yaml, so the json document is parsed and emitted again using the block style
+		node := yaml.Node{}
+		err = yaml.Unmarshal(data, &node)
+		if err != nil {
+			return err
+		}
+		resetStyle(&node)
+
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		err = encoder.Encode(&node)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf.Bytes())
+		return err
+	default:
+		return fmt.Errorf("unsupported output format: %s, options: %v", format, OutputFormats)
+	}
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
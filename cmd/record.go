@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/markusressel/fan2go/internal"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recordFile     string
+	recordInterval time.Duration
+	recordDuration time.Duration
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record sensor and fan values to a trace file",
+	Long: `Records timestamped values of all configured sensors and fans to a trace file,
+until the given duration has passed or the command is interrupted. Fans are only read, not controlled.
+
+To record from within the running daemon instead, enable the "record" section of the config.
+Recorded sensor values can be replayed using a sensor of type "replay".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if recordInterval <= 0 {
+			return fmt.Errorf("invalid interval, must be > 0")
+		}
+
+		configPath := configuration.DetectAndReadConfigFile()
+		ui.Info("Using configuration file at: %s", configPath)
+		configuration.LoadConfig()
+		err := configuration.Validate(configPath)
+		if err != nil {
+			return err
+		}
+
+		err = internal.RunRecorder(recordFile, recordInterval, recordDuration)
+		if err != nil {
+			return err
+		}
+		ui.Success("Trace written to %s", recordFile)
+		return nil
+	},
+}
+
+func init() {
+	recordCmd.Flags().StringVarP(&recordFile, "file", "f", "fan2go-trace.jsonl", "Trace file to append the recorded values to")
+	recordCmd.Flags().DurationVar(&recordInterval, "interval", time.Second, "Interval between two recordings")
+	recordCmd.Flags().DurationVarP(&recordDuration, "duration", "d", 0, "Duration of the recording, 0 records until interrupted")
+
+	rootCmd.AddCommand(recordCmd)
+}
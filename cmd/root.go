@@ -16,6 +16,7 @@ import (
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -46,6 +47,7 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&global.NoColor, "no-color", "", false, "Disable all terminal output coloration")
 	rootCmd.PersistentFlags().BoolVarP(&global.NoStyle, "no-style", "", false, "Disable all terminal output styling")
 	rootCmd.PersistentFlags().BoolVarP(&global.Verbose, "verbose", "v", false, "More verbose output")
+	rootCmd.PersistentFlags().StringVarP(&global.Output, "output", "o", global.OutputTable, fmt.Sprintf("Output format of printed data, one of: %v", global.OutputFormats))
 
 	rootCmd.AddCommand(config.Command)
 
@@ -63,6 +65,14 @@ func setupUi() {
 	if global.NoStyle {
 		pterm.DisableStyling()
 	}
+
+	if !slices.Contains(global.OutputFormats, global.Output) {
+		ui.FatalWithoutStacktrace("unsupported output format: %s, options: %v", global.Output, global.OutputFormats)
+	}
+	if global.IsStructuredOutput() {
+		// keep stdout clean for the printed data
+		pterm.SetDefaultOutput(os.Stderr)
+	}
 }
 
 // Print a large text with the LetterStyle from the standard theme.
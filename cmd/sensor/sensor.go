@@ -2,17 +2,22 @@ package sensor
 
 import (
 	"fmt"
+	"github.com/markusressel/fan2go/cmd/global"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/hwmon"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
-	"regexp"
 )
 
 var sensorId string
 
+type sensorValue struct {
+	ID    string  `json:"id"`
+	Value float64 `json:"value"`
+}
+
 var Command = &cobra.Command{
 	Use:              "sensor",
 	Short:            "Sensor related commands",
@@ -27,10 +32,19 @@ var Command = &cobra.Command{
 			return err
 		}
 
-		value, err := sensor.GetValue()
+		var value float64
+		if backgroundSensor, ok := sensor.(sensors.BackgroundSensor); ok {
+			// nothing updates the sensor in the background, read its value directly
+			value, err = backgroundSensor.Fetch()
+		} else {
+			value, err = sensor.GetValue()
+		}
 		if err != nil {
 			return err
 		}
+		if global.IsStructuredOutput() {
+			return global.PrintStructured(sensorValue{ID: sensor.GetId(), Value: value})
+		}
 		fmt.Printf("%d", int(value))
 		return nil
 	},
@@ -62,21 +76,9 @@ func getSensor(id string) (sensors.Sensor, error) {
 		availableSensorIds = append(availableSensorIds, config.ID)
 		if config.ID == id {
 			if config.HwMon != nil {
-				for _, controller := range controllers {
-					matched, err := regexp.MatchString("(?i)"+config.HwMon.Platform, controller.Platform)
-					if err != nil {
-						return nil, fmt.Errorf("Failed to match platform regex of %s (%s) against controller platform %s", config.ID, config.HwMon.Platform, controller.Platform)
-					}
-					if matched {
-						sensor, exists := controller.Sensors[config.HwMon.Index]
-						if exists {
-							if len(sensor.Input) <= 0 {
-								return nil, fmt.Errorf("unable to find temp input for sensor %s", id)
-							}
-							config.HwMon.TempInput = sensor.Input
-							break
-						}
-					}
+				err := hwmon.UpdateSensorConfigFromHwMonControllers(controllers, &config)
+				if err != nil {
+					return nil, err
 				}
 			}
 
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/guptarohit/asciigraph"
+	"github.com/markusressel/fan2go/cmd/global"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/persistence"
+	"github.com/markusressel/fan2go/internal/simulation"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+const (
+	simulateFormatCsv  = "csv"
+	simulateFormatPlot = "plot"
+)
+
+var (
+	simulateLoadProfile  string
+	simulateFormat       string
+	simulateFile         string
+	simulateSampleRate   time.Duration
+	simulateSynthetic    bool
+	simulateThermalModel = simulation.DefaultThermalModelConfig
+)
+
+// simulationSample is the structured output of a single sample of the simulation
+type simulationSample struct {
+	// Time since the start of the simulation in seconds
+	Time         float64            `json:"time"`
+	Load         float64            `json:"load"`
+	Temperatures map[string]float64 `json:"temperatures"`
+	Pwm          map[string]int     `json:"pwm"`
+	Rpm          map[string]int     `json:"rpm"`
+}
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Simulate the configured fans against a thermal model",
+	Long: `Replaces all configured sensors and fans with simulated ones and runs the fan controllers
+against a first order thermal model, faster than real time. No hardware is touched.
+
+The load profile is a comma separated list of <duration>:<load> steps, f.ex. "1m:10%,3m:100%,2m:10%".
+Using --output json or yaml prints all samples in the given format, instead of the --format option.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if simulateFormat != simulateFormatCsv && simulateFormat != simulateFormatPlot {
+			return fmt.Errorf("unsupported format: %s, options: [%s %s]", simulateFormat, simulateFormatCsv, simulateFormatPlot)
+		}
+		profile, err := simulation.ParseLoadProfile(simulateLoadProfile)
+		if err != nil {
+			return err
+		}
+
+		if simulateFormat == simulateFormatCsv && len(simulateFile) <= 0 && !global.IsStructuredOutput() {
+			// keep stdout clean for the csv output
+			pterm.DisableOutput()
+			defer pterm.EnableOutput()
+		}
+
+		configPath := configuration.DetectAndReadConfigFile()
+		ui.Info("Using configuration file at: %s", configPath)
+		configuration.LoadConfig()
+		err = configuration.Validate(configPath)
+		if err != nil {
+			return err
+		}
+
+		var calibration persistence.Persistence
+		dbPath := configuration.CurrentConfig.DbPath
+		if _, err := os.Stat(dbPath); err == nil && !simulateSynthetic {
+			ui.Info("Using fan curves from persistence at: %s", dbPath)
+			calibration = persistence.NewPersistence(dbPath)
+		}
+
+		model := simulation.NewThermalModel(simulateThermalModel)
+		s, err := simulation.NewSimulation(configuration.CurrentConfig, calibration, profile, model, simulateSampleRate)
+		if err != nil {
+			return err
+		}
+
+		ui.Info("Simulating %v...", profile.Duration())
+		samples, err := s.Run()
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if len(simulateFile) > 0 {
+			file, err := os.Create(simulateFile)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if global.IsStructuredOutput() {
+			return global.WriteStructured(out, global.Output, toSimulationSamples(samples))
+		}
+
+		switch simulateFormat {
+		case simulateFormatCsv:
+			return writeSimulationCsv(out, s, samples)
+		default:
+			_, err = fmt.Fprint(out, plotSimulation(s, samples))
+			return err
+		}
+	},
+}
+
+func toSimulationSamples(samples []simulation.Sample) []simulationSample {
+	result := make([]simulationSample, 0, len(samples))
+	for _, sample := range samples {
+		result = append(result, simulationSample{
+			Time:         sample.Time.Seconds(),
+			Load:         sample.Load,
+			Temperatures: sample.Temperatures,
+			Pwm:          sample.Pwm,
+			Rpm:          sample.Rpm,
+		})
+	}
+	return result
+}
+
+func writeSimulationCsv(out io.Writer, s *simulation.Simulation, samples []simulation.Sample) error {
+	writer := csv.NewWriter(out)
+
+	header := []string{"time", "load"}
+	for _, id := range s.SensorIds() {
+		header = append(header, fmt.Sprintf("%s_temperature", id))
+	}
+	for _, id := range s.FanIds() {
+		header = append(header, fmt.Sprintf("%s_pwm", id), fmt.Sprintf("%s_rpm", id))
+	}
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, sample := range samples {
+		row := []string{
+			strconv.FormatFloat(sample.Time.Seconds(), 'f', -1, 64),
+			strconv.FormatFloat(sample.Load, 'f', 2, 64),
+		}
+		for _, id := range s.SensorIds() {
+			row = append(row, strconv.FormatFloat(sample.Temperatures[id], 'f', 2, 64))
+		}
+		for _, id := range s.FanIds() {
+			row = append(row, strconv.Itoa(sample.Pwm[id]), strconv.Itoa(sample.Rpm[id]))
+		}
+		err = writer.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func plotSimulation(s *simulation.Simulation, samples []simulation.Sample) string {
+	if len(samples) <= 0 {
+		return ""
+	}
+
+	load := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		load = append(load, sample.Load*100)
+	}
+
+	var temperatures [][]float64
+	for _, id := range s.SensorIds() {
+		values := make([]float64, 0, len(samples))
+		for _, sample := range samples {
+			values = append(values, sample.Temperatures[id])
+		}
+		temperatures = append(temperatures, values)
+	}
+
+	var pwms [][]float64
+	for _, id := range s.FanIds() {
+		values := make([]float64, 0, len(samples))
+		for _, sample := range samples {
+			values = append(values, float64(sample.Pwm[id]))
+		}
+		pwms = append(pwms, values)
+	}
+
+	duration := samples[len(samples)-1].Time
+	colors := []asciigraph.AnsiColor{asciigraph.Red, asciigraph.Blue, asciigraph.Green, asciigraph.Yellow, asciigraph.Magenta, asciigraph.Cyan}
+	if global.NoColor {
+		colors = nil
+	}
+
+	result := asciigraph.Plot(load,
+		asciigraph.Height(5), asciigraph.Width(100),
+		asciigraph.LowerBound(0), asciigraph.UpperBound(100),
+		asciigraph.Caption(fmt.Sprintf("Load %% over %v", duration)),
+	) + "\n\n"
+	if len(temperatures) > 0 {
+		result += asciigraph.PlotMany(temperatures,
+			asciigraph.Height(15), asciigraph.Width(100),
+			asciigraph.SeriesColors(colors...), asciigraph.SeriesLegends(s.SensorIds()...),
+			asciigraph.Caption(fmt.Sprintf("Temperature °C over %v", duration)),
+		) + "\n\n"
+	}
+	if len(pwms) > 0 {
+		result += asciigraph.PlotMany(pwms,
+			asciigraph.Height(15), asciigraph.Width(100),
+			asciigraph.LowerBound(0), asciigraph.UpperBound(255),
+			asciigraph.SeriesColors(colors...), asciigraph.SeriesLegends(s.FanIds()...),
+			asciigraph.Caption(fmt.Sprintf("PWM over %v", duration)),
+		) + "\n"
+	}
+	return result
+}
+
+func init() {
+	simulateCmd.Flags().StringVarP(&simulateLoadProfile, "load", "l", simulation.DefaultLoadProfile, "Load profile to simulate")
+	simulateCmd.Flags().StringVar(&simulateFormat, "format", simulateFormatPlot, "Output format, one of: csv, plot")
+	simulateCmd.Flags().StringVarP(&simulateFile, "file", "f", "", "Write the output to the given file instead of stdout")
+	simulateCmd.Flags().DurationVar(&simulateSampleRate, "sample-rate", time.Second, "Interval between two samples of the output")
+	simulateCmd.Flags().BoolVar(&simulateSynthetic, "synthetic", false, "Always use synthetic fan curves, instead of the measured ones from persistence")
+	simulateCmd.Flags().Float64Var(&simulateThermalModel.Ambient, "ambient", simulateThermalModel.Ambient, "Ambient temperature in °C")
+	simulateCmd.Flags().Float64Var(&simulateThermalModel.LoadRise, "load-rise", simulateThermalModel.LoadRise, "Temperature rise in °C above ambient at full load, without any cooling")
+	simulateCmd.Flags().Float64Var(&simulateThermalModel.Cooling, "cooling", simulateThermalModel.Cooling, "Fraction of the temperature rise removed when the fans run at full speed")
+	simulateCmd.Flags().DurationVar(&simulateThermalModel.TimeConstant, "time-constant", simulateThermalModel.TimeConstant, "Time constant of the thermal model")
+
+	rootCmd.AddCommand(simulateCmd)
+}
@@ -0,0 +1,143 @@
+// Sample fan2go plugin, providing a simulated fan and a temperature sensor, which heats up
+// while the fan is slow and cools down while it is fast.
+//
+// Usage:
+//
+//	go run ./examples/plugin -socket /run/fan2go/sample.sock
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/plugin"
+)
+
+const (
+	fanId    = "fan1"
+	sensorId = "temp1"
+)
+
+type sampleHandler struct {
+	mu          sync.Mutex
+	pwm         int
+	controlMode int
+	temperature float64
+	lastUpdate  time.Time
+}
+
+func (h *sampleHandler) Describe() plugin.Description {
+	return plugin.Description{
+		Fans: []plugin.FanDescription{
+			{Id: fanId, Features: []string{plugin.FeatureRpmSensor, plugin.FeatureControlMode}},
+		},
+		Sensors: []plugin.SensorDescription{
+			{Id: sensorId},
+		},
+	}
+}
+
+func (h *sampleHandler) GetPwm(id string) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if id != fanId {
+		return 0, fmt.Errorf("unknown fan: %s", id)
+	}
+	return h.pwm, nil
+}
+
+func (h *sampleHandler) SetPwm(id string, pwm int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if id != fanId {
+		return fmt.Errorf("unknown fan: %s", id)
+	}
+	if pwm < 0 || pwm > 255 {
+		return fmt.Errorf("invalid pwm: %d", pwm)
+	}
+	h.update()
+	h.pwm = pwm
+	return nil
+}
+
+func (h *sampleHandler) GetRpm(id string) (int, error) {
+	pwm, err := h.GetPwm(id)
+	return pwm * 8, err
+}
+
+func (h *sampleHandler) GetPwmEnabled(id string) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if id != fanId {
+		return 0, fmt.Errorf("unknown fan: %s", id)
+	}
+	return h.controlMode, nil
+}
+
+func (h *sampleHandler) SetPwmEnabled(id string, mode int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if id != fanId {
+		return fmt.Errorf("unknown fan: %s", id)
+	}
+	h.controlMode = mode
+	return nil
+}
+
+func (h *sampleHandler) GetValue(id string) (float64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if id != sensorId {
+		return 0, fmt.Errorf("unknown sensor: %s", id)
+	}
+	h.update()
+	return h.temperature, nil
+}
+
+// update simulates the temperature since the last update, values are in millidegree Celsius
+func (h *sampleHandler) update() {
+	now := time.Now()
+	dt := now.Sub(h.lastUpdate).Seconds()
+	h.lastUpdate = now
+
+	heating := 500.0
+	cooling := float64(h.pwm) / 255 * 1000
+	h.temperature += (heating - cooling) * dt
+	h.temperature = max(30000, min(90000, h.temperature))
+}
+
+func main() {
+	socket := flag.String("socket", "/run/fan2go/sample.sock", "path of the unix socket to listen on")
+	flag.Parse()
+
+	_ = os.Remove(*socket)
+	listener, err := net.Listen("unix", *socket)
+	if err != nil {
+		log.Fatalf("unable to listen on %s: %v", *socket, err)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		_ = listener.Close()
+	}()
+
+	handler := &sampleHandler{
+		controlMode: 2,
+		temperature: 40000,
+		lastUpdate:  time.Now(),
+	}
+	log.Printf("listening on %s", *socket)
+	err = plugin.Serve(listener, handler)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
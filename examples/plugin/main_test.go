@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplePlugin(t *testing.T) {
+	// GIVEN
+	socket := path.Join(t.TempDir(), "sample.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		_ = plugin.Serve(listener, &sampleHandler{
+			controlMode: 2,
+			temperature: 40000,
+			lastUpdate:  time.Now(),
+		})
+	}()
+
+	client := plugin.NewClient(configuration.PluginConfig{
+		ID:     "sample",
+		Socket: socket,
+	})
+	defer client.Close()
+
+	// WHEN
+	description, err := client.Describe()
+
+	// THEN
+	assert.NoError(t, err)
+	fan, ok := description.GetFan(fanId)
+	assert.True(t, ok)
+	assert.True(t, fan.Supports(plugin.FeatureControlMode))
+
+	// WHEN
+	err = client.SetPwm(fanId, 100)
+
+	// THEN
+	assert.NoError(t, err)
+	rpm, err := client.GetRpm(fanId)
+	assert.NoError(t, err)
+	assert.Equal(t, 800, rpm)
+
+	temperature, err := client.GetValue(sensorId)
+	assert.NoError(t, err)
+	assert.InDelta(t, 40000, temperature, 100)
+
+	err = client.SetPwm(fanId, 300)
+	assert.EqualError(t, err, "plugin sample: fan.setPwm: invalid pwm: 300 (-32000)")
+}
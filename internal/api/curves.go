@@ -1,29 +1,44 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
 	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/sensors"
 	"net/http"
 )
 
-func registerCurveEndpoints(rest *echo.Echo) {
+func registerCurveEndpoints(rest *echo.Echo, controllers []controller.FanController) {
 	group := rest.Group("/curve")
 
 	group.GET("/", getCurves)
 	group.GET("/:"+urlParamId+"/", getCurve)
 	group.POST("/", createCurve)
+	group.PUT("/:"+urlParamId+"/", func(c echo.Context) error {
+		return updateCurve(c, controllers)
+	})
 	group.DELETE("/:"+urlParamId+"/", deleteCurve)
 }
 
+type UpdateCurve struct {
+	// Values maps paths within the curve config, like "linear.steps.60", to their new value
+	Values map[string]interface{} `json:"values"`
+	// Apply replaces the running curve with the updated one, otherwise only the config file is changed
+	Apply bool `json:"apply"`
+}
+
 func getCurves(c echo.Context) error {
-	data := curves.SpeedCurveMap
+	data := curves.SpeedCurves()
 	return c.JSONPretty(http.StatusOK, data, indentationChar)
 }
 
 func getCurve(c echo.Context) error {
 	id := c.Param(urlParamId)
-	data, exists := curves.SpeedCurveMap[id]
+	data, exists := curves.GetSpeedCurve(id)
 	if !exists {
 		return returnNotFound(c, id)
 	} else {
@@ -38,3 +53,89 @@ func deleteCurve(c echo.Context) error {
 func createCurve(c echo.Context) error {
 	return returnError(c, errors.New("not yet supported"))
 }
+
+// updateCurve changes the config of a curve in the config file and optionally applies it to the running daemon
+func updateCurve(c echo.Context, controllers []controller.FanController) error {
+	if !configuration.CurrentConfig.Api.AllowWrite {
+		return returnForbidden(c, errors.New("changing the config is disabled, set api.allowWrite to true to enable it"))
+	}
+
+	id := c.Param(urlParamId)
+	if _, exists := curves.GetSpeedCurve(id); !exists {
+		return returnNotFound(c, id)
+	}
+
+	body := UpdateCurve{}
+	if err := c.Bind(&body); err != nil || len(body.Values) <= 0 {
+		return returnBadRequest(c, errors.New("request body must be of the form {\"values\": {\"<path>\": <value>}, \"apply\": <bool>}"))
+	}
+
+	values := map[string]string{}
+	for path, value := range body.Values {
+		// json is valid yaml
+		text, err := json.Marshal(value)
+		if err != nil {
+			return returnBadRequest(c, err)
+		}
+		values[fmt.Sprintf("curves.%s.%s", id, path)] = string(text)
+	}
+
+	edit, err := configuration.EditConfig(configuration.GetFilePath(), values)
+	if err != nil {
+		return returnBadRequest(c, err)
+	}
+
+	var curve curves.SpeedCurve
+	if body.Apply {
+		curve, err = newRunnableCurve(edit.Config, id)
+		if err != nil {
+			return returnBadRequest(c, err)
+		}
+	}
+
+	if err = edit.Write(); err != nil {
+		return returnError(c, err)
+	}
+
+	if !body.Apply {
+		// the running curve stays unchanged until fan2go is restarted
+		data, _ := curves.GetSpeedCurve(id)
+		return c.JSONPretty(http.StatusOK, data, indentationChar)
+	}
+
+	curves.ReplaceSpeedCurve(curve)
+	for _, fanController := range controllers {
+		if fanController.GetCurveId() == id {
+			fanController.SetCurve(curve)
+		}
+	}
+	return c.JSONPretty(http.StatusOK, curve, indentationChar)
+}
+
+// newRunnableCurve creates the curve with the given id, if everything it depends on is running already
+func newRunnableCurve(config configuration.Configuration, id string) (curves.SpeedCurve, error) {
+	for _, curveConfig := range config.Curves {
+		if curveConfig.ID != id {
+			continue
+		}
+
+		var sensorId string
+		switch {
+		case curveConfig.Linear != nil:
+			sensorId = curveConfig.Linear.Sensor
+		case curveConfig.PID != nil:
+			sensorId = curveConfig.PID.Sensor
+		case curveConfig.Function != nil:
+			for _, curveId := range curveConfig.Function.Curves {
+				if _, exists := curves.GetSpeedCurve(curveId); !exists {
+					return nil, fmt.Errorf("curve %s is not running, restart fan2go to apply the change", curveId)
+				}
+			}
+		}
+		if _, exists := sensors.SensorMap[sensorId]; len(sensorId) > 0 && !exists {
+			return nil, fmt.Errorf("sensor %s is not running, restart fan2go to apply the change", sensorId)
+		}
+		return curves.NewSpeedCurve(curveConfig)
+	}
+	return nil, fmt.Errorf("no curve definition with id '%s' found", id)
+}
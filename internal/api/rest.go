@@ -2,6 +2,7 @@ package api
 
 import (
 	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/controller"
 	"net/http"
 )
 
@@ -17,7 +18,7 @@ type (
 	}
 )
 
-func CreateRestService() *echo.Echo {
+func CreateRestService(controllers []controller.FanController) *echo.Echo {
 	echoRest := CreateWebserver()
 
 	echoRest.GET("/alive/", isAlive)
@@ -26,7 +27,7 @@ func CreateRestService() *echo.Echo {
 	// Group level middleware
 	registerFanEndpoints(echoRest)
 	registerSensorEndpoints(echoRest)
-	registerCurveEndpoints(echoRest)
+	registerCurveEndpoints(echoRest, controllers)
 	//registerWebsocketEndpoint(echoRest)
 
 	return echoRest
@@ -52,3 +53,19 @@ func returnError(c echo.Context, e error) (err error) {
 		Message: e.Error(),
 	}, indentationChar)
 }
+
+// return a "bad request" message
+func returnBadRequest(c echo.Context, e error) (err error) {
+	return c.JSONPretty(http.StatusBadRequest, &Result{
+		Name:    "Bad Request",
+		Message: e.Error(),
+	}, indentationChar)
+}
+
+// return a "forbidden" message
+func returnForbidden(c echo.Context, e error) (err error) {
+	return c.JSONPretty(http.StatusForbidden, &Result{
+		Name:    "Forbidden",
+		Message: e.Error(),
+	}, indentationChar)
+}
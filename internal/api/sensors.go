@@ -2,6 +2,7 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"net/http"
@@ -14,10 +15,18 @@ func registerSensorEndpoints(rest *echo.Echo) {
 	group.GET("/:"+urlParamId+"/", getSensor)
 	group.POST("/", createSensor)
 	group.DELETE("/:"+urlParamId+"/", deleteSensor)
+	group.POST("/:"+urlParamId+"/value/", pushSensorValue)
+}
+
+type PushSensorValue struct {
+	Value *float64 `json:"value"`
 }
 
 func getSensors(c echo.Context) error {
-	data := sensors.SensorMap
+	data := map[string]interface{}{}
+	for id, sensor := range sensors.SensorMap {
+		data[id] = sensorSnapshot(sensor)
+	}
 	return c.JSONPretty(http.StatusOK, data, indentationChar)
 }
 
@@ -28,7 +37,20 @@ func getSensor(c echo.Context) error {
 	if !exists {
 		return returnNotFound(c, id)
 	} else {
-		return c.JSONPretty(http.StatusOK, data, indentationChar)
+		return c.JSONPretty(http.StatusOK, sensorSnapshot(data), indentationChar)
+	}
+}
+
+// returns a copy of the state of sensors whose value is updated concurrently by external systems,
+// so it can be serialized without racing against those updates
+func sensorSnapshot(sensor sensors.Sensor) interface{} {
+	switch s := sensor.(type) {
+	case *sensors.PushSensor:
+		return s.State()
+	case *sensors.MqttSensor:
+		return s.State()
+	default:
+		return sensor
 	}
 }
 
@@ -39,3 +61,26 @@ func createSensor(c echo.Context) error {
 func deleteSensor(c echo.Context) error {
 	return returnError(c, errors.New("not yet supported"))
 }
+
+// updates the value of a push sensor
+func pushSensorValue(c echo.Context) error {
+	id := c.Param(urlParamId)
+
+	data, exists := sensors.SensorMap[id]
+	if !exists {
+		return returnNotFound(c, id)
+	}
+
+	pushSensor, ok := data.(*sensors.PushSensor)
+	if !ok {
+		return returnBadRequest(c, fmt.Errorf("sensor '%s' is not a push sensor", id))
+	}
+
+	body := PushSensorValue{}
+	if err := c.Bind(&body); err != nil || body.Value == nil {
+		return returnBadRequest(c, errors.New("request body must be of the form {\"value\": <number>}"))
+	}
+
+	state := pushSensor.PushValue(*body.Value)
+	return c.JSONPretty(http.StatusOK, state, indentationChar)
+}
@@ -8,7 +8,6 @@ import (
 	"os"
 	"os/signal"
 	"os/user"
-	"regexp"
 	"syscall"
 	"time"
 
@@ -19,6 +18,7 @@ import (
 	"github.com/markusressel/fan2go/internal/curves"
 	"github.com/markusressel/fan2go/internal/fans"
 	"github.com/markusressel/fan2go/internal/hwmon"
+	"github.com/markusressel/fan2go/internal/mqtt"
 	"github.com/markusressel/fan2go/internal/persistence"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/statistics"
@@ -37,7 +37,11 @@ func RunDaemon() {
 
 	pers := persistence.NewPersistence(configuration.CurrentConfig.DbPath)
 
-	fanControllers := initializeObjects(pers)
+	fanControllers, watcher := initializeObjects(pers)
+	controllers := make([]controller.FanController, 0, len(fanControllers))
+	for _, c := range fanControllers {
+		controllers = append(controllers, c)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -78,7 +82,7 @@ func RunDaemon() {
 			g.Add(func() error {
 				ui.Info("Starting Webserver...")
 
-				servers := createWebServer()
+				servers := createWebServer(controllers)
 
 				<-ctx.Done()
 				ui.Debug("Stopping all webservers...")
@@ -101,6 +105,75 @@ func RunDaemon() {
 			})
 		}
 	}
+	{
+		// === MQTT
+		if configuration.CurrentConfig.Mqtt.Enabled {
+			mqttConfig := configuration.CurrentConfig.Mqtt
+			service := mqtt.NewService(mqttConfig, mqtt.NewClient(mqttConfig), controllers)
+
+			g.Add(func() error {
+				// MQTT is optional, so its errors must not stop fan control
+				if err := service.Run(ctx); err != nil {
+					ui.Error("MQTT service stopped: %v", err)
+					<-ctx.Done()
+				}
+				return nil
+			}, func(err error) {
+				if err != nil {
+					ui.Warning("Error running MQTT service: %v", err)
+				}
+			})
+		}
+	}
+	{
+		// === trace recording
+		if configuration.CurrentConfig.Record.Enabled {
+			recordConfig := configuration.CurrentConfig.Record
+			rec := NewControllerRecorder(recordConfig.File, recordConfig.Interval, controllers)
+
+			g.Add(func() error {
+				// recording is optional, so its errors must not stop fan control
+				if err := rec.Run(ctx); err != nil {
+					ui.Error("Recorder stopped: %v", err)
+					<-ctx.Done()
+				}
+				return nil
+			}, func(err error) {
+				if err != nil {
+					ui.Warning("Error recording trace: %v", err)
+				}
+			})
+		}
+	}
+	{
+		// === hwmon hotplug
+		if configuration.CurrentConfig.HwMonPollingRate > 0 {
+			g.Add(func() error {
+				return watcher.Run(ctx)
+			}, func(err error) {
+				if err != nil {
+					ui.Warning("Error watching hwmon devices: %v", err)
+				}
+			})
+		}
+	}
+	{
+		// === background sensors
+		for _, sensor := range sensors.SensorMap {
+			s, ok := sensor.(sensors.BackgroundSensor)
+			if !ok {
+				continue
+			}
+
+			g.Add(func() error {
+				return s.Run(ctx)
+			}, func(err error) {
+				if err != nil {
+					ui.Warning("Error updating sensor %s: %v", s.GetId(), err)
+				}
+			})
+		}
+	}
 	{
 		// === sensor monitoring
 		for _, sensor := range sensors.SensorMap {
@@ -160,7 +233,9 @@ func RunDaemon() {
 		})
 	}
 
-	if err := g.Run(); err != nil {
+	err = g.Run()
+	util.StopCoprocesses()
+	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	} else {
@@ -169,11 +244,63 @@ func RunDaemon() {
 	}
 }
 
-func createWebServer() []*echo.Echo {
+// RunRecorder records the values of all configured sensors and fans to the trace file at the given path,
+// until the given duration has passed (0 = forever) or the process is interrupted. Fans are not controlled.
+func RunRecorder(path string, interval time.Duration, duration time.Duration) error {
+	InitializeDevices()
+	defer util.StopCoprocesses()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if duration > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, duration)
+		defer cancelTimeout()
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sig)
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	startBackgroundSensors(ctx)
+	return NewRecorder(path, interval).Run(ctx)
+}
+
+// startBackgroundSensors updates the values of all sensors which fetch them in the background, until the given context is done
+func startBackgroundSensors(ctx context.Context) {
+	for _, sensor := range sensors.SensorMap {
+		s, ok := sensor.(sensors.BackgroundSensor)
+		if !ok {
+			continue
+		}
+		go func() {
+			if err := s.Run(ctx); err != nil {
+				ui.Warning("Error updating sensor %s: %v", s.GetId(), err)
+			}
+		}()
+	}
+}
+
+// InitializeDevices creates all configured sensors and fans, without starting any controllers
+func InitializeDevices() {
+	controllers := hwmon.GetChips()
+	watcher := hwmon.NewWatcher(configuration.CurrentConfig.HwMonPollingRate, hwmon.GetChips)
+	initializeSensors(controllers, watcher)
+	initializeFans(controllers, watcher)
+}
+
+func createWebServer(controllers []controller.FanController) []*echo.Echo {
 	result := []*echo.Echo{}
 	// Setup Main Server
 	if configuration.CurrentConfig.Api.Enabled {
-		result = append(result, startRestServer())
+		result = append(result, startRestServer(controllers))
 	}
 
 	if configuration.CurrentConfig.Statistics.Enabled {
@@ -183,10 +310,10 @@ func createWebServer() []*echo.Echo {
 	return result
 }
 
-func startRestServer() *echo.Echo {
+func startRestServer(controllers []controller.FanController) *echo.Echo {
 	ui.Info("Starting REST api server...")
 
-	restServer := api.CreateRestService()
+	restServer := api.CreateRestService(controllers)
 
 	go func() {
 		apiConfig := configuration.CurrentConfig.Api
@@ -217,32 +344,23 @@ func startStatisticsServer() *echo.Echo {
 	return echoPrometheus
 }
 
-func initializeObjects(pers persistence.Persistence) map[fans.Fan]controller.FanController {
+func initializeObjects(pers persistence.Persistence) (map[fans.Fan]controller.FanController, *hwmon.Watcher) {
 	controllers := hwmon.GetChips()
+	watcher := hwmon.NewWatcher(configuration.CurrentConfig.HwMonPollingRate, hwmon.GetChips)
 
-	initializeSensors(controllers)
+	initializeSensors(controllers, watcher)
 	initializeCurves()
 
 	var result = map[fans.Fan]controller.FanController{}
 
-	for config, fan := range initializeFans(controllers) {
+	for config, fan := range initializeFans(controllers, watcher) {
 		updateRate := configuration.CurrentConfig.ControllerAdjustmentTickRate
 
-		var pidLoop util.PidLoop
-		if config.ControlLoop != nil {
-			pidLoop = *util.NewPidLoop(
-				config.ControlLoop.P,
-				config.ControlLoop.I,
-				config.ControlLoop.D,
-			)
-		} else {
-			pidLoop = *util.NewPidLoop(
-				0.03,
-				0.002,
-				0.0005,
-			)
+		controlLoop, err := controller.NewControlLoop(config.ControlLoop, nil)
+		if err != nil {
+			ui.Fatal("Unable to create control loop of fan %s: %v", config.ID, err)
 		}
-		fanController := controller.NewFanController(pers, fan, pidLoop, updateRate)
+		fanController := controller.NewFanController(pers, fan, controlLoop, updateRate)
 		result[fan] = fanController
 	}
 
@@ -253,26 +371,18 @@ func initializeObjects(pers persistence.Persistence) map[fans.Fan]controller.Fan
 	controllerCollector := statistics.NewControllerCollector(fanControllers)
 	statistics.Register(controllerCollector)
 
-	return result
+	return result, watcher
 }
 
-func initializeSensors(controllers []*hwmon.HwMonController) {
+func initializeSensors(controllers []*hwmon.HwMonController, watcher *hwmon.Watcher) {
 	var sensorList []sensors.Sensor
 	for _, config := range configuration.CurrentConfig.Sensors {
+		var hwMonSelector configuration.HwMonSensorConfig
 		if config.HwMon != nil {
-			found := false
-			for _, c := range controllers {
-				matched, err := regexp.MatchString("(?i)"+config.HwMon.Platform, c.Platform)
-				if err != nil {
-					ui.Fatal("Failed to match platform regex of %s (%s) against controller platform %s", config.ID, config.HwMon.Platform, c.Platform)
-				}
-				if matched {
-					found = true
-					config.HwMon.TempInput = c.Sensors[config.HwMon.Index].Input
-				}
-			}
-			if !found {
-				ui.Fatal("Couldn't find hwmon device with platform '%s' for sensor: %s. Run 'fan2go detect' again and correct any mistake.", config.HwMon.Platform, config.ID)
+			hwMonSelector = *config.HwMon
+			err := hwmon.UpdateSensorConfigFromHwMonControllers(controllers, &config)
+			if err != nil {
+				ui.Fatal("%v", err)
 			}
 		}
 
@@ -281,6 +391,9 @@ func initializeSensors(controllers []*hwmon.HwMonController) {
 			ui.Fatal("Unable to process sensor configuration: %s", config.ID)
 		}
 		sensorList = append(sensorList, sensor)
+		if hwmonSensor, ok := sensor.(*sensors.HwmonSensor); ok {
+			watcher.AddSensor(hwMonSelector, hwmonSensor)
+		}
 
 		currentValue, err := sensor.GetValue()
 		if err != nil {
@@ -303,20 +416,22 @@ func initializeCurves() {
 			ui.Fatal("Unable to process curve configuration: %s", config.ID)
 		}
 		curveList = append(curveList, curve)
-		curves.SpeedCurveMap[config.ID] = curve
+		curves.ReplaceSpeedCurve(curve)
 	}
 
 	curveCollector := statistics.NewCurveCollector(curveList)
 	statistics.Register(curveCollector)
 }
 
-func initializeFans(controllers []*hwmon.HwMonController) map[configuration.FanConfig]fans.Fan {
+func initializeFans(controllers []*hwmon.HwMonController, watcher *hwmon.Watcher) map[configuration.FanConfig]fans.Fan {
 	var result = map[configuration.FanConfig]fans.Fan{}
 
 	var fanList []fans.Fan
 
 	for _, config := range configuration.CurrentConfig.Fans {
+		var hwMonSelector configuration.HwMonFanConfig
 		if config.HwMon != nil {
+			hwMonSelector = *config.HwMon
 			err := hwmon.UpdateFanConfigFromHwMonControllers(controllers, &config)
 			if err != nil {
 				ui.Fatal("Couldn't update fan config from hwmon: %s", err)
@@ -329,6 +444,9 @@ func initializeFans(controllers []*hwmon.HwMonController) map[configuration.FanC
 		}
 		fans.FanMap[config.ID] = fan
 		result[config] = fan
+		if hwMonFan, ok := fan.(*fans.HwMonFan); ok {
+			watcher.AddFan(hwMonSelector, hwMonFan)
+		}
 
 		fanList = append(fanList, fan)
 	}
@@ -4,4 +4,6 @@ type ApiConfig struct {
 	Enabled bool   `json:"enabled"`
 	Host    string `json:"host"`
 	Port    int    `json:"port"`
+	// AllowWrite enables endpoints which change the config file, like PUT /curve/<id>
+	AllowWrite bool `json:"allowWrite"`
 }
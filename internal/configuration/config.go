@@ -11,6 +11,8 @@ import (
 
 type Configuration struct {
 	DbPath string `json:"dbPath"`
+	// SysfsRoot is the directory sysfs is mounted at, hwmon devices are discovered below it
+	SysfsRoot string `json:"sysfsRoot"`
 
 	RunFanInitializationInParallel bool    `json:"runFanInitializationInParallel"`
 	MaxRpmDiffForSettledFan        float64 `json:"maxRpmDiffForSettledFan"`
@@ -24,11 +26,17 @@ type Configuration struct {
 
 	ControllerAdjustmentTickRate time.Duration `json:"controllerAdjustmentTickRate"`
 
+	// HwMonPollingRate is the rate at which hwmon devices are checked for disappearing and reappearing, 0 disables the check
+	HwMonPollingRate time.Duration `json:"hwMonPollingRate"`
+
 	Fans    []FanConfig    `json:"fans"`
 	Sensors []SensorConfig `json:"sensors"`
 	Curves  []CurveConfig  `json:"curves"`
+	Plugins []PluginConfig `json:"plugins"`
 
 	Api        ApiConfig        `json:"api"`
+	Mqtt       MqttConfig       `json:"mqtt"`
+	Record     RecordConfig     `json:"record"`
 	Statistics StatisticsConfig `json:"statistics"`
 	Profiling  ProfilingConfig  `json:"profiling"`
 }
@@ -57,45 +65,73 @@ func InitConfig(cfgFile string) {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
-	setDefaultValues()
+	setDefaultValues(viper.GetViper())
 }
 
-func setDefaultValues() {
-	viper.SetDefault("dbpath", "/etc/fan2go/fan2go.db")
-	viper.SetDefault("RunFanInitializationInParallel", true)
-	viper.SetDefault("MaxRpmDiffForSettledFan", 20.0)
-	viper.SetDefault("FanResponseDelay", 2)
-	viper.SetDefault("TempSensorPollingRate", 200*time.Millisecond)
-	viper.SetDefault("TempRollingWindowSize", 10)
-	viper.SetDefault("RpmPollingRate", 1*time.Second)
-	viper.SetDefault("RpmRollingWindowSize", 10)
-
-	viper.SetDefault("Statistics", StatisticsConfig{
+func setDefaultValues(v *viper.Viper) {
+	v.SetDefault("dbpath", "/etc/fan2go/fan2go.db")
+	v.SetDefault("SysfsRoot", "/sys")
+	v.SetDefault("RunFanInitializationInParallel", true)
+	v.SetDefault("MaxRpmDiffForSettledFan", 20.0)
+	v.SetDefault("FanResponseDelay", 2)
+	v.SetDefault("TempSensorPollingRate", 200*time.Millisecond)
+	v.SetDefault("TempRollingWindowSize", 10)
+	v.SetDefault("RpmPollingRate", 1*time.Second)
+	v.SetDefault("RpmRollingWindowSize", 10)
+
+	v.SetDefault("Statistics", StatisticsConfig{
 		Enabled: false,
 		Port:    9000,
 	})
-	viper.SetDefault("Statistics.Port", 9000)
+	v.SetDefault("Statistics.Port", 9000)
 
-	viper.SetDefault("Api", ApiConfig{
-		Enabled: false,
-		Host:    "localhost",
-		Port:    9001,
+	v.SetDefault("Api", ApiConfig{
+		Enabled:    false,
+		Host:       "localhost",
+		Port:       9001,
+		AllowWrite: false,
+	})
+	v.SetDefault("Api.Host", "localhost")
+	v.SetDefault("Api.Port", 9001)
+	v.SetDefault("Api.AllowWrite", false)
+
+	v.SetDefault("Mqtt", MqttConfig{
+		Enabled:         false,
+		Broker:          "tcp://localhost:1883",
+		ClientId:        "fan2go",
+		TopicPrefix:     "fan2go",
+		PublishInterval: 10 * time.Second,
+		HomeAssistant: MqttHomeAssistantConfig{
+			Enabled:         true,
+			DiscoveryPrefix: "homeassistant",
+		},
 	})
-	viper.SetDefault("Api.Host", "localhost")
-	viper.SetDefault("Api.Port", 9001)
+	v.SetDefault("Mqtt.Broker", "tcp://localhost:1883")
+	v.SetDefault("Mqtt.ClientId", "fan2go")
+	v.SetDefault("Mqtt.TopicPrefix", "fan2go")
+	v.SetDefault("Mqtt.PublishInterval", 10*time.Second)
+	v.SetDefault("Mqtt.HomeAssistant.Enabled", true)
+	v.SetDefault("Mqtt.HomeAssistant.DiscoveryPrefix", "homeassistant")
+
+	v.SetDefault("Record", RecordConfig{
+		Enabled:  false,
+		Interval: 1 * time.Second,
+	})
+	v.SetDefault("Record.Interval", 1*time.Second)
 
-	viper.SetDefault("Profiling", ProfilingConfig{
+	v.SetDefault("Profiling", ProfilingConfig{
 		Enabled: false,
 		Host:    "localhost",
 		Port:    6060,
 	})
-	viper.SetDefault("Profiling.Host", "localhost")
-	viper.SetDefault("Profiling.Port", 6060)
+	v.SetDefault("Profiling.Host", "localhost")
+	v.SetDefault("Profiling.Port", 6060)
 
-	viper.SetDefault("ControllerAdjustmentTickRate", 200*time.Millisecond)
+	v.SetDefault("ControllerAdjustmentTickRate", 200*time.Millisecond)
+	v.SetDefault("HwMonPollingRate", 2*time.Second)
 
-	viper.SetDefault("sensors", []SensorConfig{})
-	viper.SetDefault("fans", []FanConfig{})
+	v.SetDefault("sensors", []SensorConfig{})
+	v.SetDefault("fans", []FanConfig{})
 }
 
 // DetectAndReadConfigFile detects the path of the first existing config file
@@ -107,9 +143,13 @@ func DetectAndReadConfigFile() string {
 	return GetFilePath()
 }
 
-// readInConfig reads and parses the config file
+// readInConfig reads and parses the config file, merged with its includes and drop-ins
 func readInConfig() error {
-	return viper.ReadInConfig()
+	err := viper.ReadInConfig()
+	if err != nil {
+		return err
+	}
+	return mergeConfigFiles()
 }
 
 // GetFilePath this is only populated _after_ readInConfig()
@@ -1,5 +1,7 @@
 package configuration
 
+import "time"
+
 type CurveConfig struct {
 	ID       string               `json:"id"`
 	Linear   *LinearCurveConfig   `json:"linear,omitempty"`
@@ -20,6 +22,8 @@ type PidCurveConfig struct {
 	P        float64 `json:"p"`
 	I        float64 `json:"i"`
 	D        float64 `json:"d"`
+	// DerivativeFilter is the time constant of the low pass filter applied to the derivative term, 0 disables it
+	DerivativeFilter time.Duration `json:"derivativeFilter,omitempty"`
 }
 
 const (
@@ -0,0 +1,305 @@
+package configuration
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEdit is a validated change of the config file (and its includes or drop-ins), which has not been written yet
+type ConfigEdit struct {
+	// Config is the configuration resulting from the change
+	Config Configuration
+	// files contains the new content of all changed files
+	files map[string][]byte
+}
+
+// EditConfig sets the given values in the config file at the given path, keeping comments and ordering as is.
+// Values are addressed by dot separated paths like "curves.cpu_curve.linear.steps.60", entries of lists
+// are selected by their id, by a key of their entries (like the steps of a curve) or by their index.
+// Every value is changed in the file defining it, which can also be an included file or a drop-in.
+// The resulting configuration is validated, nothing is written until Write is called.
+func EditConfig(configPath string, values map[string]string) (*ConfigEdit, error) {
+	document, err := readConfigDocument(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	roots := map[string]*yaml.Node{}
+	for _, path := range paths {
+		segments := strings.Split(path, ".")
+		file := document.fileOf(segments)
+		root, ok := roots[file]
+		if !ok {
+			root, err = readYamlFile(file)
+			if err != nil {
+				return nil, err
+			}
+			roots[file] = root
+		}
+
+		value, err := parseValue(values[path])
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid value '%s': %v", path, values[path], err)
+		}
+		if err = setPathValue(root.Content[0], segments, value); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	edit := &ConfigEdit{files: map[string][]byte{}}
+	for file, root := range roots {
+		data, err := encodeNode(root)
+		if err != nil {
+			return nil, err
+		}
+		edit.files[file] = data
+	}
+
+	merged, err := readConfigDocumentWithOverrides(configPath, edit.files)
+	if err != nil {
+		return nil, err
+	}
+	edit.Config, err = decodeConfig(merged.root)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateConfig(&edit.Config, configPath); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%v", err)
+	}
+	return edit, nil
+}
+
+// Files returns the files changed by this edit
+func (e *ConfigEdit) Files() []string {
+	files := make([]string, 0, len(e.files))
+	for file := range e.files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Write replaces all changed files atomically
+func (e *ConfigEdit) Write() error {
+	for _, file := range e.Files() {
+		if err := writeFileAtomically(file, e.files[file]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileOf returns the file defining the value at the given path, or its closest existing parent
+func (d *configDocument) fileOf(path []string) string {
+	file := d.files[0]
+	node := d.root
+	for _, segment := range path {
+		node = selectChild(node, segment)
+		if node == nil {
+			break
+		}
+		if origin, ok := d.origins[node]; ok {
+			file = origin
+		}
+	}
+	return file
+}
+
+// readYamlFile reads the given file as yaml document node, which always contains a mapping
+func readYamlFile(file string) (*yaml.Node, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	root := &yaml.Node{}
+	if err = yaml.Unmarshal(data, root); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if len(root.Content) <= 0 {
+		root = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: config file must contain a mapping", file)
+	}
+	return root, nil
+}
+
+// parseValue parses the given text as yaml value, f.ex. "180", "true", "cpu" or "[cpu, gpu]"
+func parseValue(text string) (*yaml.Node, error) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal([]byte(text), &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) <= 0 {
+		return scalarNode("!!str", ""), nil
+	}
+	return document.Content[0], nil
+}
+
+// selectChild returns the value of the given path segment within a mapping or sequence node, or nil if it doesn't exist
+func selectChild(node *yaml.Node, segment string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return findMappingValue(node, segment)
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			id := findMappingValue(item, "id")
+			if id != nil && id.Value == segment {
+				return item
+			}
+		}
+		if isListOfEntries(node) {
+			return findMappingValue(node, segment)
+		}
+		if idx, err := strconv.Atoi(segment); err == nil && idx >= 0 && idx < len(node.Content) {
+			return node.Content[idx]
+		}
+	}
+	return nil
+}
+
+// isListOfEntries returns true if the given sequence node only contains single entry mappings
+// without id, like curve steps written as "- 40: 0"
+func isListOfEntries(node *yaml.Node) bool {
+	if len(node.Content) <= 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode || len(item.Content) != 2 || findMappingValue(item, "id") != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// setPathValue sets the value at the given path below node, missing mapping keys are created
+func setPathValue(node *yaml.Node, path []string, value *yaml.Node) error {
+	for idx, segment := range path {
+		child := selectChild(node, segment)
+		if child == nil {
+			return addPathValue(node, path[:idx], path[idx:], value)
+		}
+		node = child
+	}
+	if node.Kind != value.Kind && node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("cannot replace a %s with a %s", kindName(node.Kind), kindName(value.Kind))
+	}
+
+	// keep comments and the position of the replaced value
+	value.HeadComment = node.HeadComment
+	value.LineComment = node.LineComment
+	value.FootComment = node.FootComment
+	*node = *value
+	return nil
+}
+
+// addPathValue creates the missing part of a path below node
+func addPathValue(node *yaml.Node, existing []string, missing []string, value *yaml.Node) error {
+	for idx := len(missing) - 1; idx > 0; idx-- {
+		value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{keyNode(missing[idx]), value}}
+	}
+
+	switch {
+	case node.Kind == yaml.MappingNode:
+		node.Content = append(node.Content, keyNode(missing[0]), value)
+		return nil
+	case node.Kind == yaml.SequenceNode && isListOfEntries(node):
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{keyNode(missing[0]), value}})
+		return nil
+	case node.Kind == yaml.SequenceNode:
+		return fmt.Errorf("no entry '%s' found in %s", missing[0], strings.Join(existing, "."))
+	default:
+		return fmt.Errorf("%s is not a mapping or list", strings.Join(existing, "."))
+	}
+}
+
+// keyNode returns a mapping key node for the given path segment, numeric segments (like curve steps) stay numeric
+func keyNode(segment string) *yaml.Node {
+	if _, err := strconv.Atoi(segment); err == nil {
+		return scalarNode("!!int", segment)
+	}
+	return scalarNode("!!str", segment)
+}
+
+func kindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	default:
+		return "value"
+	}
+}
+
+// decodeConfig decodes the given merged config file node the same way the config file is read at startup, including default values
+func decodeConfig(node *yaml.Node) (Configuration, error) {
+	config := Configuration{}
+	data, err := encodeNode(node)
+	if err != nil {
+		return config, err
+	}
+
+	v := viper.New()
+	setDefaultValues(v)
+	v.SetConfigType("yaml")
+	if err = v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return config, err
+	}
+	err = v.Unmarshal(&config)
+	return config, err
+}
+
+// writeFileAtomically replaces the given file by writing to a temporary file next to it and renaming it,
+// so readers never see a partially written file. Permissions and ownership of the file are kept.
+func writeFileAtomically(path string, data []byte) error {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(file.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		// only possible when running as root, which is required for root owned config files anyway
+		_ = os.Chown(file.Name(), int(stat.Uid), int(stat.Gid))
+	}
+	return os.Rename(file.Name(), path)
+}
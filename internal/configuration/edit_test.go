@@ -0,0 +1,185 @@
+package configuration
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const editTestConfig = `# my config
+sensors:
+  - id: cpu
+    file:
+      path: /tmp/cpu_temp
+fans:
+  - id: cpu
+    curve: cpu_curve # keep me
+    file:
+      path: /tmp/cpu_fan
+curves:
+  - id: cpu_curve
+    linear:
+      sensor: cpu
+      steps:
+        - 40: 0
+        # full speed
+        - 80: 255
+`
+
+func TestEditConfig(t *testing.T) {
+	// GIVEN
+	configPath := path.Join(t.TempDir(), "fan2go.yaml")
+	writeConfigFile(t, configPath, editTestConfig)
+
+	// WHEN
+	edit, err := EditConfig(configPath, map[string]string{
+		"curves.cpu_curve.linear.steps.60": "180",
+		"curves.cpu_curve.linear.steps.80": "250",
+		"fans.cpu.neverStop":               "true",
+		"api.port":                         "9100",
+	})
+	assert.NoError(t, err)
+	err = edit.Write()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, []string{configPath}, edit.Files())
+	assert.Equal(t, map[int]float64{40: 0, 60: 180, 80: 250}, edit.Config.Curves[0].Linear.Steps)
+	assert.Equal(t, 9100, edit.Config.Api.Port)
+	assert.Equal(t, "localhost", edit.Config.Api.Host)
+	data, err := os.ReadFile(configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, `# my config
+sensors:
+  - id: cpu
+    file:
+      path: /tmp/cpu_temp
+fans:
+  - id: cpu
+    curve: cpu_curve # keep me
+    file:
+      path: /tmp/cpu_fan
+    neverStop: true
+curves:
+  - id: cpu_curve
+    linear:
+      sensor: cpu
+      steps:
+        - 40: 0
+        # full speed
+        - 80: 250
+        - 60: 180
+api:
+  port: 9100
+`, string(data))
+	info, err := os.Stat(configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestEditConfigDropIn(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	configPath := path.Join(dir, "fan2go.yaml")
+	writeConfigFile(t, configPath, editTestConfig)
+	dropInPath := path.Join(dir, DropInDirName, "gpu.yaml")
+	writeConfigFile(t, dropInPath, `curves:
+  - id: cpu_curve
+    linear:
+      sensor: cpu
+      min: 40
+      max: 80
+`)
+
+	// WHEN
+	edit, err := EditConfig(configPath, map[string]string{
+		"curves.cpu_curve.linear.max": "90",
+	})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dropInPath}, edit.Files())
+	assert.Equal(t, 90, edit.Config.Curves[0].Linear.Max)
+}
+
+func TestEditConfigInvalid(t *testing.T) {
+	// GIVEN
+	configPath := path.Join(t.TempDir(), "fan2go.yaml")
+	writeConfigFile(t, configPath, editTestConfig)
+
+	// WHEN
+	_, err := EditConfig(configPath, map[string]string{
+		"fans.cpu.curve": "missing",
+	})
+
+	// THEN
+	assert.EqualError(t, err, "invalid configuration:\nfan cpu: no curve definition with id 'missing' found")
+	data, err := os.ReadFile(configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, editTestConfig, string(data))
+}
+
+func TestEditConfigUnknownEntry(t *testing.T) {
+	// GIVEN
+	configPath := path.Join(t.TempDir(), "fan2go.yaml")
+	writeConfigFile(t, configPath, editTestConfig)
+
+	// WHEN
+	_, err := EditConfig(configPath, map[string]string{
+		"curves.gpu_curve.linear.min": "40",
+	})
+
+	// THEN
+	assert.EqualError(t, err, "curves.gpu_curve.linear.min: no entry 'gpu_curve' found in curves")
+}
+
+func TestEditConfigPidGains(t *testing.T) {
+	// GIVEN
+	configPath := path.Join(t.TempDir(), "fan2go.yaml")
+	writeConfigFile(t, configPath, `sensors:
+  - id: cpu
+    file:
+      path: /tmp/cpu_temp
+curves:
+  # the pid curve
+  - id: pid_curve
+    pid:
+      sensor: cpu
+      setPoint: 60
+      p: -0.05
+      # tuned by hand
+      i: -0.005
+`)
+
+	// WHEN
+	edit, err := EditConfig(configPath, map[string]string{
+		"curves.pid_curve.pid.p": "-0.1",
+		"curves.pid_curve.pid.i": "-0.002",
+		"curves.pid_curve.pid.d": "-0.25",
+	})
+	assert.NoError(t, err)
+	err = edit.Write()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, -0.25, edit.Config.Curves[0].PID.D)
+	data, err := os.ReadFile(configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, `sensors:
+  - id: cpu
+    file:
+      path: /tmp/cpu_temp
+curves:
+  # the pid curve
+  - id: pid_curve
+    pid:
+      sensor: cpu
+      setPoint: 60
+      p: -0.1
+      # tuned by hand
+      i: -0.002
+      d: -0.25
+`, string(data))
+}
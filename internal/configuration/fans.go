@@ -1,5 +1,7 @@
 package configuration
 
+import "time"
+
 type FanConfig struct {
 	ID        string `json:"id"`
 	NeverStop bool   `json:"neverStop"`
@@ -8,19 +10,37 @@ type FanConfig struct {
 	// StartPwm defines the lowest PWM value where the fans are able to start spinning from a standstill
 	StartPwm *int `json:"startPwm,omitempty"`
 	// MaxPwm defines the highest PWM value that yields an RPM increase
-	MaxPwm      *int               `json:"maxPwm,omitempty"`
-	PwmMap      *map[int]int       `json:"pwmMap,omitempty"`
-	Curve       string             `json:"curve"`
-	HwMon       *HwMonFanConfig    `json:"hwMon,omitempty"`
-	File        *FileFanConfig     `json:"file,omitempty"`
-	Cmd         *CmdFanConfig      `json:"cmd,omitempty"`
+	MaxPwm *int            `json:"maxPwm,omitempty"`
+	PwmMap *map[int]int    `json:"pwmMap,omitempty"`
+	Curve  string          `json:"curve"`
+	HwMon  *HwMonFanConfig `json:"hwMon,omitempty"`
+	File   *FileFanConfig  `json:"file,omitempty"`
+	Cmd    *CmdFanConfig   `json:"cmd,omitempty"`
+	// CoolingDevice is a fan exposed as thermal cooling device in /sys/class/thermal
+	CoolingDevice *CoolingDeviceFanConfig `json:"coolingDevice,omitempty"`
+	// Discrete is a fan controlled by writing one of a fixed list of levels as text, f.ex. /proc/acpi/ibm/fan
+	Discrete *DiscreteFanConfig `json:"discrete,omitempty"`
+	// Plugin is a fan provided by an external driver plugin
+	Plugin      *PluginFanConfig   `json:"plugin,omitempty"`
 	ControlLoop *ControlLoopConfig `json:"controlLoop,omitempty"`
 }
 
 type HwMonFanConfig struct {
-	Platform      string `json:"platform"`
-	Index         int    `json:"index"`
-	RpmChannel    int    `json:"rpmChannel"`
+	// Platform is a regex matching the platform of the device, as printed by "fan2go detect"
+	Platform string `json:"platform"`
+	// Driver is the name of the kernel driver of the device, f.ex. "amdgpu" or "nvme"
+	Driver string `json:"driver,omitempty"`
+	// Modalias is the modalias of the device
+	Modalias string `json:"modalias,omitempty"`
+	// BusAddress is the PCI address (f.ex. "0000:03:00.0") or USB port (f.ex. "1-2") of the device
+	BusAddress string `json:"busAddress,omitempty"`
+	// Device is the target of the "device" symlink of the hwmon directory
+	Device     string `json:"device,omitempty"`
+	Index      int    `json:"index"`
+	RpmChannel int    `json:"rpmChannel"`
+	// Label is the label of the fan channel, f.ex. "CPU_FAN",
+	// either matching exactly or as a regex matching the whole label
+	Label         string `json:"label,omitempty"`
 	PwmChannel    int    `json:"pwmChannel"`
 	SysfsPath     string
 	RpmInputPath  string
@@ -31,12 +51,53 @@ type HwMonFanConfig struct {
 type FileFanConfig struct {
 	Path    string `json:"path"`
 	RpmPath string `json:"rpmPath"`
+	// Range of the values written to and read from Path, 0..255 if not set
+	Range *FanValueRange `json:"range,omitempty"`
+	// Invert the values written to and read from Path, f.ex. for devices where 0 means full speed
+	Invert bool `json:"invert,omitempty"`
+}
+
+// FanValueRange defines the range of raw values accepted by a fan, onto which the pwm range (0..255) is mapped
+type FanValueRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+type CoolingDeviceFanConfig struct {
+	// Type is the content of the "type" file of the cooling device, f.ex. Fan or pwm-fan
+	Type string `json:"type"`
+}
+
+type DiscreteFanConfig struct {
+	// Path of the file to read the current level from and write new levels to
+	Path string `json:"path"`
+	// Levels is the list of levels supported by the fan, ordered from slowest to fastest
+	Levels []string `json:"levels"`
+	// WriteTemplate is the text written to Path to apply a level, "%level%" is replaced with the level
+	WriteTemplate string `json:"writeTemplate,omitempty"`
+	// ReadPattern is a regular expression used to parse the current level from the content of Path,
+	// the first capture group has to match the level
+	ReadPattern string `json:"readPattern,omitempty"`
+	// RpmPattern is an (optional) regular expression used to parse the current rpm from the content of Path
+	RpmPattern string `json:"rpmPattern,omitempty"`
+	// AutoLevel is the level which hands control back to the firmware, it is applied on shutdown
+	AutoLevel string `json:"autoLevel,omitempty"`
+	// Hysteresis is the distance (in pwm) the target has to move past the boundary of two levels,
+	// before the level is changed
+	Hysteresis int `json:"hysteresis,omitempty"`
 }
 
 type CmdFanConfig struct {
-	SetPwm *ExecConfig `json:"setPwm,omitempty"`
-	GetPwm *ExecConfig `json:"getPwm,omitempty"`
-	GetRpm *ExecConfig `json:"getRpm,omitempty"`
+	// Coprocess is a long-running command, which handles all requests of this fan
+	// using line-delimited JSON over stdin/stdout, instead of setPwm, getPwm and getRpm
+	Coprocess *CoprocessConfig `json:"coprocess,omitempty"`
+	SetPwm    *ExecConfig      `json:"setPwm,omitempty"`
+	GetPwm    *ExecConfig      `json:"getPwm,omitempty"`
+	GetRpm    *ExecConfig      `json:"getRpm,omitempty"`
+	// Range of the values passed to setPwm and returned by getPwm, 0..255 if not set
+	Range *FanValueRange `json:"range,omitempty"`
+	// Invert the values passed to setPwm and returned by getPwm, f.ex. for devices where 0 means full speed
+	Invert bool `json:"invert,omitempty"`
 }
 
 type ExecConfig struct {
@@ -44,8 +105,30 @@ type ExecConfig struct {
 	Args []string `json:"args"`
 }
 
+type CoprocessConfig struct {
+	Exec string   `json:"exec"`
+	Args []string `json:"args"`
+	// Timeout for a single request, 2s if not set
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Rpm enables reading the rpm of a fan from the coprocess
+	Rpm bool `json:"rpm,omitempty"`
+}
+
+const (
+	// ControlLoopTypePid uses a PID loop to approach the target pwm value
+	ControlLoopTypePid = "pid"
+	// ControlLoopTypeDirect applies the target pwm value immediately
+	ControlLoopTypeDirect = "direct"
+	// ControlLoopTypeSlew approaches the target pwm value with a limited rate of change
+	ControlLoopTypeSlew = "slew"
+)
+
 type ControlLoopConfig struct {
-	P float64 `json:"p"`
-	I float64 `json:"i"`
-	D float64 `json:"d"`
+	// Type of the control loop, one of: pid | direct | slew, defaults to pid
+	Type string  `json:"type,omitempty"`
+	P    float64 `json:"p"`
+	I    float64 `json:"i"`
+	D    float64 `json:"d"`
+	// SlewRate is the maximum change of the pwm value per second, used by the slew control loop
+	SlewRate float64 `json:"slewRate,omitempty"`
 }
@@ -0,0 +1,263 @@
+package configuration
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DropInDirName is the name of the directory next to the config file, whose *.yaml files are merged into the config
+	DropInDirName = "conf.d"
+	// includeKey lists further config files (or glob patterns) to merge into a config file
+	includeKey = "include"
+)
+
+// idLists are the top level lists, whose entries are merged by their id instead of being replaced
+var idLists = []string{"fans", "sensors", "curves", "plugins"}
+
+// configDocument is a config file merged with all files it includes and all drop-ins
+type configDocument struct {
+	root *yaml.Node
+	// files contains all files which have been merged, in the order they have been read
+	files []string
+	// origins maps every node to the file it has been read from
+	origins map[*yaml.Node]string
+	// overrides contains file contents to use instead of the file on disk
+	overrides map[string][]byte
+}
+
+// readConfigDocument reads the config file at the given path together with all files it includes
+// and all drop-ins of the conf.d directory next to it.
+// Included files are merged before the file including them, drop-ins are merged after the config file
+// in lexical order, so later definitions override earlier ones.
+func readConfigDocument(configPath string) (*configDocument, error) {
+	return readConfigDocumentWithOverrides(configPath, nil)
+}
+
+// readConfigDocumentWithOverrides works like readConfigDocument, but uses the given contents
+// instead of reading the respective files from disk
+func readConfigDocumentWithOverrides(configPath string, overrides map[string][]byte) (*configDocument, error) {
+	document := &configDocument{origins: map[*yaml.Node]string{}, overrides: overrides}
+	root, err := document.load(configPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dropIns, err := filepath.Glob(filepath.Join(filepath.Dir(configPath), DropInDirName, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dropIn := range dropIns {
+		node, err := document.load(dropIn, nil)
+		if err != nil {
+			return nil, err
+		}
+		mergeNodes(root, node, true)
+	}
+
+	document.root = root
+	return document, nil
+}
+
+// load reads the given file and merges it with all files it includes
+func (d *configDocument) load(file string, parents []string) (*yaml.Node, error) {
+	if slices.Contains(parents, file) {
+		return nil, fmt.Errorf("include cycle detected: %s -> %s", strings.Join(parents, " -> "), file)
+	}
+
+	data, ok := d.overrides[file]
+	if !ok {
+		var err error
+		data, err = os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(document.Content) > 0 {
+		node = document.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: config file must contain a mapping", file)
+	}
+	d.files = append(d.files, file)
+	d.setOrigin(node, file)
+
+	includes, err := parseIncludes(file, node)
+	if err != nil {
+		return nil, err
+	}
+	result := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, include := range includes {
+		included, err := d.load(include, append(slices.Clone(parents), file))
+		if err != nil {
+			return nil, err
+		}
+		mergeNodes(result, included, true)
+	}
+	mergeNodes(result, node, true)
+	return result, nil
+}
+
+func (d *configDocument) setOrigin(node *yaml.Node, file string) {
+	d.origins[node] = file
+	for _, child := range node.Content {
+		d.setOrigin(child, file)
+	}
+}
+
+// parseIncludes returns the files included by the given config file node, relative paths are resolved against the directory of the file
+func parseIncludes(file string, node *yaml.Node) ([]string, error) {
+	includeNode := findMappingValue(node, includeKey)
+	if includeNode == nil {
+		return nil, nil
+	}
+
+	var patterns []string
+	switch includeNode.Kind {
+	case yaml.ScalarNode:
+		patterns = append(patterns, includeNode.Value)
+	case yaml.SequenceNode:
+		for _, item := range includeNode.Content {
+			patterns = append(patterns, item.Value)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %s must be a file or a list of files", file, includeKey)
+	}
+
+	var result []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(file), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid %s pattern '%s': %v", file, includeKey, pattern, err)
+		}
+		if len(matches) <= 0 && !strings.ContainsAny(pattern, "*?[") {
+			return nil, fmt.Errorf("%s: included file %s not found", file, pattern)
+		}
+		result = append(result, matches...)
+	}
+	return result, nil
+}
+
+// mergeNodes merges the src mapping node into the dst mapping node.
+// Mappings are merged recursively, entries of the top level id lists are merged by id, all other values are replaced.
+func mergeNodes(dst *yaml.Node, src *yaml.Node, topLevel bool) {
+	for idx := 0; idx+1 < len(src.Content); idx += 2 {
+		key, value := src.Content[idx], src.Content[idx+1]
+		if topLevel && strings.EqualFold(key.Value, includeKey) {
+			continue
+		}
+
+		dstIdx := findMappingIndex(dst, key.Value)
+		if dstIdx < 0 {
+			dst.Content = append(dst.Content, key, value)
+			continue
+		}
+
+		dstValue := dst.Content[dstIdx+1]
+		switch {
+		case dstValue.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeNodes(dstValue, value, false)
+		case topLevel && dstValue.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode && slices.Contains(idLists, strings.ToLower(key.Value)):
+			mergeById(dstValue, value)
+		default:
+			dst.Content[dstIdx+1] = value
+		}
+	}
+}
+
+// mergeById replaces the entries of dst with the entries of src having the same id, and appends all other entries of src
+func mergeById(dst *yaml.Node, src *yaml.Node) {
+	for _, item := range src.Content {
+		id := findMappingValue(item, "id")
+		replaced := false
+		if id != nil {
+			for idx, existing := range dst.Content {
+				existingId := findMappingValue(existing, "id")
+				if existingId != nil && existingId.Value == id.Value {
+					dst.Content[idx] = item
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			dst.Content = append(dst.Content, item)
+		}
+	}
+}
+
+// findMappingIndex returns the index of the given key within a mapping node, or -1 if it doesn't exist
+func findMappingIndex(node *yaml.Node, key string) int {
+	for idx := 0; idx+1 < len(node.Content); idx += 2 {
+		if strings.EqualFold(node.Content[idx].Value, key) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// mergeConfigFiles replaces the config read by viper with the config file merged with its includes and drop-ins
+func mergeConfigFiles() error {
+	configPath := viper.ConfigFileUsed()
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml", ".json":
+	default:
+		// includes are only supported by yaml (and json) config files
+		return nil
+	}
+
+	document, err := readConfigDocument(configPath)
+	if err != nil {
+		return err
+	}
+	if len(document.files) <= 1 {
+		return nil
+	}
+
+	data, err := encodeNode(document.root)
+	if err != nil {
+		return err
+	}
+	viper.SetConfigType("yaml")
+	return viper.ReadConfig(bytes.NewReader(data))
+}
+
+// ReadMergedConfigFile returns the config file at the given path merged with its includes and drop-ins,
+// as well as the list of files that have been merged
+func ReadMergedConfigFile(configPath string) ([]byte, []string, error) {
+	document, err := readConfigDocument(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := encodeNode(document.root)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, document.files, nil
+}
+
+func encodeNode(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
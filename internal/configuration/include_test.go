@@ -0,0 +1,146 @@
+package configuration
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, filePath string, content string) {
+	err := os.MkdirAll(path.Dir(filePath), 0o755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filePath, []byte(content), 0o600)
+	assert.NoError(t, err)
+}
+
+func TestReadMergedConfigFile(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	configPath := path.Join(dir, "fan2go.yaml")
+	writeConfigFile(t, configPath, `include: base/*.yaml
+api:
+  enabled: true
+curves:
+  - id: cpu
+    linear:
+      sensor: cpu
+      min: 40
+      max: 80
+`)
+	writeConfigFile(t, path.Join(dir, "base", "common.yaml"), `api:
+  port: 9100
+curves:
+  - id: cpu
+    linear:
+      sensor: cpu
+      min: 30
+      max: 70
+  - id: gpu
+    linear:
+      sensor: gpu
+      min: 30
+      max: 70
+`)
+	writeConfigFile(t, path.Join(dir, DropInDirName, "20-gpu.yaml"), `curves:
+  - id: gpu
+    linear:
+      sensor: gpu
+      min: 50
+      max: 90
+`)
+	writeConfigFile(t, path.Join(dir, DropInDirName, "10-fans.yaml"), `fans:
+  - id: cpu
+    curve: cpu
+`)
+	writeConfigFile(t, path.Join(dir, DropInDirName, "ignored.yml.disabled"), `api:
+  enabled: false
+`)
+
+	// WHEN
+	data, files, err := ReadMergedConfigFile(configPath)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		configPath,
+		path.Join(dir, "base", "common.yaml"),
+		path.Join(dir, DropInDirName, "10-fans.yaml"),
+		path.Join(dir, DropInDirName, "20-gpu.yaml"),
+	}, files)
+	assert.Equal(t, `api:
+  port: 9100
+  enabled: true
+curves:
+  - id: cpu
+    linear:
+      sensor: cpu
+      min: 40
+      max: 80
+  - id: gpu
+    linear:
+      sensor: gpu
+      min: 50
+      max: 90
+fans:
+  - id: cpu
+    curve: cpu
+`, string(data))
+}
+
+func TestReadMergedConfigFileIncludeCycle(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	configPath := path.Join(dir, "fan2go.yaml")
+	writeConfigFile(t, configPath, "include: a.yaml\n")
+	writeConfigFile(t, path.Join(dir, "a.yaml"), "include: [fan2go.yaml]\n")
+
+	// WHEN
+	_, _, err := ReadMergedConfigFile(configPath)
+
+	// THEN
+	assert.EqualError(t, err, "include cycle detected: "+configPath+" -> "+path.Join(dir, "a.yaml")+" -> "+configPath)
+}
+
+func TestReadMergedConfigFileMissingInclude(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	configPath := path.Join(dir, "fan2go.yaml")
+	writeConfigFile(t, configPath, "include: missing.yaml\n")
+
+	// WHEN
+	_, _, err := ReadMergedConfigFile(configPath)
+
+	// THEN
+	assert.EqualError(t, err, configPath+": included file "+path.Join(dir, "missing.yaml")+" not found")
+}
+
+func TestLocateProblemsInDropIn(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	configPath := path.Join(dir, "fan2go.yaml")
+	writeConfigFile(t, configPath, `fans:
+  - id: cpu
+    curve: cpu
+`)
+	dropInPath := path.Join(dir, DropInDirName, "fans.yaml")
+	writeConfigFile(t, dropInPath, `fans:
+  - id: gpu
+    curve: gpu
+    minPwm: 300
+`)
+	problems := []ValidationProblem{
+		{Severity: SeverityError, Path: "fans[0].curve"},
+		{Severity: SeverityError, Path: "fans[1].minPwm"},
+	}
+
+	// WHEN
+	LocateProblems(problems, configPath)
+
+	// THEN
+	assert.Equal(t, []ValidationProblem{
+		{Severity: SeverityError, Path: "fans[0].curve", Line: 3},
+		{Severity: SeverityError, Path: "fans[1].minPwm", File: dropInPath, Line: 4},
+	}, problems)
+}
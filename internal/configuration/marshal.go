@@ -0,0 +1,116 @@
+package configuration
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// Marshal renders the given configuration as yaml, using the same keys as the config file.
+// Fields populated at runtime, like the resolved sysfs paths of hwmon fans, are included as well.
+func Marshal(config Configuration) ([]byte, error) {
+	return encodeNode(nodeOf(reflect.ValueOf(config)))
+}
+
+func nodeOf(value reflect.Value) *yaml.Node {
+	if value.Type() == durationType {
+		return scalarNode("!!str", time.Duration(value.Int()).String())
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return scalarNode("!!null", "null")
+		}
+		return nodeOf(value.Elem())
+	case reflect.Bool:
+		return scalarNode("!!bool", strconv.FormatBool(value.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return scalarNode("!!int", strconv.FormatInt(value.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return scalarNode("!!int", strconv.FormatUint(value.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		text := strconv.FormatFloat(value.Float(), 'g', -1, 64)
+		if !strings.ContainsAny(text, ".eIN") {
+			// keep the value recognizable as float
+			text += ".0"
+		}
+		return scalarNode("!!float", text)
+	case reflect.String:
+		return scalarNode("!!str", value.String())
+	case reflect.Slice, reflect.Array:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for idx := 0; idx < value.Len(); idx++ {
+			node.Content = append(node.Content, nodeOf(value.Index(idx)))
+		}
+		return node
+	case reflect.Map:
+		return mapNodeOf(value)
+	case reflect.Struct:
+		return structNodeOf(value)
+	default:
+		return scalarNode("!!str", fmt.Sprint(value.Interface()))
+	}
+}
+
+func mapNodeOf(value reflect.Value) *yaml.Node {
+	keys := value.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Kind() == reflect.String {
+			return keys[i].String() < keys[j].String()
+		}
+		return keys[i].Int() < keys[j].Int()
+	})
+
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, key := range keys {
+		node.Content = append(node.Content, nodeOf(key), nodeOf(value.MapIndex(key)))
+	}
+	return node
+}
+
+func structNodeOf(value reflect.Value) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for idx := 0; idx < value.NumField(); idx++ {
+		field := value.Type().Field(idx)
+		fieldValue := value.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		omitEmpty := slices.Contains(tag[1:], "omitempty")
+		if name == "-" {
+			continue
+		}
+		if len(name) <= 0 {
+			// fields without json tag are populated at runtime
+			name = lowerFirst(field.Name)
+			omitEmpty = true
+		}
+		if (omitEmpty && fieldValue.IsZero()) || (fieldValue.Kind() == reflect.Pointer && fieldValue.IsNil()) {
+			continue
+		}
+
+		node.Content = append(node.Content, scalarNode("!!str", name), nodeOf(fieldValue))
+	}
+	return node
+}
+
+func scalarNode(tag string, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+func lowerFirst(value string) string {
+	r, size := utf8.DecodeRuneInString(value)
+	return string(unicode.ToLower(r)) + value[size:]
+}
@@ -0,0 +1,108 @@
+package configuration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	// GIVEN
+	minPwm := 30
+	config := Configuration{
+		DbPath:                  "/etc/fan2go/fan2go.db",
+		MaxRpmDiffForSettledFan: 20,
+		TempSensorPollingRate:   200 * time.Millisecond,
+		Fans: []FanConfig{
+			{
+				ID:     "cpu",
+				MinPwm: &minPwm,
+				Curve:  "cpu",
+				HwMon: &HwMonFanConfig{
+					Platform:   "nct6798",
+					Index:      1,
+					RpmChannel: 1,
+					PwmChannel: 1,
+					SysfsPath:  "/sys/class/hwmon/hwmon2",
+					PwmPath:    "/sys/class/hwmon/hwmon2/pwm1",
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "cpu",
+				Linear: &LinearCurveConfig{
+					Sensor: "cpu",
+					Steps:  map[int]float64{60: 128, 40: 0},
+				},
+			},
+		},
+	}
+
+	// WHEN
+	data, err := Marshal(config)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, `dbPath: /etc/fan2go/fan2go.db
+sysfsRoot: ""
+runFanInitializationInParallel: false
+maxRpmDiffForSettledFan: 20.0
+fanResponseDelay: 0
+tempSensorPollingRate: 200ms
+tempRollingWindowSize: 0
+rpmPollingRate: 0s
+rpmRollingWindowSize: 0
+controllerAdjustmentTickRate: 0s
+hwMonPollingRate: 0s
+fans:
+  - id: cpu
+    neverStop: false
+    minPwm: 30
+    curve: cpu
+    hwMon:
+      platform: nct6798
+      index: 1
+      rpmChannel: 1
+      pwmChannel: 1
+      sysfsPath: /sys/class/hwmon/hwmon2
+      pwmPath: /sys/class/hwmon/hwmon2/pwm1
+sensors: []
+curves:
+  - id: cpu
+    linear:
+      sensor: cpu
+      min: 0
+      max: 0
+      steps:
+        40: 0.0
+        60: 128.0
+plugins: []
+api:
+  enabled: false
+  host: ""
+  port: 0
+  allowWrite: false
+mqtt:
+  enabled: false
+  broker: ""
+  clientId: ""
+  username: ""
+  password: ""
+  topicPrefix: ""
+  publishInterval: 0s
+  homeAssistant:
+    enabled: false
+    discoveryPrefix: ""
+record:
+  enabled: false
+  file: ""
+  interval: 0s
+statistics:
+  enabled: false
+profiling:
+  enabled: false
+  host: ""
+`, string(data))
+}
@@ -0,0 +1,23 @@
+package configuration
+
+import "time"
+
+type MqttConfig struct {
+	Enabled bool `json:"enabled"`
+	// Broker is the url of the MQTT broker, f.ex. tcp://localhost:1883
+	Broker   string `json:"broker"`
+	ClientId string `json:"clientId"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// TopicPrefix is prepended to all topics published and subscribed to by fan2go
+	TopicPrefix string `json:"topicPrefix"`
+	// PublishInterval defines how often the state of all sensors, curves and fans is published
+	PublishInterval time.Duration           `json:"publishInterval"`
+	HomeAssistant   MqttHomeAssistantConfig `json:"homeAssistant"`
+}
+
+type MqttHomeAssistantConfig struct {
+	// Enabled controls whether Home Assistant discovery payloads are published
+	Enabled         bool   `json:"enabled"`
+	DiscoveryPrefix string `json:"discoveryPrefix"`
+}
@@ -0,0 +1,26 @@
+package configuration
+
+import "time"
+
+// PluginConfig defines an external driver, which provides fans and sensors over a Unix socket
+type PluginConfig struct {
+	ID string `json:"id"`
+	// Socket is the path of the Unix socket the plugin listens on
+	Socket string `json:"socket"`
+	// Timeout for a single request, 2s if not set
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+type PluginFanConfig struct {
+	// ID of the plugin providing the fan
+	ID string `json:"id"`
+	// Fan is the id of the fan, as advertised by the plugin
+	Fan string `json:"fan"`
+}
+
+type PluginSensorConfig struct {
+	// ID of the plugin providing the sensor
+	ID string `json:"id"`
+	// Sensor is the id of the sensor, as advertised by the plugin
+	Sensor string `json:"sensor"`
+}
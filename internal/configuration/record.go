@@ -0,0 +1,12 @@
+package configuration
+
+import "time"
+
+// RecordConfig configures the daemon to record the values of all sensors and fans to a trace file
+type RecordConfig struct {
+	Enabled bool `json:"enabled"`
+	// File the trace is appended to
+	File string `json:"file"`
+	// Interval defines how often the values are recorded
+	Interval time.Duration `json:"interval"`
+}
@@ -0,0 +1,150 @@
+package configuration
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	SchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+	// durationPattern matches the durations accepted by time.ParseDuration, f.ex. "200ms" or "1m30s"
+	durationPattern = `^(0|-?([0-9]*\.?[0-9]+(ns|us|µs|ms|s|m|h))+)$`
+	// integerKeyPattern matches the keys of maps with integer keys, f.ex. the steps of a linear curve
+	integerKeyPattern = `^-?[0-9]+$`
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var pwmSchema = map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 255}
+
+// schemaOverrides are merged into the generated schema of a type ("TypeName") or a field ("TypeName.jsonName"),
+// for constraints which cannot be derived from the type alone
+var schemaOverrides = map[string]map[string]interface{}{
+	"FanConfig":                         {"required": []string{"id", "curve"}},
+	"FanConfig.minPwm":                  pwmSchema,
+	"FanConfig.startPwm":                pwmSchema,
+	"FanConfig.maxPwm":                  pwmSchema,
+	"FanConfig.pwmMap":                  {"additionalProperties": pwmSchema},
+	"HwMonFanConfig.index":              {"minimum": 1},
+	"HwMonFanConfig.rpmChannel":         {"minimum": 1},
+	"HwMonFanConfig.pwmChannel":         {"minimum": 1},
+	"DiscreteFanConfig.levels":          {"minItems": 2},
+	"ControlLoopConfig.type":            {"enum": []string{ControlLoopTypePid, ControlLoopTypeDirect, ControlLoopTypeSlew}},
+	"SensorConfig":                      {"required": []string{"id"}},
+	"HwMonSensorConfig.index":           {"minimum": 1},
+	"ThermalZoneSensorConfig.tripPoint": {"minimum": 0},
+	"CurveConfig":                       {"required": []string{"id"}},
+	"LinearCurveConfig.steps":           {"additionalProperties": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 255}},
+	"FunctionCurveConfig.type":          {"enum": []string{FunctionMinimum, FunctionAverage, FunctionMaximum, FunctionDelta, FunctionSum, FunctionDifference}},
+	"PluginConfig":                      {"required": []string{"id", "socket"}},
+}
+
+// schemaExtraProperties are properties of a type ("TypeName"), which are not part of the type itself
+var schemaExtraProperties = map[string]map[string]interface{}{
+	"Configuration": {
+		// see readConfigDocument
+		includeKey: map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+		},
+	},
+}
+
+// schemaAliases are additional spellings of properties, which are used throughout the documentation
+var schemaAliases = map[string]string{
+	"hwMon": "hwmon",
+}
+
+// Schema returns a JSON Schema of the config file, which can be used by editors to validate configs while typing
+func Schema() map[string]interface{} {
+	schema := schemaOf(reflect.TypeOf(Configuration{}))
+	schema["$schema"] = SchemaDraft
+	schema["title"] = "fan2go configuration"
+	return schema
+}
+
+func schemaOf(t reflect.Type) map[string]interface{} {
+	if t == durationType {
+		return map[string]interface{}{"type": []string{"string", "integer"}, "pattern": durationPattern}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+		if t.Key().Kind() != reflect.String {
+			schema["propertyNames"] = map[string]interface{}{"pattern": integerKeyPattern}
+		}
+		return schema
+	case reflect.Struct:
+		return structSchemaOf(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchemaOf(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || len(name) <= 0 || name == "-" {
+			// fields without json tag are populated at runtime
+			continue
+		}
+
+		property := schemaOf(field.Type)
+		mergeSchema(property, schemaOverrides[t.Name()+"."+name])
+		if isIntegerKeyMap(field.Type) {
+			// maps like the steps of a linear curve are often written as a list of single entry maps
+			property = map[string]interface{}{
+				"anyOf": []interface{}{property, map[string]interface{}{"type": "array", "items": property}},
+			}
+		}
+		properties[name] = property
+		if alias, ok := schemaAliases[name]; ok {
+			properties[alias] = property
+		}
+	}
+
+	for name, property := range schemaExtraProperties[t.Name()] {
+		properties[name] = property
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	mergeSchema(schema, schemaOverrides[t.Name()])
+	return schema
+}
+
+func isIntegerKeyMap(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Map && t.Key().Kind() != reflect.String
+}
+
+func mergeSchema(schema map[string]interface{}, override map[string]interface{}) {
+	for key, value := range override {
+		schema[key] = value
+	}
+}
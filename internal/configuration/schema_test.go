@@ -0,0 +1,73 @@
+package configuration
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaProperties(t *testing.T) {
+	// WHEN
+	schema := Schema()
+
+	// THEN
+	assert.Equal(t, SchemaDraft, schema["$schema"])
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string"}, properties["dbPath"])
+
+	fans := properties["fans"].(map[string]interface{})
+	assert.Equal(t, "array", fans["type"])
+	fan := fans["items"].(map[string]interface{})
+	assert.Equal(t, []string{"id", "curve"}, fan["required"])
+	assert.Equal(t, false, fan["additionalProperties"])
+
+	fanProperties := fan["properties"].(map[string]interface{})
+	assert.Equal(t, pwmSchema, fanProperties["minPwm"])
+	assert.Equal(t, fanProperties["hwMon"], fanProperties["hwmon"])
+
+	hwMon := fanProperties["hwmon"].(map[string]interface{})
+	hwMonProperties := hwMon["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "integer", "minimum": 1}, hwMonProperties["index"])
+	assert.NotContains(t, hwMonProperties, "SysfsPath")
+}
+
+func TestSchemaMapWithIntegerKeys(t *testing.T) {
+	// WHEN
+	schema := Schema()
+
+	// THEN
+	curves := schema["properties"].(map[string]interface{})["curves"].(map[string]interface{})
+	curve := curves["items"].(map[string]interface{})
+	linear := curve["properties"].(map[string]interface{})["linear"].(map[string]interface{})
+	stepsVariants := linear["properties"].(map[string]interface{})["steps"].(map[string]interface{})["anyOf"].([]interface{})
+	steps := stepsVariants[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": steps}, stepsVariants[1])
+
+	assert.Equal(t, "object", steps["type"])
+	assert.Equal(t, map[string]interface{}{"pattern": integerKeyPattern}, steps["propertyNames"])
+	assert.Equal(t, map[string]interface{}{"type": "number", "minimum": 0, "maximum": 255}, steps["additionalProperties"])
+}
+
+func TestSchemaDurationPattern(t *testing.T) {
+	// GIVEN
+	pattern := regexp.MustCompile(durationPattern)
+
+	// THEN
+	for _, value := range []string{"0", "200ms", "1s", "1m30s", "1.5h"} {
+		assert.True(t, pattern.MatchString(value), value)
+	}
+	for _, value := range []string{"", "1", "fast", "1 s"} {
+		assert.False(t, pattern.MatchString(value), value)
+	}
+}
+
+func TestSchemaIsValidJson(t *testing.T) {
+	// WHEN
+	data, err := json.Marshal(Schema())
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"tempSensorPollingRate":{"pattern"`)
+}
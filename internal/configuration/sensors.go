@@ -1,15 +1,41 @@
 package configuration
 
+import "time"
+
 type SensorConfig struct {
 	ID    string             `json:"id"`
 	HwMon *HwMonSensorConfig `json:"hwMon,omitempty"`
 	File  *FileSensorConfig  `json:"file,omitempty"`
 	Cmd   *CmdSensorConfig   `json:"cmd,omitempty"`
+	Rapl  *RaplSensorConfig  `json:"rapl,omitempty"`
+	Http  *HttpSensorConfig  `json:"http,omitempty"`
+	Push  *PushSensorConfig  `json:"push,omitempty"`
+	Mqtt  *MqttSensorConfig  `json:"mqtt,omitempty"`
+
+	ThermalZone *ThermalZoneSensorConfig `json:"thermalZone,omitempty"`
+	Plugin      *PluginSensorConfig      `json:"plugin,omitempty"`
+	// Replay is a sensor which replays the values of a sensor recorded to a trace file
+	Replay *ReplaySensorConfig `json:"replay,omitempty"`
 }
 
 type HwMonSensorConfig struct {
-	Platform  string `json:"platform"`
-	Index     int    `json:"index"`
+	// Platform is a regex matching the platform of the device, as printed by "fan2go detect"
+	Platform string `json:"platform"`
+	// Driver is the name of the kernel driver of the device, f.ex. "amdgpu" or "nvme"
+	Driver string `json:"driver,omitempty"`
+	// Modalias is the modalias of the device
+	Modalias string `json:"modalias,omitempty"`
+	// BusAddress is the PCI address (f.ex. "0000:03:00.0") or USB port (f.ex. "1-2") of the device
+	BusAddress string `json:"busAddress,omitempty"`
+	// Device is the target of the "device" symlink of the hwmon directory
+	Device string `json:"device,omitempty"`
+
+	// Index is the position of the temperature input as printed by "fan2go detect",
+	// it may change when the driver adds inputs, prefer Label if possible
+	Index int `json:"index,omitempty"`
+	// Label is the label of the temperature input, f.ex. "Tctl" or "Package id 0",
+	// either matching exactly or as a regex matching the whole label
+	Label     string `json:"label,omitempty"`
 	TempInput string
 }
 
@@ -20,4 +46,71 @@ type FileSensorConfig struct {
 type CmdSensorConfig struct {
 	Exec string   `json:"exec"`
 	Args []string `json:"args"`
+	// Coprocess keeps the command running and requests values using line-delimited JSON over stdin/stdout,
+	// instead of executing the command for every read
+	Coprocess *CoprocessConfig `json:"coprocess,omitempty"`
+}
+
+type RaplSensorConfig struct {
+	// Path is the powercap zone directory containing the energy_uj counter,
+	// f.ex. /sys/class/powercap/intel-rapl:0
+	Path string `json:"path"`
+}
+
+type ThermalZoneSensorConfig struct {
+	// Type is the content of the "type" file of the thermal zone, f.ex. x86_pkg_temp or acpitz
+	Type string `json:"type"`
+	// TripPoint is the (optional) index of a trip point of the zone, whose temperature
+	// is used instead of the current temperature of the zone
+	TripPoint *int `json:"tripPoint,omitempty"`
+}
+
+type ReplaySensorConfig struct {
+	// File is the trace file created by "fan2go record"
+	File string `json:"file"`
+	// Sensor is the id of the recorded sensor, defaults to the id of this sensor
+	Sensor string `json:"sensor,omitempty"`
+	// Loop restarts the replay at the end of the trace, instead of keeping the last value
+	Loop bool `json:"loop,omitempty"`
+}
+
+type HttpSensorConfig struct {
+	// Url to fetch the sensor value from
+	Url string `json:"url"`
+	// Interval defines how often the url is fetched, values read in between are cached
+	Interval time.Duration `json:"interval"`
+	// Timeout of a single request
+	Timeout time.Duration     `json:"timeout"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// JsonPath selects the value from a JSON response, f.ex. "sensors[0].temperature"
+	JsonPath string `json:"jsonPath,omitempty"`
+	// Metric selects the value from a Prometheus exposition format response
+	Metric *HttpSensorMetricConfig `json:"metric,omitempty"`
+	// Scale is multiplied with the extracted value, f.ex. 1000 to convert degrees to milli-degrees
+	Scale *float64 `json:"scale,omitempty"`
+}
+
+type HttpSensorMetricConfig struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type PushSensorConfig struct {
+	// Expiry defines how long a pushed value is valid, 0 means forever
+	Expiry time.Duration `json:"expiry"`
+	// Default is the value to use before the first value has been pushed
+	Default *float64 `json:"default,omitempty"`
+}
+
+type MqttSensorConfig struct {
+	// Topic to subscribe to
+	Topic string `json:"topic"`
+	// JsonPath selects the value from a JSON payload, if empty the payload is parsed as a plain number
+	JsonPath string `json:"jsonPath,omitempty"`
+	// Scale is multiplied with the received value, f.ex. 1000 to convert degrees to milli-degrees
+	Scale *float64 `json:"scale,omitempty"`
+	// Expiry defines how long a received value is valid, 0 means forever
+	Expiry time.Duration `json:"expiry"`
+	// Default is the value to use before the first value has been received
+	Default *float64 `json:"default,omitempty"`
 }
@@ -1,41 +1,158 @@
 package configuration
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/looplab/tarjan"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/markusressel/fan2go/internal/util"
 	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
 )
 
-func Validate(configPath string) error {
-	return validateConfig(&CurrentConfig, configPath)
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationProblem is a single error or warning found in the configuration
+type ValidationProblem struct {
+	Severity string `json:"severity"`
+	// Path of the offending value within the configuration, f.ex. "fans[2].minPwm"
+	Path string `json:"path"`
+	// File containing the offending value, if it is not the config file itself but f.ex. a drop-in
+	File string `json:"file,omitempty"`
+	// Line of the offending value within the config file, 0 if unknown
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
 }
 
-func validateConfig(config *Configuration, path string) error {
-	err := validateSensors(config)
-	if err != nil {
-		return err
+func (p ValidationProblem) String() string {
+	location := p.Path
+	if p.Line > 0 {
+		location = p.line()
+		if len(p.Path) > 0 {
+			location = fmt.Sprintf("%s, %s", p.line(), p.Path)
+		}
 	}
-	err = validateCurves(config)
-	if err != nil {
-		return err
+	if len(location) <= 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("%s: %s", location, p.Message)
+}
+
+func (p ValidationProblem) line() string {
+	if len(p.File) > 0 {
+		return fmt.Sprintf("%s line %d", p.File, p.Line)
+	}
+	return fmt.Sprintf("line %d", p.Line)
+}
+
+// ValidationResult contains all problems found in the configuration, in the order they were found
+type ValidationResult struct {
+	Problems []ValidationProblem `json:"problems"`
+}
+
+// Errors returns all problems with severity error
+func (r ValidationResult) Errors() []ValidationProblem {
+	return r.withSeverity(SeverityError)
+}
+
+// Warnings returns all problems with severity warning
+func (r ValidationResult) Warnings() []ValidationProblem {
+	return r.withSeverity(SeverityWarning)
+}
+
+func (r ValidationResult) withSeverity(severity string) []ValidationProblem {
+	var result []ValidationProblem
+	for _, problem := range r.Problems {
+		if problem.Severity == severity {
+			result = append(result, problem)
+		}
+	}
+	return result
+}
+
+// Err returns an error listing all errors of the result, or nil if there are none
+func (r ValidationResult) Err() error {
+	var messages []string
+	for _, problem := range r.Errors() {
+		if problem.Line > 0 {
+			messages = append(messages, fmt.Sprintf("%s: %s", problem.line(), problem.Message))
+		} else {
+			messages = append(messages, problem.Message)
+		}
+	}
+	if len(messages) <= 0 {
+		return nil
 	}
-	err = validateFans(config)
+	return errors.New(strings.Join(messages, "\n"))
+}
 
-	if containsCmdSensors() || containsCmdFan() {
+// Validate validates the current configuration, prints all warnings and returns an error listing all errors
+func Validate(configPath string) error {
+	result := Check(configPath)
+	for _, warning := range result.Warnings() {
+		ui.Warning("%s", warning)
+	}
+	return result.Err()
+}
+
+// Check validates the current configuration and returns all errors and warnings,
+// located within the config file at the given path
+func Check(configPath string) ValidationResult {
+	result := checkConfig(&CurrentConfig, configPath)
+	LocateProblems(result.Problems, configPath)
+	return result
+}
+
+func validateConfig(config *Configuration, path string) error {
+	return checkConfig(config, path).Err()
+}
+
+// validator collects the problems found in a configuration
+type validator struct {
+	config   *Configuration
+	problems []ValidationProblem
+}
+
+func (v *validator) error(path string, format string, a ...interface{}) {
+	v.problems = append(v.problems, ValidationProblem{Severity: SeverityError, Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) warning(path string, format string, a ...interface{}) {
+	v.problems = append(v.problems, ValidationProblem{Severity: SeverityWarning, Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) result() ValidationResult {
+	return ValidationResult{Problems: v.problems}
+}
+
+func checkConfig(config *Configuration, path string) ValidationResult {
+	v := &validator{config: config}
+	v.validateMqtt()
+	v.validateRecord()
+	v.validatePlugins()
+	v.validateSensors()
+	v.validateCurves()
+	v.validateFans()
+
+	if containsCmdSensors(config) || containsCmdFan(config) {
 		if _, err := util.CheckFilePermissionsForExecution(path); err != nil {
-			return fmt.Errorf("config file '%s' has invalid permissions: %s", path, err)
+			v.error("", "config file '%s' has invalid permissions: %s", path, err)
 		}
 	}
 
-	return err
+	return v.result()
 }
 
-func containsCmdFan() bool {
-	for _, fanConfig := range CurrentConfig.Fans {
+func containsCmdFan(config *Configuration) bool {
+	for _, fanConfig := range config.Fans {
 		if fanConfig.Cmd != nil {
 			return true
 		}
@@ -44,8 +161,8 @@ func containsCmdFan() bool {
 	return false
 }
 
-func containsCmdSensors() bool {
-	for _, sensorConfig := range CurrentConfig.Sensors {
+func containsCmdSensors(config *Configuration) bool {
+	for _, sensorConfig := range config.Sensors {
 		if sensorConfig.Cmd != nil {
 			return true
 		}
@@ -54,12 +171,73 @@ func containsCmdSensors() bool {
 	return false
 }
 
-func validateSensors(config *Configuration) error {
+func (v *validator) validateMqtt() {
+	config := v.config
+	if !config.Mqtt.Enabled {
+		return
+	}
+
+	if len(config.Mqtt.Broker) <= 0 {
+		v.error("mqtt.broker", "mqtt: missing broker")
+	}
+	if len(config.Mqtt.TopicPrefix) <= 0 {
+		v.error("mqtt.topicPrefix", "mqtt: missing topicPrefix")
+	}
+	if config.Mqtt.PublishInterval <= 0 {
+		v.error("mqtt.publishInterval", "mqtt: invalid publishInterval, must be > 0")
+	}
+}
+
+func (v *validator) validateRecord() {
+	config := v.config
+	if !config.Record.Enabled {
+		return
+	}
+
+	if len(config.Record.File) <= 0 {
+		v.error("record.file", "record: missing file")
+	}
+	if config.Record.Interval <= 0 {
+		v.error("record.interval", "record: invalid interval, must be > 0")
+	}
+}
+
+func (v *validator) validatePlugins() {
+	pluginIds := []string{}
+
+	for idx, pluginConfig := range v.config.Plugins {
+		path := fmt.Sprintf("plugins[%d]", idx)
+		if slices.Contains(pluginIds, pluginConfig.ID) {
+			v.error(path+".id", "duplicate plugin id detected: %s", pluginConfig.ID)
+		}
+		pluginIds = append(pluginIds, pluginConfig.ID)
+
+		if len(pluginConfig.Socket) <= 0 {
+			v.error(path+".socket", "plugin %s: missing socket", pluginConfig.ID)
+		}
+		if pluginConfig.Timeout < 0 {
+			v.error(path+".timeout", "plugin %s: invalid timeout, must be >= 0", pluginConfig.ID)
+		}
+	}
+}
+
+func pluginIdExists(pluginId string, config *Configuration) bool {
+	for _, pluginConfig := range config.Plugins {
+		if pluginConfig.ID == pluginId {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) validateSensors() {
+	config := v.config
 	sensorIds := []string{}
 
-	for _, sensorConfig := range config.Sensors {
+	for idx, sensorConfig := range config.Sensors {
+		path := fmt.Sprintf("sensors[%d]", idx)
 		if slices.Contains(sensorIds, sensorConfig.ID) {
-			return fmt.Errorf("duplicate sensor id detected: %s", sensorConfig.ID)
+			v.error(path+".id", "duplicate sensor id detected: %s", sensorConfig.ID)
 		}
 		sensorIds = append(sensorIds, sensorConfig.ID)
 
@@ -73,25 +251,114 @@ func validateSensors(config *Configuration) error {
 		if sensorConfig.Cmd != nil {
 			subConfigs++
 		}
+		if sensorConfig.Rapl != nil {
+			subConfigs++
+		}
+		if sensorConfig.Http != nil {
+			subConfigs++
+		}
+		if sensorConfig.Push != nil {
+			subConfigs++
+		}
+		if sensorConfig.Mqtt != nil {
+			subConfigs++
+		}
+		if sensorConfig.ThermalZone != nil {
+			subConfigs++
+		}
+		if sensorConfig.Plugin != nil {
+			subConfigs++
+		}
+		if sensorConfig.Replay != nil {
+			subConfigs++
+		}
 		if subConfigs > 1 {
-			return fmt.Errorf("sensor %s: only one sensor type can be used per sensor definition block", sensorConfig.ID)
+			v.error(path, "sensor %s: only one sensor type can be used per sensor definition block", sensorConfig.ID)
+			continue
 		}
 		if subConfigs <= 0 {
-			return fmt.Errorf("sensor %s: sub-configuration for sensor is missing, use one of: hwmon | file | cmd", sensorConfig.ID)
+			v.error(path, "sensor %s: sub-configuration for sensor is missing, use one of: hwmon | file | cmd | rapl | http | push | mqtt | thermalZone | plugin | replay", sensorConfig.ID)
+			continue
 		}
 
 		if !isSensorConfigInUse(sensorConfig, config.Curves) {
-			ui.Warning("Unused sensor configuration: %s", sensorConfig.ID)
+			v.warning(path, "Unused sensor configuration: %s", sensorConfig.ID)
+		}
+
+		if sensorConfig.Cmd != nil && sensorConfig.Cmd.Coprocess != nil {
+			v.validateCoprocess(path+".cmd.coprocess", fmt.Sprintf("sensor %s", sensorConfig.ID), sensorConfig.Cmd.Coprocess)
 		}
 
 		if sensorConfig.HwMon != nil {
-			if sensorConfig.HwMon.Index <= 0 {
-				return fmt.Errorf("sensor %s: invalid index, must be >= 1", sensorConfig.ID)
+			if len(sensorConfig.HwMon.Label) > 0 {
+				if sensorConfig.HwMon.Index != 0 {
+					v.error(path+".hwMon", "sensor %s: must have only one of index or label", sensorConfig.ID)
+				} else if _, err := regexp.Compile(sensorConfig.HwMon.Label); err != nil {
+					v.error(path+".hwMon.label", "sensor %s: invalid label regex: %v", sensorConfig.ID, err)
+				}
+			} else if sensorConfig.HwMon.Index <= 0 {
+				v.error(path+".hwMon.index", "sensor %s: invalid index, must be >= 1", sensorConfig.ID)
+			}
+		}
+
+		if sensorConfig.Plugin != nil {
+			if !pluginIdExists(sensorConfig.Plugin.ID, config) {
+				v.error(path+".plugin.id", "sensor %s: no plugin definition with id '%s' found", sensorConfig.ID, sensorConfig.Plugin.ID)
+			}
+			if len(sensorConfig.Plugin.Sensor) <= 0 {
+				v.error(path+".plugin.sensor", "sensor %s: missing plugin sensor id", sensorConfig.ID)
+			}
+		}
+
+		if sensorConfig.Replay != nil {
+			if len(sensorConfig.Replay.File) <= 0 {
+				v.error(path+".replay.file", "sensor %s: missing replay file", sensorConfig.ID)
+			}
+		}
+
+		if sensorConfig.ThermalZone != nil {
+			if len(sensorConfig.ThermalZone.Type) <= 0 {
+				v.error(path+".thermalZone.type", "sensor %s: missing thermal zone type", sensorConfig.ID)
+			}
+			if sensorConfig.ThermalZone.TripPoint != nil && *sensorConfig.ThermalZone.TripPoint < 0 {
+				v.error(path+".thermalZone.tripPoint", "sensor %s: invalid tripPoint, must be >= 0", sensorConfig.ID)
+			}
+		}
+
+		if sensorConfig.Http != nil {
+			httpConfig := sensorConfig.Http
+			if len(httpConfig.Url) <= 0 {
+				v.error(path+".http.url", "sensor %s: missing url", sensorConfig.ID)
+			}
+			if (len(httpConfig.JsonPath) > 0) == (httpConfig.Metric != nil) {
+				v.error(path+".http", "sensor %s: must have exactly one of jsonPath or metric", sensorConfig.ID)
+			}
+			if httpConfig.Metric != nil && len(httpConfig.Metric.Name) <= 0 {
+				v.error(path+".http.metric.name", "sensor %s: missing metric name", sensorConfig.ID)
 			}
 		}
-	}
 
-	return nil
+		if sensorConfig.Push != nil {
+			if sensorConfig.Push.Expiry < 0 {
+				v.error(path+".push.expiry", "sensor %s: invalid expiry, must be >= 0", sensorConfig.ID)
+			}
+			if !config.Api.Enabled {
+				v.warning(path+".push", "Sensor %s: push sensors require the api to be enabled", sensorConfig.ID)
+			}
+		}
+
+		if sensorConfig.Mqtt != nil {
+			if len(sensorConfig.Mqtt.Topic) <= 0 {
+				v.error(path+".mqtt.topic", "sensor %s: missing topic", sensorConfig.ID)
+			}
+			if sensorConfig.Mqtt.Expiry < 0 {
+				v.error(path+".mqtt.expiry", "sensor %s: invalid expiry, must be >= 0", sensorConfig.ID)
+			}
+			if !config.Mqtt.Enabled {
+				v.warning(path+".mqtt", "Sensor %s: mqtt sensors require mqtt to be enabled", sensorConfig.ID)
+			}
+		}
+	}
 }
 
 func isSensorConfigInUse(config SensorConfig, curves []CurveConfig) bool {
@@ -111,13 +378,15 @@ func isSensorConfigInUse(config SensorConfig, curves []CurveConfig) bool {
 	return false
 }
 
-func validateCurves(config *Configuration) error {
+func (v *validator) validateCurves() {
+	config := v.config
 	graph := make(map[interface{}][]interface{})
 	curveIds := []string{}
 
-	for _, curveConfig := range config.Curves {
+	for idx, curveConfig := range config.Curves {
+		path := fmt.Sprintf("curves[%d]", idx)
 		if slices.Contains(curveIds, curveConfig.ID) {
-			return fmt.Errorf("duplicate curve id detected: %s", curveConfig.ID)
+			v.error(path+".id", "duplicate curve id detected: %s", curveConfig.ID)
 		}
 		curveIds = append(curveIds, curveConfig.ID)
 
@@ -132,29 +401,34 @@ func validateCurves(config *Configuration) error {
 			subConfigs++
 		}
 		if subConfigs > 1 {
-			return fmt.Errorf("curve %s: only one curve type can be used per curve definition block", curveConfig.ID)
+			v.error(path, "curve %s: only one curve type can be used per curve definition block", curveConfig.ID)
+			continue
 		}
 		if subConfigs <= 0 {
-			return fmt.Errorf("curve %s: sub-configuration for curve is missing, use one of: linear | pid | function", curveConfig.ID)
+			v.error(path, "curve %s: sub-configuration for curve is missing, use one of: linear | pid | function", curveConfig.ID)
+			continue
 		}
 
 		if !isCurveConfigInUse(curveConfig, config.Curves, config.Fans) {
-			ui.Warning("Unused curve configuration: %s", curveConfig.ID)
+			v.warning(path, "Unused curve configuration: %s", curveConfig.ID)
 		}
 
 		if curveConfig.Function != nil {
 			supportedTypes := []string{FunctionMinimum, FunctionAverage, FunctionMaximum, FunctionDelta, FunctionSum, FunctionDifference}
 			if !slices.Contains(supportedTypes, curveConfig.Function.Type) {
-				return fmt.Errorf("curve %s: unsupported function type '%s', use one of: %s", curveConfig.ID, curveConfig.Function.Type, strings.Join(supportedTypes, " | "))
+				v.error(path+".function.type", "curve %s: unsupported function type '%s', use one of: %s", curveConfig.ID, curveConfig.Function.Type, strings.Join(supportedTypes, " | "))
 			}
 
 			var connections []interface{}
-			for _, curve := range curveConfig.Function.Curves {
+			for curveIdx, curve := range curveConfig.Function.Curves {
+				curvePath := fmt.Sprintf("%s.function.curves[%d]", path, curveIdx)
 				if curve == curveConfig.ID {
-					return fmt.Errorf("curve %s: a curve cannot reference itself", curveConfig.ID)
+					v.error(curvePath, "curve %s: a curve cannot reference itself", curveConfig.ID)
+					continue
 				}
 				if !curveIdExists(curve, config) {
-					return fmt.Errorf("curve %s: no curve definition with id '%s' found", curveConfig.ID, curve)
+					v.error(curvePath, "curve %s: no curve definition with id '%s' found", curveConfig.ID, curve)
+					continue
 				}
 				connections = append(connections, curve)
 			}
@@ -163,33 +437,59 @@ func validateCurves(config *Configuration) error {
 
 		if curveConfig.Linear != nil {
 			if len(curveConfig.Linear.Sensor) <= 0 {
-				return fmt.Errorf("curve %s: missing sensorId", curveConfig.ID)
-			}
-
-			if !sensorIdExists(curveConfig.Linear.Sensor, config) {
-				return fmt.Errorf("curve %s: no sensor definition with id '%s' found", curveConfig.ID, curveConfig.Linear.Sensor)
+				v.error(path+".linear.sensor", "curve %s: missing sensorId", curveConfig.ID)
+			} else if !sensorIdExists(curveConfig.Linear.Sensor, config) {
+				v.error(path+".linear.sensor", "curve %s: no sensor definition with id '%s' found", curveConfig.ID, curveConfig.Linear.Sensor)
 			}
+			v.validateLinearCurve(path+".linear", curveConfig)
 		}
 
 		if curveConfig.PID != nil {
 			if len(curveConfig.PID.Sensor) <= 0 {
-				return fmt.Errorf("curve %s: missing sensorId", curveConfig.ID)
-			}
-
-			if !sensorIdExists(curveConfig.PID.Sensor, config) {
-				return fmt.Errorf("curve %s: no sensor definition with id '%s' found", curveConfig.ID, curveConfig.PID.Sensor)
+				v.error(path+".pid.sensor", "curve %s: missing sensorId", curveConfig.ID)
+			} else if !sensorIdExists(curveConfig.PID.Sensor, config) {
+				v.error(path+".pid.sensor", "curve %s: no sensor definition with id '%s' found", curveConfig.ID, curveConfig.PID.Sensor)
 			}
 
 			pidConfig := curveConfig.PID
 			if pidConfig.P == 0 && pidConfig.I == 0 && pidConfig.D == 0 {
-				return fmt.Errorf("curve %s: all PID constants are zero", curveConfig.ID)
+				v.error(path+".pid", "curve %s: all PID constants are zero", curveConfig.ID)
+			}
+			if pidConfig.DerivativeFilter < 0 {
+				v.error(path+".pid.derivativeFilter", "curve %s: invalid derivativeFilter, must be >= 0", curveConfig.ID)
 			}
 		}
 
 	}
 
-	err := validateNoLoops(graph)
-	return err
+	v.validateNoLoops(graph)
+}
+
+func (v *validator) validateLinearCurve(path string, curveConfig CurveConfig) {
+	linear := curveConfig.Linear
+	if len(linear.Steps) <= 0 {
+		if linear.Min >= linear.Max {
+			v.error(path+".max", "curve %s: max must be greater than min", curveConfig.ID)
+		}
+		return
+	}
+
+	temps := make([]int, 0, len(linear.Steps))
+	for temp := range linear.Steps {
+		temps = append(temps, temp)
+	}
+	sort.Ints(temps)
+
+	for idx, temp := range temps {
+		value := linear.Steps[temp]
+		stepPath := fmt.Sprintf("%s.steps.%d", path, temp)
+		if value < 0 || value > 255 {
+			v.error(stepPath, "curve %s: invalid step value %v at %d, must be within 0..255", curveConfig.ID, value, temp)
+		}
+		if idx > 0 && value < linear.Steps[temps[idx-1]] {
+			v.warning(stepPath, "curve %s: steps are not monotonic, value %v at %d is lower than %v at %d", curveConfig.ID, value, temp, linear.Steps[temps[idx-1]], temps[idx-1])
+		}
+	}
 }
 
 func sensorIdExists(sensorId string, config *Configuration) bool {
@@ -202,14 +502,13 @@ func sensorIdExists(sensorId string, config *Configuration) bool {
 	return false
 }
 
-func validateNoLoops(graph map[interface{}][]interface{}) error {
+func (v *validator) validateNoLoops(graph map[interface{}][]interface{}) {
 	output := tarjan.Connections(graph)
 	for _, items := range output {
 		if len(items) > 1 {
-			return fmt.Errorf("you have created a curve dependency cycle: %v", items)
+			v.error("curves", "you have created a curve dependency cycle: %v", items)
 		}
 	}
-	return nil
 }
 
 func isCurveConfigInUse(config CurveConfig, curves []CurveConfig, fans []FanConfig) bool {
@@ -230,12 +529,14 @@ func isCurveConfigInUse(config CurveConfig, curves []CurveConfig, fans []FanConf
 	return false
 }
 
-func validateFans(config *Configuration) error {
+func (v *validator) validateFans() {
+	config := v.config
 	fanIds := []string{}
 
-	for _, fanConfig := range config.Fans {
+	for idx, fanConfig := range config.Fans {
+		path := fmt.Sprintf("fans[%d]", idx)
 		if slices.Contains(fanIds, fanConfig.ID) {
-			return fmt.Errorf("duplicate fan id detected: %s", fanConfig.ID)
+			v.error(path+".id", "duplicate fan id detected: %s", fanConfig.ID)
 		}
 		fanIds = append(fanIds, fanConfig.ID)
 
@@ -249,62 +550,201 @@ func validateFans(config *Configuration) error {
 		if fanConfig.Cmd != nil {
 			subConfigs++
 		}
+		if fanConfig.CoolingDevice != nil {
+			subConfigs++
+		}
+		if fanConfig.Discrete != nil {
+			subConfigs++
+		}
+		if fanConfig.Plugin != nil {
+			subConfigs++
+		}
 
 		if subConfigs > 1 {
-			return fmt.Errorf("fan %s: only one fan type can be used per fan definition block", fanConfig.ID)
+			v.error(path, "fan %s: only one fan type can be used per fan definition block", fanConfig.ID)
+			continue
 		}
 		if subConfigs <= 0 {
-			return fmt.Errorf("fan %s: sub-configuration for fan is missing, use one of: hwmon | file | cmd", fanConfig.ID)
+			v.error(path, "fan %s: sub-configuration for fan is missing, use one of: hwmon | file | cmd | coolingDevice | discrete | plugin", fanConfig.ID)
+			continue
 		}
 
+		v.validatePwmBoundaries(path, fanConfig)
+
 		if len(fanConfig.Curve) <= 0 {
-			return fmt.Errorf("fan %s: missing curve definition in configuration entry", fanConfig.ID)
+			v.error(path+".curve", "fan %s: missing curve definition in configuration entry", fanConfig.ID)
+		} else if !curveIdExists(fanConfig.Curve, config) {
+			v.error(path+".curve", "fan %s: no curve definition with id '%s' found", fanConfig.ID, fanConfig.Curve)
+		}
+
+		if fanConfig.CoolingDevice != nil && len(fanConfig.CoolingDevice.Type) <= 0 {
+			v.error(path+".coolingDevice.type", "fan %s: missing cooling device type", fanConfig.ID)
+		}
+
+		if fanConfig.Plugin != nil {
+			if !pluginIdExists(fanConfig.Plugin.ID, config) {
+				v.error(path+".plugin.id", "fan %s: no plugin definition with id '%s' found", fanConfig.ID, fanConfig.Plugin.ID)
+			}
+			if len(fanConfig.Plugin.Fan) <= 0 {
+				v.error(path+".plugin.fan", "fan %s: missing plugin fan id", fanConfig.ID)
+			}
 		}
 
-		if !curveIdExists(fanConfig.Curve, config) {
-			return fmt.Errorf("fan %s: no curve definition with id '%s' found", fanConfig.ID, fanConfig.Curve)
+		if fanConfig.Discrete != nil {
+			v.validateDiscreteFan(path+".discrete", fanConfig)
 		}
 
 		if fanConfig.HwMon != nil {
-			if (fanConfig.HwMon.Index != 0 && fanConfig.HwMon.RpmChannel != 0) || (fanConfig.HwMon.Index == 0 && fanConfig.HwMon.RpmChannel == 0) {
-				return fmt.Errorf("fan %s: must have one of index or rpmChannel, must be >= 1", fanConfig.ID)
+			hwMonPath := path + ".hwMon"
+			selectors := 0
+			for _, set := range []bool{fanConfig.HwMon.Index != 0, fanConfig.HwMon.RpmChannel != 0, len(fanConfig.HwMon.Label) > 0} {
+				if set {
+					selectors++
+				}
+			}
+			if selectors != 1 {
+				v.error(hwMonPath, "fan %s: must have one of index, rpmChannel or label, index and rpmChannel must be >= 1", fanConfig.ID)
+			}
+			if len(fanConfig.HwMon.Label) > 0 {
+				if _, err := regexp.Compile(fanConfig.HwMon.Label); err != nil {
+					v.error(hwMonPath+".label", "fan %s: invalid label regex: %v", fanConfig.ID, err)
+				}
 			}
 			if fanConfig.HwMon.Index < 0 {
-				return fmt.Errorf("fan %s: invalid index, must be >= 1", fanConfig.ID)
+				v.error(hwMonPath+".index", "fan %s: invalid index, must be >= 1", fanConfig.ID)
 			}
 			if fanConfig.HwMon.RpmChannel < 0 {
-				return fmt.Errorf("fan %s: invalid rpmChannel, must be >= 1", fanConfig.ID)
+				v.error(hwMonPath+".rpmChannel", "fan %s: invalid rpmChannel, must be >= 1", fanConfig.ID)
 			}
 			if fanConfig.HwMon.PwmChannel < 0 {
-				return fmt.Errorf("fan %s: invalid pwmChannel, must be >= 1", fanConfig.ID)
+				v.error(hwMonPath+".pwmChannel", "fan %s: invalid pwmChannel, must be >= 1", fanConfig.ID)
 			}
 		}
 
 		if fanConfig.File != nil {
 			if len(fanConfig.File.Path) <= 0 {
-				return fmt.Errorf("fan %s: no file path provided", fanConfig.ID)
+				v.error(path+".file.path", "fan %s: no file path provided", fanConfig.ID)
+			}
+			if fanConfig.File.Range != nil && fanConfig.File.Range.Min == fanConfig.File.Range.Max {
+				v.error(path+".file.range", "fan %s: invalid range, min and max must differ", fanConfig.ID)
 			}
 		}
 
-		if fanConfig.Cmd != nil {
+		if fanConfig.Cmd != nil && fanConfig.Cmd.Range != nil && fanConfig.Cmd.Range.Min == fanConfig.Cmd.Range.Max {
+			v.error(path+".cmd.range", "fan %s: invalid range, min and max must differ", fanConfig.ID)
+		}
+
+		if fanConfig.Cmd != nil && fanConfig.Cmd.Coprocess != nil {
+			v.validateCoprocess(path+".cmd.coprocess", fmt.Sprintf("fan %s", fanConfig.ID), fanConfig.Cmd.Coprocess)
+		} else if fanConfig.Cmd != nil {
 			cmdConfig := fanConfig.Cmd
 			if cmdConfig.SetPwm == nil {
-				return fmt.Errorf("fan %s: missing setPwm configuration", fanConfig.ID)
-			}
-			if len(cmdConfig.SetPwm.Exec) <= 0 {
-				return fmt.Errorf("fan %s: setPwm executable is missing", fanConfig.ID)
+				v.error(path+".cmd.setPwm", "fan %s: missing setPwm configuration", fanConfig.ID)
+			} else if len(cmdConfig.SetPwm.Exec) <= 0 {
+				v.error(path+".cmd.setPwm.exec", "fan %s: setPwm executable is missing", fanConfig.ID)
 			}
 
 			if cmdConfig.GetPwm == nil {
-				return fmt.Errorf("fan %s: missing getPwm configuration", fanConfig.ID)
+				v.error(path+".cmd.getPwm", "fan %s: missing getPwm configuration", fanConfig.ID)
+			} else if len(cmdConfig.GetPwm.Exec) <= 0 {
+				v.error(path+".cmd.getPwm.exec", "fan %s: getPwm executable is missing", fanConfig.ID)
 			}
-			if len(cmdConfig.GetPwm.Exec) <= 0 {
-				return fmt.Errorf("fan %s: getPwm executable is missing", fanConfig.ID)
+		}
+
+		if fanConfig.ControlLoop != nil {
+			v.validateControlLoop(path+".controlLoop", fanConfig)
+		}
+	}
+}
+
+func (v *validator) validateControlLoop(path string, fanConfig FanConfig) {
+	controlLoop := fanConfig.ControlLoop
+	switch controlLoop.Type {
+	case "", ControlLoopTypePid, ControlLoopTypeDirect:
+	case ControlLoopTypeSlew:
+		if controlLoop.SlewRate <= 0 {
+			v.error(path+".slewRate", "fan %s: invalid controlLoop slewRate, must be > 0", fanConfig.ID)
+		}
+	default:
+		v.error(path+".type", "fan %s: unsupported controlLoop type '%s', use one of: pid | direct | slew", fanConfig.ID, controlLoop.Type)
+	}
+}
+
+func (v *validator) validatePwmBoundaries(path string, fanConfig FanConfig) {
+	// boundaries which are out of range are reported once and skipped when comparing boundaries with each other
+	minPwm := v.validPwmBoundary(path, fanConfig, "minPwm", fanConfig.MinPwm)
+	startPwm := v.validPwmBoundary(path, fanConfig, "startPwm", fanConfig.StartPwm)
+	maxPwm := v.validPwmBoundary(path, fanConfig, "maxPwm", fanConfig.MaxPwm)
+
+	if minPwm != nil && maxPwm != nil && *minPwm > *maxPwm {
+		v.error(path+".minPwm", "fan %s: minPwm must not be greater than maxPwm", fanConfig.ID)
+	}
+	if startPwm != nil && minPwm != nil && *startPwm < *minPwm {
+		v.error(path+".startPwm", "fan %s: startPwm must not be lower than minPwm", fanConfig.ID)
+	}
+	if startPwm != nil && maxPwm != nil && *startPwm > *maxPwm {
+		v.error(path+".startPwm", "fan %s: startPwm must not be greater than maxPwm", fanConfig.ID)
+	}
+
+	if fanConfig.PwmMap != nil {
+		pwmMap := *fanConfig.PwmMap
+		keys := make([]int, 0, len(pwmMap))
+		for key := range pwmMap {
+			keys = append(keys, key)
+		}
+		sort.Ints(keys)
+		for _, key := range keys {
+			if !isValidPwm(key) || !isValidPwm(pwmMap[key]) {
+				v.error(fmt.Sprintf("%s.pwmMap.%d", path, key), "fan %s: invalid pwmMap entry %d: %d, values must be within 0..255", fanConfig.ID, key, pwmMap[key])
 			}
 		}
 	}
+}
+
+// validPwmBoundary returns the given boundary, or nil if it isn't set or out of range
+func (v *validator) validPwmBoundary(path string, fanConfig FanConfig, name string, value *int) *int {
+	if value == nil {
+		return nil
+	}
+	if !isValidPwm(*value) {
+		v.error(path+"."+name, "fan %s: invalid %s, must be within 0..255", fanConfig.ID, name)
+		return nil
+	}
+	return value
+}
+
+func isValidPwm(value int) bool {
+	return value >= 0 && value <= 255
+}
+
+func (v *validator) validateCoprocess(path string, name string, coprocess *CoprocessConfig) {
+	if len(coprocess.Exec) <= 0 {
+		v.error(path+".exec", "%s: coprocess executable is missing", name)
+	}
+	if coprocess.Timeout < 0 {
+		v.error(path+".timeout", "%s: invalid coprocess timeout, must be >= 0", name)
+	}
+}
 
-	return nil
+func (v *validator) validateDiscreteFan(path string, fanConfig FanConfig) {
+	discrete := fanConfig.Discrete
+	if len(discrete.Path) <= 0 {
+		v.error(path+".path", "fan %s: missing path", fanConfig.ID)
+	}
+	if len(discrete.Levels) < 2 {
+		v.error(path+".levels", "fan %s: at least two levels are required", fanConfig.ID)
+	}
+	if discrete.Hysteresis < 0 {
+		v.error(path+".hysteresis", "fan %s: invalid hysteresis, must be >= 0", fanConfig.ID)
+	}
+	for _, pattern := range []struct {
+		name  string
+		value string
+	}{{"readPattern", discrete.ReadPattern}, {"rpmPattern", discrete.RpmPattern}} {
+		if _, err := regexp.Compile(pattern.value); err != nil {
+			v.error(path+"."+pattern.name, "fan %s: invalid pattern '%s': %v", fanConfig.ID, pattern.value, err)
+		}
+	}
 }
 
 func curveIdExists(curveId string, config *Configuration) bool {
@@ -316,3 +756,86 @@ func curveIdExists(curveId string, config *Configuration) bool {
 
 	return false
 }
+
+// LocateProblems sets the file and line of all given problems, whose path can be found within the config file at the given path,
+// or within one of the files it includes. The file is only set if it differs from the given config file.
+func LocateProblems(problems []ValidationProblem, configPath string) {
+	document, err := readConfigDocument(configPath)
+	if err != nil {
+		return
+	}
+	for idx := range problems {
+		node := findNode(document.root, problems[idx].Path)
+		if node == nil || node.Line <= 0 {
+			continue
+		}
+		problems[idx].Line = node.Line
+		if file := document.origins[node]; file != configPath {
+			problems[idx].File = file
+		}
+	}
+}
+
+// findNode returns the node at the given path, f.ex. "fans[2].hwMon.index". For a mapping entry the key node is returned.
+// If the path does not exist, the node of its closest existing parent is returned.
+func findNode(node *yaml.Node, path string) *yaml.Node {
+	if len(path) <= 0 {
+		return nil
+	}
+	var result *yaml.Node
+	for _, segment := range strings.Split(path, ".") {
+		key, indices := splitPathSegment(segment)
+		keyNode, valueNode := findMappingEntry(node, key)
+		if valueNode == nil {
+			return result
+		}
+		result = keyNode
+		node = valueNode
+		for _, index := range indices {
+			if node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+				return result
+			}
+			node = node.Content[index]
+			result = node
+		}
+	}
+	return result
+}
+
+// splitPathSegment splits a path segment like "fans[2]" into its key and indices
+func splitPathSegment(segment string) (key string, indices []int) {
+	parts := strings.Split(segment, "[")
+	for _, part := range parts[1:] {
+		index, err := strconv.Atoi(strings.TrimSuffix(part, "]"))
+		if err == nil {
+			indices = append(indices, index)
+		}
+	}
+	return parts[0], indices
+}
+
+// findMappingValue returns the value of the given key within a mapping node, keys are matched case-insensitively like viper does
+func findMappingValue(node *yaml.Node, key string) *yaml.Node {
+	_, valueNode := findMappingEntry(node, key)
+	return valueNode
+}
+
+// findMappingEntry returns the key and value node of the given key within a mapping node, keys are matched case-insensitively like viper does.
+// Within a sequence node, the key is searched in all of its mappings, f.ex. for steps written as a list of single entry maps.
+func findMappingEntry(node *yaml.Node, key string) (keyNode *yaml.Node, valueNode *yaml.Node) {
+	if node != nil && node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			if keyNode, valueNode = findMappingEntry(item, key); valueNode != nil {
+				return keyNode, valueNode
+			}
+		}
+		return nil, nil
+	}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	if idx := findMappingIndex(node, key); idx >= 0 {
+		return node.Content[idx], node.Content[idx+1]
+	}
+	return nil, nil
+}
@@ -2,9 +2,13 @@ package configuration
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func TestValidateDuplicateFanId(t *testing.T) {
@@ -74,7 +78,7 @@ func TestValidateFanSubConfigIsMissing(t *testing.T) {
 	err := validateConfig(&config, "")
 
 	// THEN
-	assert.EqualError(t, err, "fan fan: sub-configuration for fan is missing, use one of: hwmon | file | cmd")
+	assert.EqualError(t, err, "fan fan: sub-configuration for fan is missing, use one of: hwmon | file | cmd | coolingDevice | discrete | plugin")
 }
 
 func TestValidateFanCurveWithIdIsNotDefined(t *testing.T) {
@@ -96,7 +100,7 @@ func TestValidateFanCurveWithIdIsNotDefined(t *testing.T) {
 	err := validateConfig(&config, "")
 
 	// THEN
-	assert.EqualError(t, err, "fan fan: no curve definition with id 'curve' found")
+	assert.EqualError(t, err, "fan fan: no curve definition with id 'curve' found\nfan fan: no file path provided")
 }
 
 func TestValidateCurveSubConfigSensorIdIsMissing(t *testing.T) {
@@ -332,6 +336,37 @@ func TestValidateCurve(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestValidatePidCurveNegativeDerivativeFilter(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				PID: &PidCurveConfig{
+					Sensor:           "sensor",
+					SetPoint:         60,
+					P:                -0.05,
+					DerivativeFilter: -time.Second,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "curve curve: invalid derivativeFilter, must be >= 0")
+}
+
 func TestValidateCurveFunctionTypeUnsupported(t *testing.T) {
 	// GIVEN
 	config := Configuration{
@@ -352,7 +387,7 @@ func TestValidateCurveFunctionTypeUnsupported(t *testing.T) {
 	err := validateConfig(&config, "")
 
 	// THEN
-	assert.EqualError(t, err, "curve curve1: unsupported function type 'unsupported', use one of: minimum | average | maximum | delta | sum | difference")
+	assert.EqualError(t, err, "curve curve1: unsupported function type 'unsupported', use one of: minimum | average | maximum | delta | sum | difference\ncurve curve1: no curve definition with id 'curve2' found")
 }
 
 func TestValidateSensorSubConfigSensorIdIsMissing(t *testing.T) {
@@ -369,7 +404,7 @@ func TestValidateSensorSubConfigSensorIdIsMissing(t *testing.T) {
 	err := validateConfig(&config, "")
 
 	// THEN
-	assert.EqualError(t, err, "sensor sensor: sub-configuration for sensor is missing, use one of: hwmon | file | cmd")
+	assert.EqualError(t, err, "sensor sensor: sub-configuration for sensor is missing, use one of: hwmon | file | cmd | rapl | http | push | mqtt | thermalZone | plugin | replay")
 }
 
 func TestValidateSensor(t *testing.T) {
@@ -454,7 +489,7 @@ func TestValidateFanHasIndexOrChannel(t *testing.T) {
 	err := validateConfig(&config, "")
 
 	// THEN
-	assert.EqualError(t, err, "fan fan: must have one of index or rpmChannel, must be >= 1")
+	assert.EqualError(t, err, "fan fan: must have one of index, rpmChannel or label, index and rpmChannel must be >= 1")
 }
 
 func TestValidateFanIndex(t *testing.T) {
@@ -537,6 +572,124 @@ func TestValidateFanChannel(t *testing.T) {
 	assert.EqualError(t, err, "fan fan: invalid rpmChannel, must be >= 1")
 }
 
+func TestValidateFanIndexAndLabel(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				HwMon: &HwMonFanConfig{
+					Index: 1,
+					Label: "CPU_FAN",
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "fan fan: must have one of index, rpmChannel or label, index and rpmChannel must be >= 1")
+}
+
+func TestValidateFanInvalidLabelRegex(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				HwMon: &HwMonFanConfig{
+					Label: "CPU_FAN(",
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "fan fan: invalid label regex: error parsing regexp: missing closing ): `CPU_FAN(`")
+}
+
+func TestValidateSensorIndexAndLabel(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				File:  &FileFanConfig{Path: "/tmp/fan"},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				HwMon: &HwMonSensorConfig{
+					Platform: "k10temp",
+					Index:    1,
+					Label:    "Tctl",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "sensor sensor: must have only one of index or label")
+}
+
 func TestValidateFanPwmChannel(t *testing.T) {
 	// GIVEN
 	config := Configuration{
@@ -577,3 +730,614 @@ func TestValidateFanPwmChannel(t *testing.T) {
 	// THEN
 	assert.EqualError(t, err, "fan fan: invalid pwmChannel, must be >= 1")
 }
+
+func TestValidateHttpSensorMissingUrl(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				Http: &HttpSensorConfig{
+					JsonPath: "value",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "sensor sensor: missing url")
+}
+
+func TestValidateHttpSensorJsonPathOrMetric(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				Http: &HttpSensorConfig{
+					Url: "http://localhost:9100/metrics",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "sensor sensor: must have exactly one of jsonPath or metric")
+}
+
+func TestValidatePushSensorExpiry(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				Push: &PushSensorConfig{
+					Expiry: -1 * time.Second,
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "sensor sensor: invalid expiry, must be >= 0")
+}
+
+func TestValidateMqttSensorMissingTopic(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{
+				ID:   "sensor",
+				Mqtt: &MqttSensorConfig{},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "sensor sensor: missing topic")
+}
+
+func TestValidateMqttMissingBroker(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Mqtt: MqttConfig{
+			Enabled:         true,
+			TopicPrefix:     "fan2go",
+			PublishInterval: 10 * time.Second,
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "mqtt: missing broker")
+}
+
+func TestValidateThermalZoneSensorMissingType(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{
+				ID:          "sensor",
+				ThermalZone: &ThermalZoneSensorConfig{},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "sensor sensor: missing thermal zone type")
+}
+
+func TestValidateFanInvalidRange(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+				},
+			},
+		},
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				File: &FileFanConfig{
+					Path:  "/sys/class/some/file",
+					Range: &FanValueRange{Min: 100, Max: 100},
+				},
+			},
+		},
+	}
+
+	// WHEN
+	v := &validator{config: &config}
+	v.validateFans()
+	err := v.result().Err()
+
+	// THEN
+	assert.EqualError(t, err, "fan fan: invalid range, min and max must differ")
+}
+
+func TestValidateFanControlLoopUnsupportedType(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+				},
+			},
+		},
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				File: &FileFanConfig{
+					Path: "/sys/class/some/file",
+				},
+				ControlLoop: &ControlLoopConfig{Type: "bang-bang"},
+			},
+		},
+	}
+
+	// WHEN
+	v := &validator{config: &config}
+	v.validateFans()
+	err := v.result().Err()
+
+	// THEN
+	assert.EqualError(t, err, "fan fan: unsupported controlLoop type 'bang-bang', use one of: pid | direct | slew")
+}
+
+func TestValidateFanControlLoopInvalidSlewRate(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+				},
+			},
+		},
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				File: &FileFanConfig{
+					Path: "/sys/class/some/file",
+				},
+				ControlLoop: &ControlLoopConfig{Type: ControlLoopTypeSlew},
+			},
+		},
+	}
+
+	// WHEN
+	v := &validator{config: &config}
+	v.validateFans()
+	err := v.result().Err()
+
+	// THEN
+	assert.EqualError(t, err, "fan fan: invalid controlLoop slewRate, must be > 0")
+}
+
+func TestValidateFanInvalidMinPwm(t *testing.T) {
+	// GIVEN
+	minPwm := 300
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:     "fan",
+				MinPwm: &minPwm,
+				File: &FileFanConfig{
+					Path: "/sys/class/some/file",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	v := &validator{config: &config}
+	v.validateFans()
+	err := v.result().Err()
+
+	// THEN
+	assert.EqualError(t, err, "fan fan: invalid minPwm, must be within 0..255\nfan fan: missing curve definition in configuration entry")
+}
+
+func TestValidateFanPwmBoundariesAllProblems(t *testing.T) {
+	// GIVEN
+	minPwm := 300
+	startPwm := 120
+	maxPwm := 100
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:       "fan",
+				Curve:    "curve",
+				MinPwm:   &minPwm,
+				StartPwm: &startPwm,
+				MaxPwm:   &maxPwm,
+				PwmMap:   &map[int]int{0: 0, 128: 300, 300: 255},
+				File: &FileFanConfig{
+					Path: "/sys/class/some/file",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	v := &validator{config: &config}
+	v.validatePwmBoundaries("fans[0]", config.Fans[0])
+
+	// THEN
+	assert.Equal(t, []ValidationProblem{
+		{Severity: SeverityError, Path: "fans[0].minPwm", Message: "fan fan: invalid minPwm, must be within 0..255"},
+		{Severity: SeverityError, Path: "fans[0].startPwm", Message: "fan fan: startPwm must not be greater than maxPwm"},
+		{Severity: SeverityError, Path: "fans[0].pwmMap.128", Message: "fan fan: invalid pwmMap entry 128: 300, values must be within 0..255"},
+		{Severity: SeverityError, Path: "fans[0].pwmMap.300", Message: "fan fan: invalid pwmMap entry 300: 255, values must be within 0..255"},
+	}, v.problems)
+}
+
+func TestValidateCmdFanCoprocessMissingExec(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+				},
+			},
+		},
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				Cmd: &CmdFanConfig{
+					Coprocess: &CoprocessConfig{},
+				},
+			},
+		},
+	}
+
+	// WHEN
+	v := &validator{config: &config}
+	v.validateFans()
+	err := v.result().Err()
+
+	// THEN
+	assert.EqualError(t, err, "fan fan: coprocess executable is missing")
+}
+
+func TestValidatePluginSensorUnknownPlugin(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Plugins: []PluginConfig{
+			{
+				ID:     "usb",
+				Socket: "/run/fan2go/usb.sock",
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				Plugin: &PluginSensorConfig{
+					ID:     "pdu",
+					Sensor: "probe1",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "sensor sensor: no plugin definition with id 'pdu' found")
+}
+
+func TestValidatePluginMissingSocket(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Plugins: []PluginConfig{
+			{
+				ID: "usb",
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "plugin usb: missing socket")
+}
+
+func TestValidateReplaySensorMissingFile(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{
+				ID:     "sensor",
+				Replay: &ReplaySensorConfig{},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "sensor sensor: missing replay file")
+}
+
+func TestValidateRecordMissingFile(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Record: RecordConfig{
+			Enabled:  true,
+			Interval: time.Second,
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "record: missing file")
+}
+
+func TestValidateFanStartPwmOutsideOfBoundaries(t *testing.T) {
+	// GIVEN
+	minPwm := 50
+	startPwm := 40
+	maxPwm := 30
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+		},
+		Fans: []FanConfig{
+			{
+				ID:       "fan",
+				Curve:    "curve",
+				MinPwm:   &minPwm,
+				StartPwm: &startPwm,
+				MaxPwm:   &maxPwm,
+				File: &FileFanConfig{
+					Path: "/sys/class/some/file",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	v := &validator{config: &config}
+	v.validateFans()
+	err := v.result().Err()
+
+	// THEN
+	assert.EqualError(t, err, "fan fan: minPwm must not be greater than maxPwm\n"+
+		"fan fan: startPwm must not be lower than minPwm\n"+
+		"fan fan: startPwm must not be greater than maxPwm")
+}
+
+func TestValidateFanInvalidPwmMap(t *testing.T) {
+	// GIVEN
+	pwmMap := map[int]int{0: 0, 128: 300, 255: 255}
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+		},
+		Fans: []FanConfig{
+			{
+				ID:     "fan",
+				Curve:  "curve",
+				PwmMap: &pwmMap,
+				File: &FileFanConfig{
+					Path: "/sys/class/some/file",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	v := &validator{config: &config}
+	v.validateFans()
+	result := v.result()
+
+	// THEN
+	assert.EqualError(t, result.Err(), "fan fan: invalid pwmMap entry 128: 300, values must be within 0..255")
+	assert.Equal(t, "fans[0].pwmMap.128", result.Problems[0].Path)
+}
+
+func TestValidateLinearCurveSteps(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "/sys/class/some/file",
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Steps: map[int]float64{
+						40: 100,
+						60: 80,
+						80: 300,
+					},
+				},
+			},
+		},
+	}
+
+	// WHEN
+	v := &validator{config: &config}
+	v.validateCurves()
+	result := v.result()
+
+	// THEN
+	assert.Equal(t, []ValidationProblem{
+		{Severity: SeverityWarning, Path: "curves[0]", Message: "Unused curve configuration: curve"},
+		{Severity: SeverityWarning, Path: "curves[0].linear.steps.60", Message: "curve curve: steps are not monotonic, value 80 at 60 is lower than 100 at 40"},
+		{Severity: SeverityError, Path: "curves[0].linear.steps.80", Message: "curve curve: invalid step value 300 at 80, must be within 0..255"},
+	}, result.Problems)
+}
+
+func TestValidateLinearCurveMinNotLowerThanMax(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "/sys/class/some/file",
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    80,
+					Max:    40,
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "curve curve: max must be greater than min")
+}
+
+func TestCheckLocatesProblemsInConfigFile(t *testing.T) {
+	// GIVEN
+	content := `sensors:
+  - id: sensor
+    file:
+      path: /sys/class/some/file
+curves:
+  - id: curve
+    linear:
+      sensor: sensor
+      min: 40
+      max: 80
+fans:
+  - id: fan
+    curve: missing
+    minPwm: 300
+    file:
+      path: /sys/class/some/file
+`
+	filePath := path.Join(t.TempDir(), "fan2go.yaml")
+	err := os.WriteFile(filePath, []byte(content), 0600)
+	assert.NoError(t, err)
+
+	minPwm := 300
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{ID: "sensor", File: &FileSensorConfig{Path: "/sys/class/some/file"}},
+		},
+		Curves: []CurveConfig{
+			{ID: "curve", Linear: &LinearCurveConfig{Sensor: "sensor", Min: 40, Max: 80}},
+		},
+		Fans: []FanConfig{
+			{ID: "fan", Curve: "missing", MinPwm: &minPwm, File: &FileFanConfig{Path: "/sys/class/some/file"}},
+		},
+	}
+
+	// WHEN
+	result := checkConfig(&config, filePath)
+	LocateProblems(result.Problems, filePath)
+
+	// THEN
+	assert.Equal(t, []ValidationProblem{
+		{Severity: SeverityWarning, Path: "curves[0]", Line: 6, Message: "Unused curve configuration: curve"},
+		{Severity: SeverityError, Path: "fans[0].minPwm", Line: 14, Message: "fan fan: invalid minPwm, must be within 0..255"},
+		{Severity: SeverityError, Path: "fans[0].curve", Line: 13, Message: "fan fan: no curve definition with id 'missing' found"},
+	}, result.Problems)
+	assert.EqualError(t, result.Err(), "line 14: fan fan: invalid minPwm, must be within 0..255\n"+
+		"line 13: fan fan: no curve definition with id 'missing' found")
+}
+
+func TestFindNodeFallsBackToParent(t *testing.T) {
+	// GIVEN
+	content := `fans:
+  - id: fan
+    hwmon:
+      platform: nct6798
+`
+	root := yaml.Node{}
+	err := yaml.Unmarshal([]byte(content), &root)
+	assert.NoError(t, err)
+
+	// WHEN
+	line := findNode(root.Content[0], "fans[0].hwMon.index").Line
+
+	// THEN
+	assert.Equal(t, 3, line)
+}
+
+func TestFindNodeInListOfMaps(t *testing.T) {
+	// GIVEN
+	content := `curves:
+  - id: curve
+    linear:
+      sensor: sensor
+      steps:
+        - 40: 100
+        - 60: 50
+`
+	root := yaml.Node{}
+	err := yaml.Unmarshal([]byte(content), &root)
+	assert.NoError(t, err)
+
+	// WHEN
+	line := findNode(root.Content[0], "curves[0].linear.steps.60").Line
+
+	// THEN
+	assert.Equal(t, 7, line)
+}
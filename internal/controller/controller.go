@@ -2,7 +2,7 @@ package controller
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"math"
 	"sort"
 	"sync"
@@ -12,6 +12,7 @@ import (
 	"github.com/markusressel/fan2go/internal/curves"
 	"github.com/markusressel/fan2go/internal/fans"
 	"github.com/markusressel/fan2go/internal/persistence"
+	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/markusressel/fan2go/internal/util"
 	"github.com/oklog/run"
@@ -28,6 +29,16 @@ type FanControllerStatistics struct {
 	MinPwmOffset            int
 }
 
+// FanControllerState is a copy of the state of a fan, as tracked by its controller
+type FanControllerState struct {
+	// Pwm is the last pwm value set to the fan, nil if none was set yet
+	Pwm *int
+	// RpmAvg is the moving average of the fan rpm, nil if it hasn't been measured yet
+	RpmAvg *float64
+	// Mode is the control mode last set by the controller, nil if it hasn't been set yet
+	Mode *fans.ControlMode
+}
+
 type FanController interface {
 	// Run starts the control loop
 	Run(ctx context.Context) error
@@ -39,10 +50,27 @@ type FanController interface {
 	// RunInitializationSequence for the given fan to determine its characteristics
 	RunInitializationSequence() (err error)
 
+	// Initialize prepares the controller for UpdateFanSpeed, this is done by Run automatically
+	Initialize() error
+
 	UpdateFanSpeed() error
+
+	// SetPwmOverride pins the fan to the given pwm value, nil resumes curve based control
+	SetPwmOverride(pwm *int)
+	// GetPwmOverride returns the pwm value the fan is pinned to, or nil
+	GetPwmOverride() *int
+
+	// SetCurve replaces the curve used to control the fan
+	SetCurve(curve curves.SpeedCurve)
+	// GetCurveId returns the id of the curve currently used to control the fan
+	GetCurveId() string
+	// GetLastCurveValue returns the value of the curve computed by the last update, false if there was none yet
+	GetLastCurveValue() (int, bool)
+	// GetState returns the state of the fan as tracked by the controller, which can be read by other components at any time
+	GetState() FanControllerState
 }
 
-type PidFanController struct {
+type DefaultFanController struct {
 	// controller statistics
 	stats FanControllerStatistics
 	// persistence where fan data is stored
@@ -63,40 +91,114 @@ type PidFanController struct {
 	pwmValuesWithDistinctTarget []int
 	// a map of x -> getPwm() where x is setPwm(x) for the controlled fan
 	pwmMap map[int]int
-	// PID loop for the PWM control
-	pidLoop *util.PidLoop
+	// control loop deciding how the pwm value approaches the target
+	controlLoop ControlLoop
 
 	// offset applied to the actual minPwm of the fan to ensure "neverStops" constraint
 	minPwmOffset int
+
+	// true while the device of the fan is absent
+	absent bool
+	// true while a sensor of the curve is absent and the fan runs at full speed
+	failSafe bool
+
+	// pwm value the fan is pinned to, ignoring its curve
+	pwmOverride *int
+	// guards curve and pwmOverride, which can be changed at runtime
+	overrideMutex sync.Mutex
+
+	// value of the curve computed by the last update, which can be read by other components at any time
+	lastCurveValue      *int
+	lastCurveValueMutex sync.Mutex
+
+	// state of the fan, which can be read by other components at any time
+	state      FanControllerState
+	stateMutex sync.Mutex
 }
 
 func NewFanController(
 	persistence persistence.Persistence,
 	fan fans.Fan,
-	pidLoop util.PidLoop,
+	controlLoop ControlLoop,
 	updateRate time.Duration,
 ) FanController {
-	return &PidFanController{
+	curve, _ := curves.GetSpeedCurve(fan.GetCurveId())
+	return &DefaultFanController{
 		persistence:                 persistence,
 		fan:                         fan,
-		curve:                       curves.SpeedCurveMap[fan.GetCurveId()],
+		curve:                       curve,
 		updateRate:                  updateRate,
 		pwmValuesWithDistinctTarget: []int{},
 		pwmMap:                      map[int]int{},
-		pidLoop:                     &pidLoop,
+		controlLoop:                 controlLoop,
 		minPwmOffset:                0,
 	}
 }
 
-func (f *PidFanController) GetFanId() string {
+func (f *DefaultFanController) GetFanId() string {
 	return f.fan.GetId()
 }
 
-func (f *PidFanController) GetStatistics() FanControllerStatistics {
+func (f *DefaultFanController) GetStatistics() FanControllerStatistics {
 	return f.stats
 }
 
-func (f *PidFanController) Run(ctx context.Context) error {
+func (f *DefaultFanController) SetPwmOverride(pwm *int) {
+	f.overrideMutex.Lock()
+	defer f.overrideMutex.Unlock()
+	f.pwmOverride = pwm
+}
+
+func (f *DefaultFanController) GetPwmOverride() *int {
+	f.overrideMutex.Lock()
+	defer f.overrideMutex.Unlock()
+	return f.pwmOverride
+}
+
+func (f *DefaultFanController) SetCurve(curve curves.SpeedCurve) {
+	f.overrideMutex.Lock()
+	defer f.overrideMutex.Unlock()
+	f.curve = curve
+}
+
+func (f *DefaultFanController) GetCurveId() string {
+	return f.getCurve().GetId()
+}
+
+func (f *DefaultFanController) GetLastCurveValue() (int, bool) {
+	f.lastCurveValueMutex.Lock()
+	defer f.lastCurveValueMutex.Unlock()
+	if f.lastCurveValue == nil {
+		return 0, false
+	}
+	return *f.lastCurveValue, true
+}
+
+func (f *DefaultFanController) setLastCurveValue(value int) {
+	f.lastCurveValueMutex.Lock()
+	defer f.lastCurveValueMutex.Unlock()
+	f.lastCurveValue = &value
+}
+
+func (f *DefaultFanController) GetState() FanControllerState {
+	f.stateMutex.Lock()
+	defer f.stateMutex.Unlock()
+	return f.state
+}
+
+func (f *DefaultFanController) updateState(update func(state *FanControllerState)) {
+	f.stateMutex.Lock()
+	defer f.stateMutex.Unlock()
+	update(&f.state)
+}
+
+func (f *DefaultFanController) getCurve() curves.SpeedCurve {
+	f.overrideMutex.Lock()
+	defer f.overrideMutex.Unlock()
+	return f.curve
+}
+
+func (f *DefaultFanController) Run(ctx context.Context) error {
 	fan := f.fan
 
 	if fan.ShouldNeverStop() && !fan.Supports(fans.FeatureRpmSensor) {
@@ -123,44 +225,11 @@ func (f *PidFanController) Run(ctx context.Context) error {
 	// wait a bit to gather monitoring data
 	time.Sleep(2*time.Second + configuration.CurrentConfig.TempSensorPollingRate*2)
 
-	// check if we have data for this fan in persistence,
-	// if not we need to run the initialization sequence
-	ui.Info("Loading fan curve data for fan '%s'...", fan.GetId())
-	fanPwmData, err := f.persistence.LoadFanPwmData(fan)
-	if err != nil {
-		_, ok := fan.(*fans.HwMonFan)
-		if ok {
-			ui.Warning("Fan '%s' has not yet been analyzed, starting initialization sequence...", fan.GetId())
-			err = f.RunInitializationSequence()
-			if err != nil {
-				return err
-			}
-		} else {
-			err = f.persistence.SaveFanPwmData(fan)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	fanPwmData, err = f.persistence.LoadFanPwmData(fan)
+	err = f.Initialize()
 	if err != nil {
 		return err
 	}
 
-	err = fan.AttachFanCurveData(&fanPwmData)
-	if err != nil {
-		return err
-	}
-
-	err1 := f.computePwmMap()
-	if err1 != nil {
-		ui.Warning("Error computing PWM map: %v", err1)
-	}
-
-	f.updateDistinctPwmValues()
-
-	ui.Debug("PWM map of fan '%s': %v", fan.GetId(), f.pwmMap)
 	ui.Info("PWM settings of fan '%s': Min %d, Start %d, Max %d", fan.GetId(), fan.GetMinPwm(), fan.GetStartPwm(), fan.GetMaxPwm())
 	ui.Info("Starting controller loop for fan '%s'", fan.GetId())
 
@@ -182,7 +251,12 @@ func (f *PidFanController) Run(ctx context.Context) error {
 					ui.Info("Stopping RPM monitor of fan controller for fan %s...", fan.GetId())
 					return nil
 				case <-tick.C:
-					measureRpm(fan)
+					if measureRpm(fan) {
+						rpmAvg := fan.GetRpmAvg()
+						f.updateState(func(state *FanControllerState) {
+							state.RpmAvg = &rpmAvg
+						})
+					}
 				}
 			}
 		}, func(err error) {
@@ -222,9 +296,81 @@ func (f *PidFanController) Run(ctx context.Context) error {
 	return err
 }
 
-func (f *PidFanController) UpdateFanSpeed() error {
+// Initialize loads the fan curve data of the fan, or measures it if necessary,
+// and computes the pwm map used by UpdateFanSpeed
+func (f *DefaultFanController) Initialize() error {
+	fan := f.fan
+
+	// check if we have data for this fan in persistence,
+	// if not we need to run the initialization sequence
+	ui.Info("Loading fan curve data for fan '%s'...", fan.GetId())
+	fanPwmData, err := f.persistence.LoadFanPwmData(fan)
+	if err != nil {
+		_, ok := fan.(*fans.HwMonFan)
+		if ok {
+			ui.Warning("Fan '%s' has not yet been analyzed, starting initialization sequence...", fan.GetId())
+			err = f.RunInitializationSequence()
+			if err != nil {
+				return err
+			}
+		} else {
+			err = f.persistence.SaveFanPwmData(fan)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	fanPwmData, err = f.persistence.LoadFanPwmData(fan)
+	if err != nil {
+		return err
+	}
+
+	err = fan.AttachFanCurveData(&fanPwmData)
+	if err != nil {
+		return err
+	}
+
+	err1 := f.computePwmMap()
+	if err1 != nil {
+		ui.Warning("Error computing PWM map: %v", err1)
+	}
+
+	f.updateDistinctPwmValues()
+
+	ui.Debug("PWM map of fan '%s': %v", fan.GetId(), f.pwmMap)
+	return nil
+}
+
+func (f *DefaultFanController) UpdateFanSpeed() error {
 	fan := f.fan
 
+	if fans.IsAbsent(fan.GetId()) {
+		if !f.absent {
+			ui.Warning("Device of fan %s is absent, waiting for it to reappear...", fan.GetId())
+			f.absent = true
+		}
+		return nil
+	}
+	if f.absent {
+		ui.Info("Device of fan %s reappeared, resuming control", fan.GetId())
+		f.absent = false
+		// the device was reset, so the last known state is meaningless
+		f.lastSetPwm = nil
+		f.controlLoop.Reset()
+	}
+
+	if pwmOverride := f.GetPwmOverride(); pwmOverride != nil {
+		// the state of the control loop is outdated once the override is removed
+		f.controlLoop.Reset()
+		f.setManualPwm()
+		err := f.setPwm(*pwmOverride)
+		if err != nil {
+			ui.Error("Error setting %s: %v", fan.GetId(), err)
+		}
+		return nil
+	}
+
 	lastSetPwm := 0
 	if f.lastSetPwm != nil {
 		lastSetPwm = *(f.lastSetPwm)
@@ -238,30 +384,28 @@ func (f *PidFanController) UpdateFanSpeed() error {
 
 	// calculate the direct optimal target speed
 	target := f.calculateTargetPwm()
+	if target < 0 {
+		return nil
+	}
 
-	// ask the PID controller how to proceed
-	pidChange := math.Ceil(f.pidLoop.Loop(float64(target), float64(lastSetPwm)))
-
-	// the last value set on the pid controller target
-	pidControllerTarget := math.Ceil(f.pidLoop.Loop(float64(target), float64(lastSetPwm)))
-	pidControllerTarget = pidControllerTarget + pidChange
-
-	// ensure we are within sane bounds
-	coerced := util.Coerce(float64(lastSetPwm)+pidControllerTarget, 0, 255)
-	roundedTarget := int(math.Round(coerced))
+	// ask the control loop how to proceed
+	nextPwm := f.controlLoop.Cycle(target, lastSetPwm)
+	if f.failSafe {
+		// don't let the control loop delay the fail-safe speed
+		f.controlLoop.Reset()
+		nextPwm = target
+	}
 
-	if target >= 0 {
-		_ = trySetManualPwm(f.fan)
-		err := f.setPwm(roundedTarget)
-		if err != nil {
-			ui.Error("Error setting %s: %v", fan.GetId(), err)
-		}
+	f.setManualPwm()
+	err := f.setPwm(nextPwm)
+	if err != nil {
+		ui.Error("Error setting %s: %v", fan.GetId(), err)
 	}
 
 	return nil
 }
 
-func (f *PidFanController) RunInitializationSequence() (err error) {
+func (f *DefaultFanController) RunInitializationSequence() (err error) {
 	fan := f.fan
 
 	err1 := f.computePwmMap()
@@ -344,8 +488,12 @@ func (f *PidFanController) RunInitializationSequence() (err error) {
 	return err
 }
 
-// read the current value of a fan RPM sensor and append it to the moving window
-func measureRpm(fan fans.Fan) {
+// read the current value of a fan RPM sensor and append it to the moving window,
+// returns false if the fan is absent
+func measureRpm(fan fans.Fan) bool {
+	if fans.IsAbsent(fan.GetId()) {
+		return false
+	}
 	pwm, err := fan.GetPwm()
 	if err != nil {
 		ui.Warning("Error reading PWM value of fan %s: %v", fan.GetId(), err)
@@ -360,6 +508,23 @@ func measureRpm(fan fans.Fan) {
 
 	pwmRpmMap := fan.GetFanCurveData()
 	(*pwmRpmMap)[pwm] = float64(rpm)
+	return true
+}
+
+// setManualPwm tries to enable manual pwm control of the fan and keeps track of the resulting control mode
+func (f *DefaultFanController) setManualPwm() {
+	if !f.fan.Supports(fans.FeatureControlMode) {
+		return
+	}
+	if trySetManualPwm(f.fan) == nil {
+		f.setControlModeState(fans.ControlModePWM)
+	}
+}
+
+func (f *DefaultFanController) setControlModeState(mode fans.ControlMode) {
+	f.updateState(func(state *FanControllerState) {
+		state.Mode = &mode
+	})
 }
 
 func trySetManualPwm(fan fans.Fan) error {
@@ -378,9 +543,23 @@ func trySetManualPwm(fan fans.Fan) error {
 	return err
 }
 
-func (f *PidFanController) restorePwmEnabled() {
+func (f *DefaultFanController) restorePwmEnabled() {
 	ui.Info("Trying to restore fan settings for %s...", f.fan.GetId())
 
+	if restorer, ok := f.fan.(fans.Restorer); ok {
+		err := restorer.Restore()
+		if err == nil {
+			// the fan decides on its own which mode it restores
+			f.updateState(func(state *FanControllerState) {
+				state.Mode = nil
+			})
+			return
+		}
+		ui.Warning("Error restoring fan %s: %v", f.fan.GetId(), err)
+		f.setMaxPwmOnRestoreFailure()
+		return
+	}
+
 	err := f.setPwm(f.originalPwmValue)
 	if err != nil {
 		ui.Warning("Error restoring original PWM value for fan %s: %v", f.fan.GetId(), err)
@@ -390,11 +569,16 @@ func (f *PidFanController) restorePwmEnabled() {
 	if f.fan.Supports(fans.FeatureControlMode) && f.originalPwmEnabled != fans.ControlModePWM {
 		err := f.fan.SetPwmEnabled(f.originalPwmEnabled)
 		if err == nil {
+			f.setControlModeState(f.originalPwmEnabled)
 			return
 		}
 	}
-	// if this fails, try to set it to max speed instead
-	err = f.setPwm(fans.MaxPwmValue)
+	f.setMaxPwmOnRestoreFailure()
+}
+
+// setMaxPwmOnRestoreFailure leaves the fan at max speed, if its original settings couldn't be restored
+func (f *DefaultFanController) setMaxPwmOnRestoreFailure() {
+	err := f.setPwm(fans.MaxPwmValue)
 	if err != nil {
 		ui.Warning("Unable to restore fan %s, make sure it is running!", f.fan.GetId())
 	}
@@ -402,12 +586,22 @@ func (f *PidFanController) restorePwmEnabled() {
 
 // calculates the optimal pwm for a fan with the given target level.
 // returns -1 if no rpm is detected even at fan.maxPwm
-func (f *PidFanController) calculateTargetPwm() int {
+func (f *DefaultFanController) calculateTargetPwm() int {
 	fan := f.fan
-	target, err := f.curve.Evaluate()
-	if err != nil {
+	target, err := f.getCurve().Evaluate()
+	if errors.Is(err, sensors.ErrSensorAbsent) {
+		if !f.failSafe {
+			ui.Warning("A sensor used by the curve of fan %s is absent, running at full speed until it reappears: %v", fan.GetId(), err)
+			f.failSafe = true
+		}
+		target = fans.MaxPwmValue
+	} else if err != nil {
 		ui.Fatal("Unable to calculate optimal PWM value for %s: %v", fan.GetId(), err)
+	} else if f.failSafe {
+		ui.Info("All sensors used by the curve of fan %s are available again, resuming control", fan.GetId())
+		f.failSafe = false
 	}
+	f.setLastCurveValue(target)
 
 	// ensure target value is within bounds of possible values
 	if target > fans.MaxPwmValue {
@@ -466,13 +660,16 @@ func (f *PidFanController) calculateTargetPwm() int {
 }
 
 // set the pwm speed of a fan to the specified value (0..255)
-func (f *PidFanController) setPwm(target int) (err error) {
+func (f *DefaultFanController) setPwm(target int) (err error) {
 	current, err := f.fan.GetPwm()
 
 	closestTarget := f.findClosestDistinctTarget(target)
 	closestExpected := f.pwmMap[closestTarget]
 
 	f.lastSetPwm = &target
+	f.updateState(func(state *FanControllerState) {
+		state.Pwm = &target
+	})
 	if err == nil {
 		if closestExpected == current {
 			// nothing to do
@@ -482,7 +679,7 @@ func (f *PidFanController) setPwm(target int) (err error) {
 	return f.fan.SetPwm(closestTarget)
 }
 
-func (f *PidFanController) waitForFanToSettle(fan fans.Fan) {
+func (f *DefaultFanController) waitForFanToSettle(fan fans.Fan) {
 	// TODO: this "waiting" logic could also be applied to the other measurements
 	diffThreshold := configuration.CurrentConfig.MaxRpmDiffForSettledFan
 
@@ -506,38 +703,26 @@ func (f *PidFanController) waitForFanToSettle(fan fans.Fan) {
 	ui.Debug("Fan %s has settled (current RPM max diff: %f)", fan.GetId(), measuredRpmDiffMax)
 }
 
-func (f *PidFanController) findClosestDistinctTarget(target int) int {
+func (f *DefaultFanController) findClosestDistinctTarget(target int) int {
 	return util.FindClosest(target, f.pwmValuesWithDistinctTarget)
 }
 
 // computePwmMap computes a mapping between "requested pwm value" -> "actual set pwm value"
-func (f *PidFanController) computePwmMap() (err error) {
+func (f *DefaultFanController) computePwmMap() (err error) {
 	if !configuration.CurrentConfig.RunFanInitializationInParallel {
 		InitializationSequenceMutex.Lock()
 		defer InitializationSequenceMutex.Unlock()
 	}
 
-	var configOverride *map[int]int
+	configOverride := f.fan.GetConfig().PwmMap
 
-	switch f := f.fan.(type) {
-	case *fans.HwMonFan:
-		c := f.Config.PwmMap
-		if c != nil {
-			configOverride = c
-		}
-	case *fans.CmdFan:
-		c := f.Config.PwmMap
-		if c != nil {
-			configOverride = c
-		}
-	case *fans.FileFan:
-		c := f.Config.PwmMap
-		if c != nil {
-			configOverride = c
+	if provider, ok := f.fan.(fans.PwmMapProvider); ok && configOverride == nil {
+		// the pwm values the fan applies are known upfront
+		pwmMap, err := provider.GetPwmMap()
+		if err != nil {
+			return err
 		}
-	default:
-		// if type is other than above
-		fmt.Println("Type is unknown!")
+		configOverride = &pwmMap
 	}
 
 	if configOverride != nil {
@@ -559,7 +744,7 @@ func (f *PidFanController) computePwmMap() (err error) {
 	return f.persistence.SaveFanPwmMap(f.fan.GetId(), f.pwmMap)
 }
 
-func (f *PidFanController) computePwmMapAutomatically() {
+func (f *DefaultFanController) computePwmMapAutomatically() {
 	fan := f.fan
 	_ = trySetManualPwm(fan)
 
@@ -579,7 +764,7 @@ func (f *PidFanController) computePwmMapAutomatically() {
 	_ = fan.SetPwm(f.pwmMap[fan.GetStartPwm()])
 }
 
-func (f *PidFanController) updateDistinctPwmValues() {
+func (f *DefaultFanController) updateDistinctPwmValues() {
 	var keys = util.ExtractKeysWithDistinctValues(f.pwmMap)
 	sort.Ints(keys)
 	f.pwmValuesWithDistinctTarget = keys
@@ -587,7 +772,7 @@ func (f *PidFanController) updateDistinctPwmValues() {
 	ui.Debug("Distinct PWM value targets of fan %s: %v", f.fan.GetId(), keys)
 }
 
-func (f *PidFanController) increaseMinPwmOffset() {
+func (f *DefaultFanController) increaseMinPwmOffset() {
 	f.minPwmOffset += 1
 	f.stats.MinPwmOffset = f.minPwmOffset
 	f.stats.IncreasedMinPwmCount += 1
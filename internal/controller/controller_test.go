@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"fmt"
 	"sort"
 	"testing"
 	"time"
@@ -46,6 +47,7 @@ func (sensor *MockSensor) SetMovingAvg(avg float64) {
 type MockCurve struct {
 	ID    string
 	Value int
+	Err   error
 }
 
 func (c MockCurve) GetId() string {
@@ -53,7 +55,7 @@ func (c MockCurve) GetId() string {
 }
 
 func (c MockCurve) Evaluate() (value int, err error) {
-	return c.Value, nil
+	return c.Value, c.Err
 }
 
 type MockFan struct {
@@ -125,7 +127,7 @@ func (fan MockFan) GetPwmEnabled() (int, error) {
 }
 
 func (fan *MockFan) SetPwmEnabled(value fans.ControlMode) (err error) {
-	panic("implement me")
+	return nil
 }
 
 func (fan MockFan) IsPwmAuto() (bool, error) {
@@ -136,6 +138,10 @@ func (fan MockFan) GetId() string {
 	return fan.ID
 }
 
+func (fan MockFan) GetConfig() configuration.FanConfig {
+	return configuration.FanConfig{ID: fan.ID}
+}
+
 func (fan MockFan) GetName() string {
 	return fan.ID
 }
@@ -311,7 +317,7 @@ func TestCalculateTargetSpeedLinear(t *testing.T) {
 		ID:    "curve",
 		Value: curveValue,
 	}
-	curves.SpeedCurveMap[curve.GetId()] = &curve
+	curves.ReplaceSpeedCurve(&curve)
 
 	fan := &MockFan{
 		ID:              "fan",
@@ -322,7 +328,7 @@ func TestCalculateTargetSpeedLinear(t *testing.T) {
 	}
 	fans.FanMap[fan.GetId()] = fan
 
-	controller := PidFanController{
+	controller := DefaultFanController{
 		persistence: mockPersistence{},
 		fan:         fan,
 		curve:       curve,
@@ -336,6 +342,9 @@ func TestCalculateTargetSpeedLinear(t *testing.T) {
 
 	// THEN
 	assert.Equal(t, 127, optimal)
+	lastCurveValue, ok := controller.GetLastCurveValue()
+	assert.True(t, ok)
+	assert.Equal(t, curveValue, lastCurveValue)
 }
 
 func TestCalculateTargetSpeedNeverStop(t *testing.T) {
@@ -354,7 +363,7 @@ func TestCalculateTargetSpeedNeverStop(t *testing.T) {
 		ID:    "curve",
 		Value: curveValue,
 	}
-	curves.SpeedCurveMap[curve.GetId()] = curve
+	curves.ReplaceSpeedCurve(curve)
 
 	fan := &MockFan{
 		ID:              "fan",
@@ -367,7 +376,7 @@ func TestCalculateTargetSpeedNeverStop(t *testing.T) {
 	}
 	fans.FanMap[fan.GetId()] = fan
 
-	controller := PidFanController{
+	controller := DefaultFanController{
 		persistence: mockPersistence{}, fan: fan,
 		curve:      curve,
 		updateRate: time.Duration(100),
@@ -424,7 +433,7 @@ func TestFanController_UpdateFanSpeed_FanCurveGaps(t *testing.T) {
 		ID:    "curve",
 		Value: curveValue,
 	}
-	curves.SpeedCurveMap[curve.GetId()] = curve
+	curves.ReplaceSpeedCurve(curve)
 
 	fan := &MockFan{
 		ID:              "fan",
@@ -453,7 +462,7 @@ func TestFanController_UpdateFanSpeed_FanCurveGaps(t *testing.T) {
 		255: 255,
 	}
 
-	controller := PidFanController{
+	controller := DefaultFanController{
 		persistence: mockPersistence{}, fan: fan,
 		curve:      curve,
 		updateRate: time.Duration(100),
@@ -470,3 +479,231 @@ func TestFanController_UpdateFanSpeed_FanCurveGaps(t *testing.T) {
 	closestTarget := controller.findClosestDistinctTarget(targetPwm)
 	assert.Equal(t, 58, closestTarget)
 }
+
+func TestFanController_UpdateFanSpeed_PwmOverride(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "curve",
+		Value: 100,
+	}
+	curves.ReplaceSpeedCurve(curve)
+
+	fan := &MockFan{
+		ID:         "fan",
+		PWM:        0,
+		curveId:    curve.GetId(),
+		speedCurve: &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := DefaultFanController{
+		persistence: mockPersistence{}, fan: fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      map[int]int{},
+		controlLoop: NewDirectControlLoop(),
+	}
+	for pwm := fans.MinPwmValue; pwm <= fans.MaxPwmValue; pwm++ {
+		controller.pwmMap[pwm] = pwm
+	}
+	controller.updateDistinctPwmValues()
+
+	pinned := 200
+	controller.SetPwmOverride(&pinned)
+
+	// WHEN
+	err := controller.UpdateFanSpeed()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 200, fan.PWM)
+
+	// WHEN
+	controller.SetPwmOverride(nil)
+
+	// THEN
+	assert.Nil(t, controller.GetPwmOverride())
+}
+
+func TestFanController_UpdateFanSpeed_DirectControlLoop(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "curve",
+		Value: 100,
+	}
+	curves.ReplaceSpeedCurve(curve)
+
+	fan := &MockFan{
+		ID:         "fan",
+		PWM:        0,
+		curveId:    curve.GetId(),
+		speedCurve: &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := DefaultFanController{
+		persistence: mockPersistence{}, fan: fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		controlLoop: NewDirectControlLoop(),
+	}
+	controller.updateDistinctPwmValues()
+
+	// WHEN
+	err := controller.UpdateFanSpeed()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 100, fan.PWM)
+	state := controller.GetState()
+	assert.Equal(t, 100, *state.Pwm)
+	assert.Equal(t, fans.ControlModePWM, *state.Mode)
+}
+
+func TestFanController_UpdateFanSpeed_AbsentFan(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "curve",
+		Value: 100,
+	}
+	curves.ReplaceSpeedCurve(curve)
+
+	fan := &MockFan{
+		ID:         "fan",
+		PWM:        0,
+		curveId:    curve.GetId(),
+		speedCurve: &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := DefaultFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		controlLoop: NewDirectControlLoop(),
+	}
+	controller.updateDistinctPwmValues()
+	fans.SetAbsent(fan.GetId(), true)
+	defer fans.SetAbsent(fan.GetId(), false)
+
+	// WHEN
+	err := controller.UpdateFanSpeed()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 0, fan.PWM)
+	assert.True(t, controller.absent)
+
+	// WHEN
+	fans.SetAbsent(fan.GetId(), false)
+	err = controller.UpdateFanSpeed()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 100, fan.PWM)
+	assert.False(t, controller.absent)
+}
+
+func TestFanController_UpdateFanSpeed_AbsentSensor(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "curve",
+		Value: 100,
+		Err:   fmt.Errorf("curve curve: %w", sensors.ErrSensorAbsent),
+	}
+	curves.ReplaceSpeedCurve(curve)
+
+	fan := &MockFan{
+		ID:         "fan",
+		PWM:        0,
+		curveId:    curve.GetId(),
+		speedCurve: &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := DefaultFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		controlLoop: NewSlewControlLoop(1),
+	}
+	controller.updateDistinctPwmValues()
+
+	// WHEN
+	err := controller.UpdateFanSpeed()
+
+	// THEN
+	// the fail-safe speed is applied immediately, regardless of the control loop
+	assert.NoError(t, err)
+	assert.Equal(t, 255, fan.PWM)
+	assert.True(t, controller.failSafe)
+
+	// WHEN
+	curve.Err = nil
+	controller.controlLoop = NewDirectControlLoop()
+	err = controller.UpdateFanSpeed()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 100, fan.PWM)
+	assert.False(t, controller.failSafe)
+}
+
+func TestFanController_UpdateFanSpeed_ExpiredPushSensor(t *testing.T) {
+	// GIVEN
+	sensor := &sensors.PushSensor{
+		Config: configuration.SensorConfig{
+			ID: "push_sensor",
+			Push: &configuration.PushSensorConfig{
+				Expiry: time.Minute,
+			},
+		},
+	}
+	sensor.PushValue(40000)
+	sensor.LastPush = time.Now().Add(-2 * time.Minute)
+	sensors.SensorMap[sensor.GetId()] = sensor
+
+	curve, err := curves.NewSpeedCurve(configuration.CurveConfig{
+		ID: "pid_curve",
+		PID: &configuration.PidCurveConfig{
+			Sensor:   sensor.GetId(),
+			SetPoint: 60,
+			P:        -0.05,
+			I:        -0.005,
+			D:        -0.005,
+		},
+	})
+	assert.NoError(t, err)
+	curves.ReplaceSpeedCurve(curve)
+
+	fan := &MockFan{
+		ID:         "fan",
+		PWM:        0,
+		curveId:    curve.GetId(),
+		speedCurve: &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := DefaultFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		controlLoop: NewDirectControlLoop(),
+	}
+	controller.updateDistinctPwmValues()
+
+	// WHEN
+	err = controller.UpdateFanSpeed()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 255, fan.PWM)
+	assert.True(t, controller.failSafe)
+}
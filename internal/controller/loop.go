@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+// ControlLoop decides how the pwm value of a fan approaches the target given by its curve
+type ControlLoop interface {
+	// Cycle returns the next pwm value (0..255) of the fan,
+	// given the target pwm value and the pwm value that was set last
+	Cycle(target int, lastSetPwm int) int
+	// Reset clears the state of the loop, f.ex. after the fan has been controlled by something else
+	Reset()
+}
+
+// NewControlLoop creates the control loop defined by the given config, nil results in the default pid loop.
+// The loop measures time using the given clock, nil uses the global clock.
+func NewControlLoop(config *configuration.ControlLoopConfig, clock util.Clock) (ControlLoop, error) {
+	if config == nil {
+		return NewDefaultPidControlLoop().WithClock(clock), nil
+	}
+
+	switch config.Type {
+	case "", configuration.ControlLoopTypePid:
+		return NewPidControlLoop(config.P, config.I, config.D).WithClock(clock), nil
+	case configuration.ControlLoopTypeDirect:
+		return NewDirectControlLoop(), nil
+	case configuration.ControlLoopTypeSlew:
+		return NewSlewControlLoop(config.SlewRate).WithClock(clock), nil
+	default:
+		return nil, fmt.Errorf("unsupported control loop type: %s", config.Type)
+	}
+}
+
+// pidIntegralLimit bounds the integral term of the pid control loop to less than half a pwm step.
+// Since the change computed in each cycle is rounded, the integral term alone can never move the fan,
+// it only helps the proportional term to overcome the rounding close to the target. Larger values
+// wind up while the fan approaches the target and cause a sustained overshoot.
+const pidIntegralLimit = 0.49
+
+// PidControlLoop uses a PID loop to compute the change of the pwm value in each cycle
+type PidControlLoop struct {
+	pidLoop *util.PidLoop
+}
+
+func NewPidControlLoop(p float64, i float64, d float64) *PidControlLoop {
+	return &PidControlLoop{
+		pidLoop: util.NewPidLoop(p, i, d).
+			WithOutputLimits(-fans.MaxPwmValue, fans.MaxPwmValue).
+			WithIntegralLimits(-pidIntegralLimit, pidIntegralLimit),
+	}
+}
+
+// NewDefaultPidControlLoop creates a pid control loop using the default, non-aggressive, constants
+func NewDefaultPidControlLoop() *PidControlLoop {
+	return NewPidControlLoop(0.03, 0.002, 0.0005)
+}
+
+// WithClock uses the given clock instead of the global one
+func (l *PidControlLoop) WithClock(clock util.Clock) *PidControlLoop {
+	l.pidLoop.WithClock(clock)
+	return l
+}
+
+func (l *PidControlLoop) Cycle(target int, lastSetPwm int) int {
+	change := l.pidLoop.Loop(float64(target), float64(lastSetPwm))
+	// ensure we are within sane bounds
+	coerced := util.Coerce(float64(lastSetPwm)+change, fans.MinPwmValue, fans.MaxPwmValue)
+	return int(math.Round(coerced))
+}
+
+func (l *PidControlLoop) Reset() {
+	l.pidLoop.Reset()
+}
+
+// DirectControlLoop applies the target pwm value immediately
+type DirectControlLoop struct{}
+
+func NewDirectControlLoop() *DirectControlLoop {
+	return &DirectControlLoop{}
+}
+
+func (l *DirectControlLoop) Cycle(target int, lastSetPwm int) int {
+	return int(util.Coerce(float64(target), fans.MinPwmValue, fans.MaxPwmValue))
+}
+
+func (l *DirectControlLoop) Reset() {}
+
+// SlewControlLoop moves the pwm value towards the target with a limited rate of change
+type SlewControlLoop struct {
+	// maximum change of the pwm value per second
+	rate float64
+
+	// clock used to measure the time between two cycles, nil uses the global clock
+	clock util.Clock
+
+	// current pwm value, including the fraction that could not be applied yet
+	value    float64
+	lastTime time.Time
+}
+
+func NewSlewControlLoop(rate float64) *SlewControlLoop {
+	return &SlewControlLoop{
+		rate: rate,
+	}
+}
+
+// WithClock uses the given clock instead of the global one
+func (l *SlewControlLoop) WithClock(clock util.Clock) *SlewControlLoop {
+	l.clock = clock
+	return l
+}
+
+func (l *SlewControlLoop) Cycle(target int, lastSetPwm int) int {
+	now := l.now()
+	if l.lastTime.IsZero() || int(math.Round(l.value)) != lastSetPwm {
+		// (re)synchronize with the actual value of the fan
+		l.value = float64(lastSetPwm)
+	} else if dt := now.Sub(l.lastTime).Seconds(); dt > 0 {
+		maxChange := l.rate * dt
+		l.value += util.Coerce(float64(target)-l.value, -maxChange, maxChange)
+	}
+	l.lastTime = now
+
+	coerced := util.Coerce(l.value, fans.MinPwmValue, fans.MaxPwmValue)
+	return int(math.Round(coerced))
+}
+
+func (l *SlewControlLoop) Reset() {
+	l.value = 0
+	l.lastTime = time.Time{}
+}
+
+func (l *SlewControlLoop) now() time.Time {
+	if l.clock != nil {
+		return l.clock.Now()
+	}
+	return util.Now()
+}
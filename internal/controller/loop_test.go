@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func useManualClock(t *testing.T) *util.ManualClock {
+	clock := util.NewManualClock(time.Now())
+	util.SetClock(clock)
+	t.Cleanup(func() {
+		util.SetClock(nil)
+	})
+	return clock
+}
+
+func TestNewControlLoop(t *testing.T) {
+	// GIVEN
+	tests := []struct {
+		config   *configuration.ControlLoopConfig
+		expected ControlLoop
+	}{
+		{nil, &PidControlLoop{}},
+		{&configuration.ControlLoopConfig{P: 1}, &PidControlLoop{}},
+		{&configuration.ControlLoopConfig{Type: configuration.ControlLoopTypePid, P: 1}, &PidControlLoop{}},
+		{&configuration.ControlLoopConfig{Type: configuration.ControlLoopTypeDirect}, &DirectControlLoop{}},
+		{&configuration.ControlLoopConfig{Type: configuration.ControlLoopTypeSlew, SlewRate: 10}, &SlewControlLoop{}},
+	}
+
+	for _, test := range tests {
+		// WHEN
+		loop, err := NewControlLoop(test.config, nil)
+
+		// THEN
+		assert.NoError(t, err)
+		assert.IsType(t, test.expected, loop)
+	}
+}
+
+func TestNewControlLoop_UnsupportedType(t *testing.T) {
+	// WHEN
+	_, err := NewControlLoop(&configuration.ControlLoopConfig{Type: "bang-bang"}, nil)
+
+	// THEN
+	assert.EqualError(t, err, "unsupported control loop type: bang-bang")
+}
+
+func TestPidControlLoop_Cycle(t *testing.T) {
+	// GIVEN
+	clock := useManualClock(t)
+	loop := NewPidControlLoop(0.5, 0, 0)
+
+	// WHEN
+	first := loop.Cycle(200, 100)
+	clock.Advance(time.Second)
+	second := loop.Cycle(200, first)
+	clock.Advance(time.Second)
+	third := loop.Cycle(200, second)
+
+	// THEN
+	// the first cycle only initializes the loop
+	assert.Equal(t, 100, first)
+	// each cycle moves half of the remaining distance, exactly once
+	assert.Equal(t, 150, second)
+	assert.Equal(t, 175, third)
+}
+
+func TestPidControlLoop_Bounds(t *testing.T) {
+	// GIVEN
+	clock := useManualClock(t)
+	loop := NewPidControlLoop(10, 0, 0)
+
+	// WHEN
+	loop.Cycle(255, 200)
+	clock.Advance(time.Second)
+	result := loop.Cycle(255, 200)
+
+	// THEN
+	assert.Equal(t, 255, result)
+}
+
+func TestPidControlLoop_StepResponse(t *testing.T) {
+	// GIVEN
+	clock := useManualClock(t)
+	tests := []struct {
+		start  int
+		target int
+	}{
+		{0, 100},
+		{0, 255},
+		{200, 30},
+	}
+
+	for _, test := range tests {
+		loop := NewDefaultPidControlLoop()
+		pwm := test.start
+		minPwm, maxPwm := pwm, pwm
+
+		// WHEN
+		for tick := 0; tick < 300; tick++ {
+			pwm = loop.Cycle(test.target, pwm)
+			minPwm = min(minPwm, pwm)
+			maxPwm = max(maxPwm, pwm)
+			clock.Advance(200 * time.Millisecond)
+		}
+
+		// THEN
+		// the target is reached within 60 seconds, without overshooting it
+		assert.Equal(t, test.target, pwm)
+		if test.target > test.start {
+			assert.Equal(t, test.target, maxPwm)
+		} else {
+			assert.Equal(t, test.target, minPwm)
+		}
+	}
+}
+
+func TestDirectControlLoop_Cycle(t *testing.T) {
+	// GIVEN
+	loop := NewDirectControlLoop()
+
+	// WHEN
+	result := loop.Cycle(200, 100)
+
+	// THEN
+	assert.Equal(t, 200, result)
+}
+
+func TestSlewControlLoop_Cycle(t *testing.T) {
+	// GIVEN
+	clock := useManualClock(t)
+	loop := NewSlewControlLoop(10)
+
+	// WHEN
+	first := loop.Cycle(200, 100)
+	clock.Advance(time.Second)
+	second := loop.Cycle(200, first)
+	clock.Advance(250 * time.Millisecond)
+	third := loop.Cycle(200, second)
+	clock.Advance(250 * time.Millisecond)
+	fourth := loop.Cycle(200, third)
+	clock.Advance(time.Second)
+	down := loop.Cycle(0, fourth)
+
+	// THEN
+	assert.Equal(t, 100, first)
+	assert.Equal(t, 110, second)
+	// fractions of a step are accumulated
+	assert.Equal(t, 113, third)
+	assert.Equal(t, 115, fourth)
+	assert.Equal(t, 105, down)
+}
+
+func TestSlewControlLoop_ReachesTarget(t *testing.T) {
+	// GIVEN
+	clock := useManualClock(t)
+	loop := NewSlewControlLoop(100)
+
+	// WHEN
+	pwm := loop.Cycle(120, 100)
+	clock.Advance(time.Second)
+	pwm = loop.Cycle(120, pwm)
+
+	// THEN
+	assert.Equal(t, 120, pwm)
+}
+
+func TestSlewControlLoop_Resynchronize(t *testing.T) {
+	// GIVEN
+	clock := useManualClock(t)
+	loop := NewSlewControlLoop(10)
+	loop.Cycle(200, 100)
+	clock.Advance(time.Second)
+
+	// WHEN
+	// the pwm value was changed by something else
+	result := loop.Cycle(200, 50)
+	clock.Advance(time.Second)
+	next := loop.Cycle(200, result)
+
+	// THEN
+	assert.Equal(t, 50, result)
+	assert.Equal(t, 60, next)
+}
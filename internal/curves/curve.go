@@ -2,6 +2,8 @@ package curves
 
 import (
 	"fmt"
+	"sync"
+
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/util"
 )
@@ -14,9 +16,38 @@ type SpeedCurve interface {
 }
 
 var (
+	// SpeedCurveMap contains all curves by their id, use GetSpeedCurve, SpeedCurves and ReplaceSpeedCurve to access it
 	SpeedCurveMap = map[string]SpeedCurve{}
+	// speedCurveMapMutex guards SpeedCurveMap against curves being replaced while the daemon is running
+	speedCurveMapMutex sync.RWMutex
 )
 
+// GetSpeedCurve returns the curve with the given id
+func GetSpeedCurve(id string) (SpeedCurve, bool) {
+	speedCurveMapMutex.RLock()
+	defer speedCurveMapMutex.RUnlock()
+	curve, exists := SpeedCurveMap[id]
+	return curve, exists
+}
+
+// SpeedCurves returns a copy of SpeedCurveMap
+func SpeedCurves() map[string]SpeedCurve {
+	speedCurveMapMutex.RLock()
+	defer speedCurveMapMutex.RUnlock()
+	result := make(map[string]SpeedCurve, len(SpeedCurveMap))
+	for id, curve := range SpeedCurveMap {
+		result[id] = curve
+	}
+	return result
+}
+
+// ReplaceSpeedCurve adds the given curve, or replaces the curve having the same id
+func ReplaceSpeedCurve(curve SpeedCurve) {
+	speedCurveMapMutex.Lock()
+	defer speedCurveMapMutex.Unlock()
+	SpeedCurveMap[curve.GetId()] = curve
+}
+
 func NewSpeedCurve(config configuration.CurveConfig) (SpeedCurve, error) {
 	if config.Linear != nil {
 		return &LinearSpeedCurve{
@@ -29,7 +60,7 @@ func NewSpeedCurve(config configuration.CurveConfig) (SpeedCurve, error) {
 			config.PID.P,
 			config.PID.I,
 			config.PID.D,
-		)
+		).WithOutputLimits(0, 1).WithDerivativeFilter(config.PID.DerivativeFilter)
 		return &PidSpeedCurve{
 			Config:  config,
 			pidLoop: pidLoop,
@@ -50,7 +50,7 @@ func TestFunctionCurveSum(t *testing.T) {
 	)
 
 	c1, _ := NewSpeedCurve(curve1)
-	SpeedCurveMap[c1.GetId()] = c1
+	ReplaceSpeedCurve(c1)
 
 	curve2 := createLinearCurveConfig(
 		"case_fan_back1",
@@ -61,7 +61,7 @@ func TestFunctionCurveSum(t *testing.T) {
 
 	var c2 SpeedCurve
 	c2, _ = NewSpeedCurve(curve2)
-	SpeedCurveMap[c2.GetId()] = c2
+	ReplaceSpeedCurve(c2)
 
 	function := configuration.FunctionSum
 	functionCurveConfig := createFunctionCurveConfig(
@@ -73,7 +73,7 @@ func TestFunctionCurveSum(t *testing.T) {
 		},
 	)
 	functionCurve, _ := NewSpeedCurve(functionCurveConfig)
-	SpeedCurveMap[functionCurve.GetId()] = functionCurve
+	ReplaceSpeedCurve(functionCurve)
 
 	// WHEN
 	result, err := functionCurve.Evaluate()
@@ -111,7 +111,7 @@ func TestFunctionCurveDifference(t *testing.T) {
 		80,
 	)
 	c1, _ := NewSpeedCurve(curve1)
-	SpeedCurveMap[c1.GetId()] = c1
+	ReplaceSpeedCurve(c1)
 
 	curve2 := createLinearCurveConfig(
 		"case_fan_back1",
@@ -120,7 +120,7 @@ func TestFunctionCurveDifference(t *testing.T) {
 		80,
 	)
 	c2, _ := NewSpeedCurve(curve2)
-	SpeedCurveMap[c2.GetId()] = c2
+	ReplaceSpeedCurve(c2)
 
 	function := configuration.FunctionDifference
 	functionCurveConfig := createFunctionCurveConfig(
@@ -132,7 +132,7 @@ func TestFunctionCurveDifference(t *testing.T) {
 		},
 	)
 	functionCurve, _ := NewSpeedCurve(functionCurveConfig)
-	SpeedCurveMap[functionCurve.GetId()] = functionCurve
+	ReplaceSpeedCurve(functionCurve)
 
 	// WHEN
 	result, err := functionCurve.Evaluate()
@@ -170,7 +170,7 @@ func TestFunctionCurveAverage(t *testing.T) {
 		80,
 	)
 	c1, _ := NewSpeedCurve(curve1)
-	SpeedCurveMap[c1.GetId()] = c1
+	ReplaceSpeedCurve(c1)
 
 	curve2 := createLinearCurveConfig(
 		"case_fan_back1",
@@ -179,7 +179,7 @@ func TestFunctionCurveAverage(t *testing.T) {
 		80,
 	)
 	c2, _ := NewSpeedCurve(curve2)
-	SpeedCurveMap[c2.GetId()] = c2
+	ReplaceSpeedCurve(c2)
 
 	function := configuration.FunctionAverage
 	functionCurveConfig := createFunctionCurveConfig(
@@ -191,7 +191,7 @@ func TestFunctionCurveAverage(t *testing.T) {
 		},
 	)
 	functionCurve, _ := NewSpeedCurve(functionCurveConfig)
-	SpeedCurveMap[functionCurve.GetId()] = functionCurve
+	ReplaceSpeedCurve(functionCurve)
 
 	// WHEN
 	result, err := functionCurve.Evaluate()
@@ -229,7 +229,7 @@ func TestFunctionCurveDelta(t *testing.T) {
 		60,
 	)
 	c1, _ := NewSpeedCurve(curve1)
-	SpeedCurveMap[c1.GetId()] = c1
+	ReplaceSpeedCurve(c1)
 
 	curve2 := createLinearCurveConfig(
 		"case_fan_back2",
@@ -238,7 +238,7 @@ func TestFunctionCurveDelta(t *testing.T) {
 		60,
 	)
 	c2, _ := NewSpeedCurve(curve2)
-	SpeedCurveMap[c2.GetId()] = c2
+	ReplaceSpeedCurve(c2)
 
 	function := configuration.FunctionDelta
 	functionCurveConfig := createFunctionCurveConfig(
@@ -250,7 +250,7 @@ func TestFunctionCurveDelta(t *testing.T) {
 		},
 	)
 	functionCurve, _ := NewSpeedCurve(functionCurveConfig)
-	SpeedCurveMap[functionCurve.GetId()] = functionCurve
+	ReplaceSpeedCurve(functionCurve)
 
 	// WHEN
 	result, err := functionCurve.Evaluate()
@@ -288,7 +288,7 @@ func TestFunctionCurveMinimum(t *testing.T) {
 		80,
 	)
 	c1, _ := NewSpeedCurve(curve1)
-	SpeedCurveMap[c1.GetId()] = c1
+	ReplaceSpeedCurve(c1)
 
 	curve2 := createLinearCurveConfig(
 		"case_fan_back3",
@@ -297,7 +297,7 @@ func TestFunctionCurveMinimum(t *testing.T) {
 		80,
 	)
 	c2, _ := NewSpeedCurve(curve2)
-	SpeedCurveMap[c2.GetId()] = c2
+	ReplaceSpeedCurve(c2)
 
 	function := configuration.FunctionMinimum
 	functionCurveConfig := createFunctionCurveConfig(
@@ -346,7 +346,7 @@ func TestFunctionCurveMaximum(t *testing.T) {
 		80,
 	)
 	c1, _ := NewSpeedCurve(curve1)
-	SpeedCurveMap[c1.GetId()] = c1
+	ReplaceSpeedCurve(c1)
 
 	curve2 := createLinearCurveConfig(
 		"case_fan_back4",
@@ -355,7 +355,7 @@ func TestFunctionCurveMaximum(t *testing.T) {
 		80,
 	)
 	c2, _ := NewSpeedCurve(curve2)
-	SpeedCurveMap[c2.GetId()] = c2
+	ReplaceSpeedCurve(c2)
 
 	function := configuration.FunctionMaximum
 	functionCurveConfig := createFunctionCurveConfig(
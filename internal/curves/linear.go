@@ -1,6 +1,7 @@
 package curves
 
 import (
+	"fmt"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/util"
@@ -17,6 +18,10 @@ func (c *LinearSpeedCurve) GetId() string {
 }
 
 func (c *LinearSpeedCurve) Evaluate() (value int, err error) {
+	if sensors.IsAbsent(c.Config.Linear.Sensor) {
+		// the moving average is outdated
+		return c.Value, fmt.Errorf("curve %s: %w", c.Config.ID, sensors.ErrSensorAbsent)
+	}
 	sensor := sensors.SensorMap[c.Config.Linear.Sensor]
 	var avgTemp = sensor.GetMovingAvg()
 
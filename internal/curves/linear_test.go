@@ -99,3 +99,28 @@ func TestLinearCurveWithSteps(t *testing.T) {
 	// THEN
 	assert.Equal(t, 100, result)
 }
+
+func TestLinearCurveWithAbsentSensor(t *testing.T) {
+	// GIVEN
+	s := MockSensor{
+		Name:      "sensor",
+		MovingAvg: 60000.0,
+	}
+	sensors.SensorMap[s.GetId()] = &s
+	sensors.SetAbsent(s.GetId(), true)
+	defer sensors.SetAbsent(s.GetId(), false)
+
+	curveConfig := createLinearCurveConfig(
+		"curve",
+		s.GetId(),
+		40,
+		80,
+	)
+	curve, _ := NewSpeedCurve(curveConfig)
+
+	// WHEN
+	_, err := curve.Evaluate()
+
+	// THEN
+	assert.ErrorIs(t, err, sensors.ErrSensorAbsent)
+}
@@ -13,6 +13,12 @@ type PidSpeedCurve struct {
 	pidLoop *util.PidLoop
 }
 
+// WithClock uses the given clock for the pid loop instead of the global one
+func (c *PidSpeedCurve) WithClock(clock util.Clock) *PidSpeedCurve {
+	c.pidLoop.WithClock(clock)
+	return c
+}
+
 func (c *PidSpeedCurve) GetId() string {
 	return c.Config.ID
 }
@@ -26,15 +32,9 @@ func (c *PidSpeedCurve) Evaluate() (value int, err error) {
 	}
 	pidTarget := c.Config.PID.SetPoint
 
+	// the loop output is limited to (0..1)
 	loopValue := c.pidLoop.Loop(pidTarget, measured/1000.0)
 
-	// clamp to (0..1)
-	if loopValue > 1 {
-		loopValue = 1
-	} else if loopValue < 0 {
-		loopValue = 0
-	}
-
 	// map to expected output range
 	curveValue := int(loopValue * 255)
 
@@ -3,6 +3,7 @@ package curves
 import (
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/util"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -30,10 +31,21 @@ func createPidCurveConfig(
 	return curve
 }
 
+// useManualClock replaces the clock used by the pid loops, so the tests do not depend on the timing of the scheduler
+func useManualClock(t *testing.T) *util.ManualClock {
+	clock := util.NewManualClock(time.Now())
+	util.SetClock(clock)
+	t.Cleanup(func() {
+		util.SetClock(nil)
+	})
+	return clock
+}
+
 // proportional
 
 func TestPidCurveProportionalBelowTarget(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 50000.0
 
 	s := MockSensor{
@@ -62,12 +74,13 @@ func TestPidCurveProportionalBelowTarget(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
 func TestPidCurveProportionalAboveTarget(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 70000.0
 
 	s := MockSensor{
@@ -96,12 +109,13 @@ func TestPidCurveProportionalAboveTarget(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
 func TestPidCurveProportionalWayAboveTarget(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 80000.0
 
 	s := MockSensor{
@@ -130,7 +144,7 @@ func TestPidCurveProportionalWayAboveTarget(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
@@ -138,6 +152,7 @@ func TestPidCurveProportionalWayAboveTarget(t *testing.T) {
 
 func TestPidCurveIntegralBelowTarget(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 50000.0
 
 	s := MockSensor{
@@ -166,12 +181,13 @@ func TestPidCurveIntegralBelowTarget(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
 func TestPidCurveIntegralAboveTarget(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 70000.0
 
 	s := MockSensor{
@@ -200,12 +216,13 @@ func TestPidCurveIntegralAboveTarget(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
 func TestPidCurveIntegralWayAboveTarget(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 80000.0
 
 	s := MockSensor{
@@ -234,7 +251,7 @@ func TestPidCurveIntegralWayAboveTarget(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
@@ -242,6 +259,7 @@ func TestPidCurveIntegralWayAboveTarget(t *testing.T) {
 
 func TestPidCurveDerivativeNoDiff(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 60000.0
 
 	s := MockSensor{
@@ -270,12 +288,13 @@ func TestPidCurveDerivativeNoDiff(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
 func TestPidCurveDerivativePositiveStaticDiff(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 60000.0
 
 	s := MockSensor{
@@ -307,12 +326,13 @@ func TestPidCurveDerivativePositiveStaticDiff(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
 func TestPidCurveDerivativeIncreasingDiff(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 60000.0
 
 	s := MockSensor{
@@ -344,7 +364,7 @@ func TestPidCurveDerivativeIncreasingDiff(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
@@ -352,6 +372,7 @@ func TestPidCurveDerivativeIncreasingDiff(t *testing.T) {
 
 func TestPidCurveOnTarget(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 60000.0
 
 	s := MockSensor{
@@ -376,7 +397,7 @@ func TestPidCurveOnTarget(t *testing.T) {
 		assert.Fail(t, err.Error())
 	}
 
-	time.Sleep(1 * time.Second)
+	clock.Advance(1 * time.Second)
 
 	var result int
 	result, err = curve.Evaluate()
@@ -390,6 +411,7 @@ func TestPidCurveOnTarget(t *testing.T) {
 
 func TestPidCurveAboveTarget(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 70000.0
 
 	s := MockSensor{
@@ -418,12 +440,13 @@ func TestPidCurveAboveTarget(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
 
 func TestPidCurveWayAboveTarget(t *testing.T) {
 	// GIVEN
+	clock := useManualClock(t)
 	avgTmp := 80000.0
 
 	s := MockSensor{
@@ -452,6 +475,6 @@ func TestPidCurveWayAboveTarget(t *testing.T) {
 		// THEN
 		assert.Equal(t, expected, result, "loop: %d", loopIdx)
 
-		time.Sleep(200 * time.Millisecond)
+		clock.Advance(200 * time.Millisecond)
 	}
 }
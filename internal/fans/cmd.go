@@ -5,6 +5,7 @@ import (
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/markusressel/fan2go/internal/util"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -13,6 +14,11 @@ import (
 type CmdFan struct {
 	Config    configuration.FanConfig `json:"config"`
 	MovingAvg float64                 `json:"movingAvg"`
+	MinPwm    *int                    `json:"minPwm"`
+	StartPwm  *int                    `json:"startPwm"`
+	MaxPwm    *int                    `json:"maxPwm"`
+
+	FanCurveData *map[int]float64 `json:"fanCurveData"`
 
 	Rpm int `json:"rpm"`
 	Pwm int `json:"pwm"`
@@ -22,27 +28,47 @@ func (fan *CmdFan) GetId() string {
 	return fan.Config.ID
 }
 
+func (fan *CmdFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
 func (fan *CmdFan) GetStartPwm() int {
+	if fan.StartPwm != nil {
+		return *fan.StartPwm
+	}
 	return 1
 }
 
 func (fan *CmdFan) SetStartPwm(pwm int, force bool) {
+	if fan.Config.StartPwm == nil || force {
+		fan.StartPwm = &pwm
+	}
 }
 
 func (fan *CmdFan) GetMinPwm() int {
+	if fan.MinPwm != nil {
+		return *fan.MinPwm
+	}
 	return MinPwmValue
 }
 
 func (fan *CmdFan) SetMinPwm(pwm int, force bool) {
-	// not supported
+	if fan.Config.MinPwm == nil || force {
+		fan.MinPwm = &pwm
+	}
 }
 
 func (fan *CmdFan) GetMaxPwm() int {
+	if fan.MaxPwm != nil {
+		return *fan.MaxPwm
+	}
 	return MaxPwmValue
 }
 
 func (fan *CmdFan) SetMaxPwm(pwm int, force bool) {
-	// not supported
+	if fan.Config.MaxPwm == nil || force {
+		fan.MaxPwm = &pwm
+	}
 }
 
 func (fan *CmdFan) GetRpm() (int, error) {
@@ -50,6 +76,15 @@ func (fan *CmdFan) GetRpm() (int, error) {
 		return 0, nil
 	}
 
+	if conf := fan.Config.Cmd.Coprocess; conf != nil {
+		rpm, err := fan.request("getRpm", nil)
+		if err != nil {
+			return 0, err
+		}
+		fan.Rpm = int(rpm)
+		return fan.Rpm, nil
+	}
+
 	conf := fan.Config.Cmd.GetRpm
 
 	timeout := 2 * time.Second
@@ -78,6 +113,15 @@ func (fan *CmdFan) SetRpmAvg(rpm float64) {
 }
 
 func (fan *CmdFan) GetPwm() (result int, err error) {
+	if fan.Config.Cmd.Coprocess != nil {
+		value, err := fan.request("getPwm", nil)
+		if err != nil {
+			return 0, err
+		}
+		fan.Pwm = rawValueToPwm(value, fan.Config.Cmd.Range, fan.Config.Cmd.Invert)
+		return fan.Pwm, nil
+	}
+
 	conf := fan.Config.Cmd.GetPwm
 
 	timeout := 2 * time.Second
@@ -92,17 +136,25 @@ func (fan *CmdFan) GetPwm() (result int, err error) {
 		return 0, err
 	}
 
-	fan.Pwm = int(pwm)
+	fan.Pwm = rawValueToPwm(pwm, fan.Config.Cmd.Range, fan.Config.Cmd.Invert)
 
-	return int(pwm), nil
+	return fan.Pwm, nil
 }
 
 func (fan *CmdFan) SetPwm(pwm int) (err error) {
+	value := pwmToRawValue(pwm, fan.Config.Cmd.Range, fan.Config.Cmd.Invert)
+
+	if fan.Config.Cmd.Coprocess != nil {
+		rawValue := float64(value)
+		_, err = fan.request("setPwm", &rawValue)
+		return err
+	}
+
 	conf := fan.Config.Cmd.SetPwm
 
 	var args = []string{}
 	for _, arg := range conf.Args {
-		replaced := strings.ReplaceAll(arg, "%pwm%", strconv.Itoa(pwm))
+		replaced := strings.ReplaceAll(arg, "%pwm%", strconv.Itoa(value))
 		args = append(args, replaced)
 	}
 
@@ -116,12 +168,18 @@ func (fan *CmdFan) SetPwm(pwm int) (err error) {
 }
 
 func (fan *CmdFan) GetFanCurveData() *map[int]float64 {
-	return &interpolated
+	return fan.FanCurveData
 }
 
+// AttachFanCurveData attaches fan curve data from persistence to a fan
 func (fan *CmdFan) AttachFanCurveData(curveData *map[int]float64) (err error) {
-	// not supported
-	return
+	if curveData == nil || len(*curveData) <= 0 {
+		ui.Error("Cant attach empty fan curve data to fan %s", fan.GetId())
+		return os.ErrInvalid
+	}
+
+	fan.FanCurveData = curveData
+	return nil
 }
 
 func (fan *CmdFan) GetCurveId() string {
@@ -150,7 +208,21 @@ func (fan *CmdFan) Supports(feature FeatureFlag) bool {
 	case FeatureControlMode:
 		return false
 	case FeatureRpmSensor:
+		if fan.Config.Cmd.Coprocess != nil {
+			return fan.Config.Cmd.Coprocess.Rpm
+		}
 		return fan.Config.Cmd.GetRpm != nil
 	}
 	return false
 }
+
+// request sends a request for this fan to its coprocess
+func (fan *CmdFan) request(method string, value *float64) (float64, error) {
+	conf := fan.Config.Cmd.Coprocess
+	coprocess := util.GetCoprocess(conf.Exec, conf.Args)
+	result, err := coprocess.Request(method, fan.GetId(), value, conf.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("fan %s: %v", fan.GetId(), err)
+	}
+	return result, nil
+}
@@ -2,8 +2,11 @@ package fans
 
 import (
 	"fmt"
-	"github.com/markusressel/fan2go/internal/configuration"
 	"sort"
+	"sync"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
 )
 
 const (
@@ -31,11 +34,36 @@ const (
 
 var (
 	FanMap = map[string]Fan{}
+
+	// absentFans contains the ids of all fans whose device has disappeared at runtime
+	absentFans      = map[string]bool{}
+	absentFansMutex sync.RWMutex
 )
 
+// SetAbsent marks the device of the fan with the given id as absent or present
+func SetAbsent(id string, absent bool) {
+	absentFansMutex.Lock()
+	defer absentFansMutex.Unlock()
+	if absent {
+		absentFans[id] = true
+	} else {
+		delete(absentFans, id)
+	}
+}
+
+// IsAbsent returns true if the device of the fan with the given id has disappeared at runtime
+func IsAbsent(id string) bool {
+	absentFansMutex.RLock()
+	defer absentFansMutex.RUnlock()
+	return absentFans[id]
+}
+
 type Fan interface {
 	GetId() string
 
+	// GetConfig returns the configuration of this fan
+	GetConfig() configuration.FanConfig
+
 	// GetMinPwm returns the lowest PWM value where the fans are still spinning, when spinning previously
 	GetMinPwm() int
 	SetMinPwm(pwm int, force bool)
@@ -76,6 +104,19 @@ type Fan interface {
 	Supports(feature FeatureFlag) bool
 }
 
+// PwmMapProvider is implemented by fans whose pwm map is known upfront, so it doesn't have to be measured
+type PwmMapProvider interface {
+	// GetPwmMap returns a map of x -> getPwm() where x is setPwm(x)
+	GetPwmMap() (map[int]int, error)
+}
+
+// Restorer is implemented by fans that know best how to restore the state they had
+// before fan2go started to control them, instead of restoring their original pwm value and control mode
+type Restorer interface {
+	// Restore hands the fan back after fan2go stopped controlling it
+	Restore() error
+}
+
 func NewFan(config configuration.FanConfig) (Fan, error) {
 	if config.HwMon != nil {
 		return &HwMonFan{
@@ -90,19 +131,52 @@ func NewFan(config configuration.FanConfig) (Fan, error) {
 
 	if config.File != nil {
 		return &FileFan{
-			Config: config,
+			MinPwm:       config.MinPwm,
+			StartPwm:     config.StartPwm,
+			MaxPwm:       config.MaxPwm,
+			Config:       config,
+			FanCurveData: newFanCurveData(),
 		}, nil
 	}
 
 	if config.Cmd != nil {
 		return &CmdFan{
-			Config: config,
+			MinPwm:       config.MinPwm,
+			StartPwm:     config.StartPwm,
+			MaxPwm:       config.MaxPwm,
+			Config:       config,
+			FanCurveData: newFanCurveData(),
+		}, nil
+	}
+
+	if config.CoolingDevice != nil {
+		return &CoolingDeviceFan{
+			Config:       config,
+			FanCurveData: newFanCurveData(),
 		}, nil
 	}
 
+	if config.Discrete != nil {
+		return newDiscreteFan(config)
+	}
+
+	if config.Plugin != nil {
+		fan, err := newPluginFan(config)
+		if err != nil {
+			return nil, err
+		}
+		return fan, nil
+	}
+
 	return nil, fmt.Errorf("no matching fan type for fan: %s", config.ID)
 }
 
+// newFanCurveData returns a linear fan curve owned by a single fan, for fans without an rpm sensor of their own
+func newFanCurveData() *map[int]float64 {
+	data := util.InterpolateLinearly(&map[int]float64{0: 0, 255: 255}, 0, 255)
+	return &data
+}
+
 // ComputePwmBoundaries calculates the startPwm and maxPwm values for a fan based on its fan curve data
 func ComputePwmBoundaries(fan Fan) (startPwm int, maxPwm int) {
 	userStartPwm := fan.GetStartPwm()
@@ -0,0 +1,217 @@
+package fans
+
+import (
+	"fmt"
+	"math"
+	"path"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+const (
+	coolingDeviceCurStateFile = "cur_state"
+	coolingDeviceMaxStateFile = "max_state"
+)
+
+// CoolingDeviceFan is a fan exposed as thermal cooling device in /sys/class/thermal, which is selected by its type.
+// The pwm range of fan2go (0..255) is mapped onto the discrete states (0..max_state) of the device.
+type CoolingDeviceFan struct {
+	Config    configuration.FanConfig `json:"config"`
+	MovingAvg float64                 `json:"movingAvg"`
+
+	Pwm          int              `json:"pwm"`
+	FanCurveData *map[int]float64 `json:"fanCurveData"`
+
+	// path of the resolved cooling device directory
+	devicePath string
+	// highest state supported by the device
+	maxState int
+	// state of the device before the first pwm value was set, nil if it was never changed
+	originalState *int
+}
+
+func (fan *CoolingDeviceFan) GetId() string {
+	return fan.Config.ID
+}
+
+func (fan *CoolingDeviceFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
+func (fan *CoolingDeviceFan) GetStartPwm() int {
+	return 1
+}
+
+func (fan *CoolingDeviceFan) SetStartPwm(pwm int, force bool) {
+	// not supported
+}
+
+func (fan *CoolingDeviceFan) GetMinPwm() int {
+	return MinPwmValue
+}
+
+func (fan *CoolingDeviceFan) SetMinPwm(pwm int, force bool) {
+	// not supported
+}
+
+func (fan *CoolingDeviceFan) GetMaxPwm() int {
+	return MaxPwmValue
+}
+
+func (fan *CoolingDeviceFan) SetMaxPwm(pwm int, force bool) {
+	// not supported
+}
+
+func (fan *CoolingDeviceFan) GetRpm() (int, error) {
+	return 0, fmt.Errorf("fan %s: cooling devices have no rpm sensor", fan.GetId())
+}
+
+func (fan *CoolingDeviceFan) GetRpmAvg() float64 {
+	return 0
+}
+
+func (fan *CoolingDeviceFan) SetRpmAvg(rpm float64) {
+	// not supported
+}
+
+func (fan *CoolingDeviceFan) GetPwm() (int, error) {
+	err := fan.resolveDevice()
+	if err != nil {
+		return MinPwmValue, err
+	}
+
+	state, err := util.ReadIntFromFile(path.Join(fan.devicePath, coolingDeviceCurStateFile))
+	if err != nil {
+		fan.devicePath = ""
+		return MinPwmValue, fmt.Errorf("fan %s: unable to read current state: %v", fan.GetId(), err)
+	}
+
+	fan.Pwm = stateToPwm(state, fan.maxState)
+	return fan.Pwm, nil
+}
+
+func (fan *CoolingDeviceFan) SetPwm(pwm int) error {
+	err := fan.resolveDevice()
+	if err != nil {
+		return err
+	}
+
+	if fan.originalState == nil {
+		if state, err := util.ReadIntFromFile(path.Join(fan.devicePath, coolingDeviceCurStateFile)); err == nil {
+			fan.originalState = &state
+		}
+	}
+
+	state := pwmToState(pwm, fan.maxState)
+	err = util.WriteIntToFile(state, path.Join(fan.devicePath, coolingDeviceCurStateFile))
+	if err != nil {
+		fan.devicePath = ""
+		return fmt.Errorf("fan %s: unable to set state %d: %v", fan.GetId(), state, err)
+	}
+	fan.Pwm = stateToPwm(state, fan.maxState)
+	return nil
+}
+
+// GetPwmMap returns a pwm map containing the pwm value of every discrete state of the device
+func (fan *CoolingDeviceFan) GetPwmMap() (map[int]int, error) {
+	err := fan.resolveDevice()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[int]int{}
+	for state := 0; state <= fan.maxState; state++ {
+		pwm := stateToPwm(state, fan.maxState)
+		result[pwm] = pwm
+	}
+	return result, nil
+}
+
+// Restore writes back the exact state the device had before the first pwm value was set,
+// since cooling devices have no automatic mode to hand control back to
+func (fan *CoolingDeviceFan) Restore() error {
+	if fan.originalState == nil {
+		return nil
+	}
+	err := fan.resolveDevice()
+	if err != nil {
+		return err
+	}
+
+	err = util.WriteIntToFile(*fan.originalState, path.Join(fan.devicePath, coolingDeviceCurStateFile))
+	if err != nil {
+		fan.devicePath = ""
+		return fmt.Errorf("fan %s: unable to restore state %d: %v", fan.GetId(), *fan.originalState, err)
+	}
+	fan.Pwm = stateToPwm(*fan.originalState, fan.maxState)
+	return nil
+}
+
+func (fan *CoolingDeviceFan) GetFanCurveData() *map[int]float64 {
+	return fan.FanCurveData
+}
+
+func (fan *CoolingDeviceFan) AttachFanCurveData(curveData *map[int]float64) (err error) {
+	// not supported
+	return
+}
+
+func (fan *CoolingDeviceFan) GetCurveId() string {
+	return fan.Config.Curve
+}
+
+func (fan *CoolingDeviceFan) ShouldNeverStop() bool {
+	return fan.Config.NeverStop
+}
+
+func (fan *CoolingDeviceFan) GetPwmEnabled() (int, error) {
+	return 1, nil
+}
+
+func (fan *CoolingDeviceFan) SetPwmEnabled(value ControlMode) (err error) {
+	// nothing to do
+	return nil
+}
+
+func (fan *CoolingDeviceFan) IsPwmAuto() (bool, error) {
+	return true, nil
+}
+
+func (fan *CoolingDeviceFan) Supports(feature FeatureFlag) bool {
+	return false
+}
+
+// resolveDevice looks up the cooling device directory and its max_state, if not done already
+func (fan *CoolingDeviceFan) resolveDevice() error {
+	if len(fan.devicePath) > 0 {
+		return nil
+	}
+
+	devicePath, err := util.FindThermalDevice(util.ThermalClassPath(configuration.CurrentConfig.SysfsRoot), util.CoolingDevicePrefix, fan.Config.CoolingDevice.Type)
+	if err != nil {
+		return fmt.Errorf("fan %s: %v", fan.GetId(), err)
+	}
+
+	maxState, err := util.ReadIntFromFile(path.Join(devicePath, coolingDeviceMaxStateFile))
+	if err != nil {
+		return fmt.Errorf("fan %s: unable to read max state: %v", fan.GetId(), err)
+	}
+	if maxState <= 0 {
+		return fmt.Errorf("fan %s: invalid max state: %d", fan.GetId(), maxState)
+	}
+
+	fan.devicePath = devicePath
+	fan.maxState = maxState
+	return nil
+}
+
+func pwmToState(pwm int, maxState int) int {
+	value := util.Coerce(float64(pwm), MinPwmValue, MaxPwmValue)
+	return int(math.Round(value * float64(maxState) / MaxPwmValue))
+}
+
+func stateToPwm(state int, maxState int) int {
+	value := util.Coerce(float64(state), 0, float64(maxState))
+	return int(math.Round(value * MaxPwmValue / float64(maxState)))
+}
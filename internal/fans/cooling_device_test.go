@@ -0,0 +1,150 @@
+package fans
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function to create a fake cooling device in a fake /sys/class/thermal tree
+func createCoolingDevice(t *testing.T, deviceType string, curState int, maxState int) string {
+	original := configuration.CurrentConfig.SysfsRoot
+	configuration.CurrentConfig.SysfsRoot = t.TempDir()
+	t.Cleanup(func() {
+		configuration.CurrentConfig.SysfsRoot = original
+	})
+
+	devicePath := path.Join(util.ThermalClassPath(configuration.CurrentConfig.SysfsRoot), "cooling_device3")
+	err := os.MkdirAll(devicePath, 0755)
+	assert.NoError(t, err)
+
+	files := map[string]string{
+		"type":                    deviceType + "\n",
+		coolingDeviceCurStateFile: strconv.Itoa(curState) + "\n",
+		coolingDeviceMaxStateFile: strconv.Itoa(maxState) + "\n",
+	}
+	for file, content := range files {
+		err = os.WriteFile(path.Join(devicePath, file), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+	return devicePath
+}
+
+func createCoolingDeviceFan(deviceType string) *CoolingDeviceFan {
+	return &CoolingDeviceFan{
+		Config: configuration.FanConfig{
+			ID: "fan",
+			CoolingDevice: &configuration.CoolingDeviceFanConfig{
+				Type: deviceType,
+			},
+		},
+	}
+}
+
+func readCurState(t *testing.T, devicePath string) string {
+	data, err := os.ReadFile(path.Join(devicePath, coolingDeviceCurStateFile))
+	assert.NoError(t, err)
+	return strings.TrimSpace(string(data))
+}
+
+func TestCoolingDeviceFan_GetPwm(t *testing.T) {
+	// GIVEN
+	createCoolingDevice(t, "pwm-fan", 2, 3)
+	fan := createCoolingDeviceFan("pwm-fan")
+
+	// WHEN
+	pwm, err := fan.GetPwm()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 170, pwm)
+}
+
+func TestCoolingDeviceFan_SetPwm(t *testing.T) {
+	// GIVEN
+	devicePath := createCoolingDevice(t, "pwm-fan", 0, 3)
+	fan := createCoolingDeviceFan("pwm-fan")
+
+	// WHEN
+	err := fan.SetPwm(100)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "1", readCurState(t, devicePath))
+
+	// WHEN
+	err = fan.SetPwm(255)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "3", readCurState(t, devicePath))
+}
+
+func TestCoolingDeviceFan_GetPwmMap(t *testing.T) {
+	// GIVEN
+	createCoolingDevice(t, "Fan", 0, 3)
+	fan := createCoolingDeviceFan("Fan")
+
+	// WHEN
+	pwmMap, err := fan.GetPwmMap()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]int{0: 0, 85: 85, 170: 170, 255: 255}, pwmMap)
+}
+
+func TestCoolingDeviceFan_Restore(t *testing.T) {
+	// GIVEN
+	devicePath := createCoolingDevice(t, "pwm-fan", 2, 3)
+	fan := createCoolingDeviceFan("pwm-fan")
+	err := fan.SetPwm(255)
+	assert.NoError(t, err)
+	err = fan.SetPwm(0)
+	assert.NoError(t, err)
+
+	// WHEN
+	err = fan.Restore()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "2", readCurState(t, devicePath))
+}
+
+func TestCoolingDeviceFan_UnknownType(t *testing.T) {
+	// GIVEN
+	createCoolingDevice(t, "Fan", 0, 3)
+	fan := createCoolingDeviceFan("Processor")
+
+	// WHEN
+	_, err := fan.GetPwm()
+
+	// THEN
+	assert.Error(t, err)
+}
+
+func TestCoolingDeviceFan_OwnFanCurveData(t *testing.T) {
+	// GIVEN
+	config := configuration.FanConfig{
+		ID: "fan",
+		CoolingDevice: &configuration.CoolingDeviceFanConfig{
+			Type: "Processor",
+		},
+	}
+	fan1, err := NewFan(config)
+	assert.NoError(t, err)
+	fan2, err := NewFan(config)
+	assert.NoError(t, err)
+
+	// WHEN
+	(*fan1.GetFanCurveData())[128] = 42
+
+	// THEN
+	assert.NotSame(t, fan1.GetFanCurveData(), fan2.GetFanCurveData())
+	assert.Equal(t, 128.0, (*fan2.GetFanCurveData())[128])
+}
@@ -0,0 +1,326 @@
+package fans
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+const (
+	DefaultDiscreteWriteTemplate = "level %level%"
+	DefaultDiscreteReadPattern   = `(?m)^level:\s*(\S+)`
+	DefaultDiscreteAutoLevel     = "auto"
+
+	discreteLevelPlaceholder = "%level%"
+)
+
+var defaultDiscreteReadRegex = regexp.MustCompile(DefaultDiscreteReadPattern)
+
+// DiscreteFan is a fan which only supports a fixed list of levels, that are applied by writing a text command
+// to a file, f.ex. ThinkPad fans controlled via /proc/acpi/ibm/fan.
+// The pwm range of fan2go (0..255) is spread evenly across all levels.
+type DiscreteFan struct {
+	Config    configuration.FanConfig `json:"config"`
+	MovingAvg float64                 `json:"movingAvg"`
+
+	FanCurveData *map[int]float64 `json:"fanCurveData"`
+
+	Pwm int `json:"pwm"`
+	Rpm int `json:"rpm"`
+
+	// index of the level last set by fan2go
+	levelIndex int
+	// whether levelIndex reflects the level of the fan
+	levelKnown bool
+
+	// compiled ReadPattern of the config
+	readRegex *regexp.Regexp
+	// compiled RpmPattern of the config, nil if the fan has no rpm sensor
+	rpmRegex *regexp.Regexp
+}
+
+func (fan *DiscreteFan) GetId() string {
+	return fan.Config.ID
+}
+
+func (fan *DiscreteFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
+func (fan *DiscreteFan) GetStartPwm() int {
+	return 1
+}
+
+func (fan *DiscreteFan) SetStartPwm(pwm int, force bool) {
+	// not supported
+}
+
+func (fan *DiscreteFan) GetMinPwm() int {
+	return MinPwmValue
+}
+
+func (fan *DiscreteFan) SetMinPwm(pwm int, force bool) {
+	// not supported
+}
+
+func (fan *DiscreteFan) GetMaxPwm() int {
+	return MaxPwmValue
+}
+
+func (fan *DiscreteFan) SetMaxPwm(pwm int, force bool) {
+	// not supported
+}
+
+func (fan *DiscreteFan) GetRpm() (int, error) {
+	if !fan.Supports(FeatureRpmSensor) {
+		return 0, nil
+	}
+
+	value, err := fan.readMatch(fan.rpmRegex)
+	if err != nil {
+		return 0, err
+	}
+	rpm, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("fan %s: unable to parse rpm '%s': %v", fan.GetId(), value, err)
+	}
+
+	fan.Rpm = rpm
+	return rpm, nil
+}
+
+func (fan *DiscreteFan) GetRpmAvg() float64 {
+	return float64(fan.Rpm)
+}
+
+func (fan *DiscreteFan) SetRpmAvg(rpm float64) {
+	fan.Rpm = int(rpm)
+}
+
+// GetPwm returns the last pwm value set by fan2go, if the fan is still at the corresponding level,
+// otherwise the pwm value of the current level.
+func (fan *DiscreteFan) GetPwm() (int, error) {
+	level, err := fan.readLevel()
+	if err != nil {
+		return MinPwmValue, err
+	}
+
+	if level == fan.getAutoLevel() {
+		return fan.Pwm, nil
+	}
+
+	index := fan.indexOfLevel(level)
+	if index < 0 {
+		return MinPwmValue, fmt.Errorf("fan %s: unknown level '%s'", fan.GetId(), level)
+	}
+
+	if fan.levelKnown && index == fan.levelIndex {
+		return fan.Pwm, nil
+	}
+	return fan.levelToPwm(index), nil
+}
+
+func (fan *DiscreteFan) SetPwm(pwm int) error {
+	index := fan.pwmToLevel(pwm)
+
+	if !fan.levelKnown || index != fan.levelIndex {
+		err := fan.writeLevel(fan.Config.Discrete.Levels[index])
+		if err != nil {
+			return err
+		}
+	}
+
+	fan.levelIndex = index
+	fan.levelKnown = true
+	fan.Pwm = pwm
+	return nil
+}
+
+// GetPwmMap returns an identity pwm map, since the fan remembers the last pwm value set to it
+func (fan *DiscreteFan) GetPwmMap() (map[int]int, error) {
+	result := map[int]int{}
+	for pwm := MinPwmValue; pwm <= MaxPwmValue; pwm++ {
+		result[pwm] = pwm
+	}
+	return result, nil
+}
+
+func (fan *DiscreteFan) GetFanCurveData() *map[int]float64 {
+	return fan.FanCurveData
+}
+
+// AttachFanCurveData attaches fan curve data from persistence to a fan
+func (fan *DiscreteFan) AttachFanCurveData(curveData *map[int]float64) (err error) {
+	if curveData == nil || len(*curveData) <= 0 {
+		ui.Error("Cant attach empty fan curve data to fan %s", fan.GetId())
+		return os.ErrInvalid
+	}
+
+	fan.FanCurveData = curveData
+	return nil
+}
+
+func (fan *DiscreteFan) GetCurveId() string {
+	return fan.Config.Curve
+}
+
+func (fan *DiscreteFan) ShouldNeverStop() bool {
+	return fan.Config.NeverStop
+}
+
+func (fan *DiscreteFan) GetPwmEnabled() (int, error) {
+	level, err := fan.readLevel()
+	if err != nil {
+		return int(ControlModePWM), err
+	}
+	if level == fan.getAutoLevel() {
+		return int(ControlModeAutomatic), nil
+	}
+	return int(ControlModePWM), nil
+}
+
+func (fan *DiscreteFan) SetPwmEnabled(value ControlMode) (err error) {
+	switch value {
+	case ControlModeAutomatic:
+		fan.levelKnown = false
+		return fan.writeLevel(fan.getAutoLevel())
+	case ControlModeDisabled:
+		return fan.SetPwm(MaxPwmValue)
+	default:
+		// levels are applied by SetPwm
+		return nil
+	}
+}
+
+// Restore hands control of the fan back to the firmware, regardless of the level it had before
+func (fan *DiscreteFan) Restore() error {
+	return fan.SetPwmEnabled(ControlModeAutomatic)
+}
+
+func (fan *DiscreteFan) IsPwmAuto() (bool, error) {
+	value, err := fan.GetPwmEnabled()
+	if err != nil {
+		return false, err
+	}
+	return ControlMode(value) == ControlModeAutomatic, nil
+}
+
+func (fan *DiscreteFan) Supports(feature FeatureFlag) bool {
+	switch feature {
+	case FeatureControlMode:
+		return true
+	case FeatureRpmSensor:
+		return fan.rpmRegex != nil
+	}
+	return false
+}
+
+// pwmToLevel returns the index of the level for the given pwm value.
+// The level is only changed, if the pwm value moved past the boundary to the neighbouring level
+// by more than the configured hysteresis.
+func (fan *DiscreteFan) pwmToLevel(pwm int) int {
+	target := fan.nearestLevel(float64(pwm))
+	if !fan.levelKnown {
+		return target
+	}
+
+	current := fan.levelIndex
+	hysteresis := float64(fan.Config.Discrete.Hysteresis)
+	if target > current {
+		return max(current, fan.nearestLevel(float64(pwm)-hysteresis))
+	}
+	if target < current {
+		return min(current, fan.nearestLevel(float64(pwm)+hysteresis))
+	}
+	return current
+}
+
+func (fan *DiscreteFan) nearestLevel(pwm float64) int {
+	lastIndex := len(fan.Config.Discrete.Levels) - 1
+	index := int(math.Round(pwm * float64(lastIndex) / MaxPwmValue))
+	return int(util.Coerce(float64(index), 0, float64(lastIndex)))
+}
+
+func (fan *DiscreteFan) levelToPwm(index int) int {
+	lastIndex := len(fan.Config.Discrete.Levels) - 1
+	return int(math.Round(float64(index) * MaxPwmValue / float64(lastIndex)))
+}
+
+func (fan *DiscreteFan) indexOfLevel(level string) int {
+	for i, l := range fan.Config.Discrete.Levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+func (fan *DiscreteFan) readLevel() (string, error) {
+	return fan.readMatch(fan.readRegex)
+}
+
+// readMatch returns the first capture group of the given expression within the content of the fan file
+func (fan *DiscreteFan) readMatch(expr *regexp.Regexp) (string, error) {
+	data, err := os.ReadFile(fan.Config.Discrete.Path)
+	if err != nil {
+		return "", fmt.Errorf("fan %s: %v", fan.GetId(), err)
+	}
+
+	match := expr.FindStringSubmatch(string(data))
+	if len(match) < 2 {
+		return "", fmt.Errorf("fan %s: pattern '%s' did not match", fan.GetId(), expr.String())
+	}
+	return strings.TrimSpace(match[1]), nil
+}
+
+func (fan *DiscreteFan) writeLevel(level string) error {
+	template := fan.Config.Discrete.WriteTemplate
+	if len(template) <= 0 {
+		template = DefaultDiscreteWriteTemplate
+	}
+	command := strings.ReplaceAll(template, discreteLevelPlaceholder, level)
+
+	err := os.WriteFile(fan.Config.Discrete.Path, []byte(command), 0644)
+	if err != nil {
+		return fmt.Errorf("fan %s: unable to set level '%s': %v", fan.GetId(), level, err)
+	}
+	return nil
+}
+
+func (fan *DiscreteFan) getAutoLevel() string {
+	if len(fan.Config.Discrete.AutoLevel) > 0 {
+		return fan.Config.Discrete.AutoLevel
+	}
+	return DefaultDiscreteAutoLevel
+}
+
+func newDiscreteFan(config configuration.FanConfig) (*DiscreteFan, error) {
+	fan := &DiscreteFan{
+		Config:       config,
+		FanCurveData: newFanCurveData(),
+		readRegex:    defaultDiscreteReadRegex,
+	}
+
+	// the patterns are compiled only once, since they are used on every read of the fan
+	var err error
+	if len(config.Discrete.ReadPattern) > 0 {
+		fan.readRegex, err = regexp.Compile(config.Discrete.ReadPattern)
+		if err != nil {
+			return nil, fmt.Errorf("fan %s: invalid readPattern '%s': %v", config.ID, config.Discrete.ReadPattern, err)
+		}
+	}
+	if len(config.Discrete.RpmPattern) > 0 {
+		fan.rpmRegex, err = regexp.Compile(config.Discrete.RpmPattern)
+		if err != nil {
+			return nil, fmt.Errorf("fan %s: invalid rpmPattern '%s': %v", config.ID, config.Discrete.RpmPattern, err)
+		}
+	}
+	return fan, nil
+}
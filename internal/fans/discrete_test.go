@@ -0,0 +1,196 @@
+package fans
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+)
+
+const thinkpadFanContent = `status:		enabled
+speed:		2950
+level:		%s
+commands:	level <level> (<level> is 0-7, auto, disengaged, full-speed)
+`
+
+// helper function to create a fake /proc/acpi/ibm/fan file
+func createDiscreteFan(t *testing.T, level string, hysteresis int) (*DiscreteFan, string) {
+	filePath := path.Join(t.TempDir(), "fan")
+	writeThinkpadLevel(t, filePath, level)
+
+	fan, err := newDiscreteFan(configuration.FanConfig{
+		ID: "thinkpad",
+		Discrete: &configuration.DiscreteFanConfig{
+			Path:       filePath,
+			Levels:     []string{"0", "1", "2", "3", "4", "5", "6", "7"},
+			RpmPattern: `(?m)^speed:\s*(\d+)`,
+			Hysteresis: hysteresis,
+		},
+	})
+	assert.NoError(t, err)
+	return fan, filePath
+}
+
+func writeThinkpadLevel(t *testing.T, filePath string, level string) {
+	err := os.WriteFile(filePath, []byte(fmt.Sprintf(thinkpadFanContent, level)), 0644)
+	assert.NoError(t, err)
+}
+
+func readFile(t *testing.T, filePath string) string {
+	data, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestDiscreteFan_GetPwm(t *testing.T) {
+	// GIVEN
+	fan, _ := createDiscreteFan(t, "7", 0)
+
+	// WHEN
+	pwm, err := fan.GetPwm()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 255, pwm)
+}
+
+func TestDiscreteFan_GetRpm(t *testing.T) {
+	// GIVEN
+	fan, _ := createDiscreteFan(t, "auto", 0)
+
+	// WHEN
+	rpm, err := fan.GetRpm()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 2950, rpm)
+}
+
+func TestDiscreteFan_GetPwmEnabled(t *testing.T) {
+	// GIVEN
+	fan, _ := createDiscreteFan(t, "auto", 0)
+
+	// WHEN
+	auto, err := fan.IsPwmAuto()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.True(t, auto)
+}
+
+func TestDiscreteFan_SetPwm(t *testing.T) {
+	// GIVEN
+	fan, filePath := createDiscreteFan(t, "auto", 0)
+
+	// WHEN
+	err := fan.SetPwm(110)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "level 3", readFile(t, filePath))
+
+	// the fan reports the pwm value set by fan2go, while it stays at the same level
+	writeThinkpadLevel(t, filePath, "3")
+	pwm, err := fan.GetPwm()
+	assert.NoError(t, err)
+	assert.Equal(t, 110, pwm)
+}
+
+func TestDiscreteFan_SetPwm_Hysteresis(t *testing.T) {
+	// GIVEN
+	fan, filePath := createDiscreteFan(t, "auto", 10)
+	// level 3 ~ pwm 109, boundary to level 4 at ~127
+	_ = fan.SetPwm(109)
+
+	// WHEN
+	err := fan.SetPwm(130)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fan.levelIndex)
+
+	// WHEN
+	err = fan.SetPwm(140)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 4, fan.levelIndex)
+	assert.Equal(t, "level 4", readFile(t, filePath))
+
+	// WHEN
+	err = fan.SetPwm(120)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 4, fan.levelIndex)
+}
+
+func TestDiscreteFan_SetPwmEnabled_Auto(t *testing.T) {
+	// GIVEN
+	fan, filePath := createDiscreteFan(t, "2", 0)
+
+	// WHEN
+	err := fan.SetPwmEnabled(ControlModeAutomatic)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "level auto", readFile(t, filePath))
+}
+
+func TestDiscreteFan_Restore(t *testing.T) {
+	// GIVEN
+	fan, filePath := createDiscreteFan(t, "2", 0)
+	err := fan.SetPwm(255)
+	assert.NoError(t, err)
+
+	// WHEN
+	err = fan.Restore()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "level auto", readFile(t, filePath))
+	assert.False(t, fan.levelKnown)
+}
+
+func TestDiscreteFan_InvalidPattern(t *testing.T) {
+	// GIVEN
+	config := configuration.FanConfig{
+		ID: "fan",
+		Discrete: &configuration.DiscreteFanConfig{
+			RpmPattern: `(`,
+		},
+	}
+
+	// WHEN
+	_, err := NewFan(config)
+
+	// THEN
+	assert.ErrorContains(t, err, "fan fan: invalid rpmPattern '('")
+}
+
+func TestDiscreteFan_AttachFanCurveData(t *testing.T) {
+	// GIVEN
+	fan, err := NewFan(configuration.FanConfig{
+		ID:       "fan",
+		Discrete: &configuration.DiscreteFanConfig{},
+	})
+	assert.NoError(t, err)
+	other, err := NewFan(configuration.FanConfig{
+		ID:       "other",
+		Discrete: &configuration.DiscreteFanConfig{},
+	})
+	assert.NoError(t, err)
+	curveData := map[int]float64{0: 0, 255: 100}
+
+	// WHEN
+	err = fan.AttachFanCurveData(&curveData)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Same(t, &curveData, fan.GetFanCurveData())
+	assert.NotSame(t, fan.GetFanCurveData(), other.GetFanCurveData())
+	assert.Equal(t, 255.0, (*other.GetFanCurveData())[255])
+}
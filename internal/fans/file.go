@@ -4,6 +4,7 @@ import (
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/markusressel/fan2go/internal/util"
+	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
@@ -12,6 +13,11 @@ import (
 type FileFan struct {
 	Config    configuration.FanConfig `json:"config"`
 	MovingAvg float64                 `json:"movingAvg"`
+	MinPwm    *int                    `json:"minPwm"`
+	StartPwm  *int                    `json:"startPwm"`
+	MaxPwm    *int                    `json:"maxPwm"`
+
+	FanCurveData *map[int]float64 `json:"fanCurveData"`
 
 	Pwm int `json:"pwm"`
 	Rpm int `json:"rpm"`
@@ -21,27 +27,47 @@ func (fan *FileFan) GetId() string {
 	return fan.Config.ID
 }
 
+func (fan *FileFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
 func (fan *FileFan) GetStartPwm() int {
+	if fan.StartPwm != nil {
+		return *fan.StartPwm
+	}
 	return 1
 }
 
 func (fan *FileFan) SetStartPwm(pwm int, force bool) {
+	if fan.Config.StartPwm == nil || force {
+		fan.StartPwm = &pwm
+	}
 }
 
 func (fan *FileFan) GetMinPwm() int {
+	if fan.MinPwm != nil {
+		return *fan.MinPwm
+	}
 	return MinPwmValue
 }
 
 func (fan *FileFan) SetMinPwm(pwm int, force bool) {
-	// not supported
+	if fan.Config.MinPwm == nil || force {
+		fan.MinPwm = &pwm
+	}
 }
 
 func (fan *FileFan) GetMaxPwm() int {
+	if fan.MaxPwm != nil {
+		return *fan.MaxPwm
+	}
 	return MaxPwmValue
 }
 
 func (fan *FileFan) SetMaxPwm(pwm int, force bool) {
-	// not supported
+	if fan.Config.MaxPwm == nil || force {
+		fan.MaxPwm = &pwm
+	}
 }
 
 func (fan *FileFan) GetRpm() (result int, err error) {
@@ -89,7 +115,7 @@ func (fan *FileFan) GetPwm() (result int, err error) {
 	if err != nil {
 		return MinPwmValue, err
 	}
-	result = integer
+	result = rawValueToPwm(float64(integer), fan.Config.File.Range, fan.Config.File.Invert)
 	fan.Pwm = result
 	return result, err
 }
@@ -106,22 +132,27 @@ func (fan *FileFan) SetPwm(pwm int) (err error) {
 		filePath = filepath.Join(currentUser.HomeDir, filePath[1:])
 	}
 
-	err = util.WriteIntToFile(pwm, filePath)
+	value := pwmToRawValue(pwm, fan.Config.File.Range, fan.Config.File.Invert)
+	err = util.WriteIntToFile(value, filePath)
 	if err != nil {
 		ui.Error("Unable to write to file: %v", fan.Config.File.Path)
 	}
 	return nil
 }
 
-var interpolated = util.InterpolateLinearly(&map[int]float64{0: 0, 255: 255}, 0, 255)
-
 func (fan *FileFan) GetFanCurveData() *map[int]float64 {
-	return &interpolated
+	return fan.FanCurveData
 }
 
+// AttachFanCurveData attaches fan curve data from persistence to a fan
 func (fan *FileFan) AttachFanCurveData(curveData *map[int]float64) (err error) {
-	// not supported
-	return
+	if curveData == nil || len(*curveData) <= 0 {
+		ui.Error("Cant attach empty fan curve data to fan %s", fan.GetId())
+		return os.ErrInvalid
+	}
+
+	fan.FanCurveData = curveData
+	return nil
 }
 
 func (fan *FileFan) GetCurveId() string {
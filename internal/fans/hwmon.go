@@ -4,12 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/markusressel/fan2go/internal/util"
 )
 
+// guards the sysfs paths of all hwmon fans, which are changed when a device is re-bound at runtime
+var hwmonPathMutex sync.RWMutex
+
 type HwMonFan struct {
 	Label        string                  `json:"label"`
 	Index        int                     `json:"index"`
@@ -27,6 +31,10 @@ func (fan *HwMonFan) GetId() string {
 	return fan.Config.ID
 }
 
+func (fan *HwMonFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
 func (fan *HwMonFan) GetMinPwm() int {
 	// if the fan is never supposed to stop,
 	// use the lowest pwm value where the fan is still spinning
@@ -76,7 +84,7 @@ func (fan *HwMonFan) SetMaxPwm(pwm int, force bool) {
 }
 
 func (fan *HwMonFan) GetRpm() (int, error) {
-	if value, err := util.ReadIntFromFile(fan.Config.HwMon.RpmInputPath); err != nil {
+	if value, err := util.ReadIntFromFile(fan.GetPaths().RpmInputPath); err != nil {
 		return 0, err
 	} else {
 		fan.Rpm = value
@@ -93,7 +101,7 @@ func (fan *HwMonFan) SetRpmAvg(rpm float64) {
 }
 
 func (fan *HwMonFan) GetPwm() (int, error) {
-	value, err := util.ReadIntFromFile(fan.Config.HwMon.PwmPath)
+	value, err := util.ReadIntFromFile(fan.GetPaths().PwmPath)
 	if err != nil {
 		return MinPwmValue, err
 	}
@@ -103,7 +111,7 @@ func (fan *HwMonFan) GetPwm() (int, error) {
 
 func (fan *HwMonFan) SetPwm(pwm int) (err error) {
 	ui.Debug("Setting Fan PWM of '%s' to %d ...", fan.GetId(), pwm)
-	err = util.WriteIntToFile(pwm, fan.Config.HwMon.PwmPath)
+	err = util.WriteIntToFile(pwm, fan.GetPaths().PwmPath)
 	return err
 }
 
@@ -142,7 +150,7 @@ func (fan *HwMonFan) ShouldNeverStop() bool {
 }
 
 func (fan *HwMonFan) GetPwmEnabled() (int, error) {
-	return util.ReadIntFromFile(fan.Config.HwMon.PwmEnablePath)
+	return util.ReadIntFromFile(fan.GetPaths().PwmEnablePath)
 }
 
 func (fan *HwMonFan) IsPwmAuto() (bool, error) {
@@ -159,7 +167,7 @@ func (fan *HwMonFan) IsPwmAuto() (bool, error) {
 // 1 - manual pwm control
 // 2 - motherboard pwm control
 func (fan *HwMonFan) SetPwmEnabled(value ControlMode) (err error) {
-	err = util.WriteIntToFile(int(value), fan.Config.HwMon.PwmEnablePath)
+	err = util.WriteIntToFile(int(value), fan.GetPaths().PwmEnablePath)
 	if err == nil {
 		currentValue, err := fan.GetPwmEnabled()
 		if err != nil {
@@ -177,11 +185,25 @@ func (fan *HwMonFan) SetPwmEnabled(value ControlMode) (err error) {
 func (fan *HwMonFan) Supports(feature FeatureFlag) bool {
 	switch feature {
 	case FeatureControlMode:
-		_, err := os.Stat(fan.Config.HwMon.PwmEnablePath)
+		_, err := os.Stat(fan.GetPaths().PwmEnablePath)
 		return err == nil
 	case FeatureRpmSensor:
-		_, err := os.Stat(fan.Config.HwMon.RpmInputPath)
+		_, err := os.Stat(fan.GetPaths().RpmInputPath)
 		return err == nil
 	}
 	return false
 }
+
+// GetPaths returns a copy of the hwmon config of this fan, containing the sysfs paths currently in use
+func (fan *HwMonFan) GetPaths() configuration.HwMonFanConfig {
+	hwmonPathMutex.RLock()
+	defer hwmonPathMutex.RUnlock()
+	return *fan.Config.HwMon
+}
+
+// Rebind changes the sysfs paths of this fan, f.ex. after its device reappeared with a new hwmon number
+func (fan *HwMonFan) Rebind(config configuration.HwMonFanConfig) {
+	hwmonPathMutex.Lock()
+	defer hwmonPathMutex.Unlock()
+	*fan.Config.HwMon = config
+}
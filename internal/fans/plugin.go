@@ -0,0 +1,230 @@
+package fans
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/plugin"
+	"github.com/markusressel/fan2go/internal/ui"
+)
+
+// PluginFan is a fan provided by an external driver plugin
+type PluginFan struct {
+	Config    configuration.FanConfig `json:"config"`
+	MovingAvg float64                 `json:"movingAvg"`
+	MinPwm    *int                    `json:"minPwm"`
+	StartPwm  *int                    `json:"startPwm"`
+	MaxPwm    *int                    `json:"maxPwm"`
+
+	FanCurveData *map[int]float64 `json:"fanCurveData"`
+
+	Pwm int `json:"pwm"`
+	Rpm int `json:"rpm"`
+
+	client *plugin.Client
+	// description of the fan advertised by the plugin, cached since it is needed on every control cycle
+	description *plugin.FanDescription
+	// time of the last failed attempt to describe the fan
+	lastDescribeFailure time.Time
+	descriptionMutex    sync.Mutex
+}
+
+// pluginDescribeRetryInterval is the time to wait before describing a fan again, after the plugin couldn't be reached
+const pluginDescribeRetryInterval = 30 * time.Second
+
+func (fan *PluginFan) GetId() string {
+	return fan.Config.ID
+}
+
+func (fan *PluginFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
+func (fan *PluginFan) GetStartPwm() int {
+	if fan.StartPwm != nil {
+		return *fan.StartPwm
+	}
+	return 1
+}
+
+func (fan *PluginFan) SetStartPwm(pwm int, force bool) {
+	if fan.Config.StartPwm == nil || force {
+		fan.StartPwm = &pwm
+	}
+}
+
+func (fan *PluginFan) GetMinPwm() int {
+	if fan.MinPwm != nil {
+		return *fan.MinPwm
+	}
+	return MinPwmValue
+}
+
+func (fan *PluginFan) SetMinPwm(pwm int, force bool) {
+	if fan.Config.MinPwm == nil || force {
+		fan.MinPwm = &pwm
+	}
+}
+
+func (fan *PluginFan) GetMaxPwm() int {
+	if fan.MaxPwm != nil {
+		return *fan.MaxPwm
+	}
+	return MaxPwmValue
+}
+
+func (fan *PluginFan) SetMaxPwm(pwm int, force bool) {
+	if fan.Config.MaxPwm == nil || force {
+		fan.MaxPwm = &pwm
+	}
+}
+
+func (fan *PluginFan) GetRpm() (int, error) {
+	if !fan.Supports(FeatureRpmSensor) {
+		return 0, nil
+	}
+
+	rpm, err := fan.client.GetRpm(fan.Config.Plugin.Fan)
+	if err != nil {
+		return 0, err
+	}
+	fan.Rpm = rpm
+	return rpm, nil
+}
+
+func (fan *PluginFan) GetRpmAvg() float64 {
+	return float64(fan.Rpm)
+}
+
+func (fan *PluginFan) SetRpmAvg(rpm float64) {
+	fan.Rpm = int(rpm)
+}
+
+func (fan *PluginFan) GetPwm() (int, error) {
+	pwm, err := fan.client.GetPwm(fan.Config.Plugin.Fan)
+	if err != nil {
+		return MinPwmValue, err
+	}
+	fan.Pwm = pwm
+	return pwm, nil
+}
+
+func (fan *PluginFan) SetPwm(pwm int) error {
+	ui.Debug("Setting Fan PWM of '%s' to %d ...", fan.GetId(), pwm)
+	return fan.client.SetPwm(fan.Config.Plugin.Fan, pwm)
+}
+
+func (fan *PluginFan) GetFanCurveData() *map[int]float64 {
+	return fan.FanCurveData
+}
+
+// AttachFanCurveData attaches fan curve data from persistence to a fan
+func (fan *PluginFan) AttachFanCurveData(curveData *map[int]float64) (err error) {
+	if curveData == nil || len(*curveData) <= 0 {
+		ui.Error("Cant attach empty fan curve data to fan %s", fan.GetId())
+		return os.ErrInvalid
+	}
+
+	fan.FanCurveData = curveData
+
+	startPwm, maxPwm := ComputePwmBoundaries(fan)
+	fan.SetStartPwm(startPwm, false)
+	fan.SetMaxPwm(maxPwm, false)
+	return nil
+}
+
+func (fan *PluginFan) GetCurveId() string {
+	return fan.Config.Curve
+}
+
+func (fan *PluginFan) ShouldNeverStop() bool {
+	return fan.Config.NeverStop
+}
+
+func (fan *PluginFan) GetPwmEnabled() (int, error) {
+	if !fan.Supports(FeatureControlMode) {
+		return int(ControlModePWM), nil
+	}
+	return fan.client.GetPwmEnabled(fan.Config.Plugin.Fan)
+}
+
+func (fan *PluginFan) SetPwmEnabled(value ControlMode) (err error) {
+	if !fan.Supports(FeatureControlMode) {
+		return nil
+	}
+	return fan.client.SetPwmEnabled(fan.Config.Plugin.Fan, int(value))
+}
+
+func (fan *PluginFan) IsPwmAuto() (bool, error) {
+	value, err := fan.GetPwmEnabled()
+	if err != nil {
+		return false, err
+	}
+	return ControlMode(value) == ControlModeAutomatic, nil
+}
+
+// Supports returns the features advertised by the plugin for this fan
+func (fan *PluginFan) Supports(feature FeatureFlag) bool {
+	fanDescription, ok := fan.describe()
+	if !ok {
+		return false
+	}
+
+	switch feature {
+	case FeatureRpmSensor:
+		return fanDescription.Supports(plugin.FeatureRpmSensor)
+	case FeatureControlMode:
+		return fanDescription.Supports(plugin.FeatureControlMode)
+	}
+	return false
+}
+
+// describe returns the description of the fan advertised by the plugin.
+// A failed lookup is only retried after pluginDescribeRetryInterval, so an unreachable plugin doesn't block every call.
+func (fan *PluginFan) describe() (plugin.FanDescription, bool) {
+	fan.descriptionMutex.Lock()
+	defer fan.descriptionMutex.Unlock()
+
+	if fan.description != nil {
+		return *fan.description, true
+	}
+	if !fan.lastDescribeFailure.IsZero() && time.Since(fan.lastDescribeFailure) < pluginDescribeRetryInterval {
+		return plugin.FanDescription{}, false
+	}
+
+	description, err := fan.client.Describe()
+	if err == nil {
+		if fanDescription, ok := description.GetFan(fan.Config.Plugin.Fan); ok {
+			fan.description = &fanDescription
+			return fanDescription, true
+		}
+	}
+	fan.lastDescribeFailure = time.Now()
+	return plugin.FanDescription{}, false
+}
+
+func newPluginFan(config configuration.FanConfig) (*PluginFan, error) {
+	client, err := plugin.GetClient(config.Plugin.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	description, err := client.Describe()
+	if err != nil {
+		ui.Warning("Unable to query devices of plugin %s: %v", config.Plugin.ID, err)
+	} else if _, ok := description.GetFan(config.Plugin.Fan); !ok {
+		return nil, fmt.Errorf("plugin %s doesn't provide a fan with id '%s'", config.Plugin.ID, config.Plugin.Fan)
+	}
+
+	return &PluginFan{
+		MinPwm:       config.MinPwm,
+		StartPwm:     config.StartPwm,
+		MaxPwm:       config.MaxPwm,
+		Config:       config,
+		FanCurveData: newFanCurveData(),
+		client:       client,
+	}, nil
+}
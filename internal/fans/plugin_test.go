@@ -0,0 +1,149 @@
+package fans
+
+import (
+	"net"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPluginHandler struct {
+	plugin.Handler
+	pwm int
+}
+
+func (h *mockPluginHandler) Describe() plugin.Description {
+	return plugin.Description{
+		Fans: []plugin.FanDescription{
+			{Id: "usb1", Features: []string{plugin.FeatureRpmSensor}},
+		},
+	}
+}
+
+func (h *mockPluginHandler) GetPwm(fanId string) (int, error) {
+	return h.pwm, nil
+}
+
+func (h *mockPluginHandler) SetPwm(fanId string, pwm int) error {
+	h.pwm = pwm
+	return nil
+}
+
+func (h *mockPluginHandler) GetRpm(fanId string) (int, error) {
+	return h.pwm * 10, nil
+}
+
+func startMockPlugin(t *testing.T, pluginId string, handler plugin.Handler) {
+	socket := path.Join(t.TempDir(), "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		_ = plugin.Serve(listener, handler)
+	}()
+
+	original := configuration.CurrentConfig.Plugins
+	configuration.CurrentConfig.Plugins = []configuration.PluginConfig{
+		{ID: pluginId, Socket: socket},
+	}
+	t.Cleanup(func() {
+		configuration.CurrentConfig.Plugins = original
+	})
+}
+
+func TestPluginFan(t *testing.T) {
+	// GIVEN
+	handler := &mockPluginHandler{}
+	startMockPlugin(t, "usb_fans", handler)
+
+	fan, err := NewFan(configuration.FanConfig{
+		ID: "fan",
+		Plugin: &configuration.PluginFanConfig{
+			ID:  "usb_fans",
+			Fan: "usb1",
+		},
+	})
+	assert.NoError(t, err)
+
+	// WHEN
+	err = fan.SetPwm(120)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 120, handler.pwm)
+
+	rpm, err := fan.GetRpm()
+	assert.NoError(t, err)
+	assert.Equal(t, 1200, rpm)
+
+	assert.True(t, fan.Supports(FeatureRpmSensor))
+	assert.False(t, fan.Supports(FeatureControlMode))
+	mode, err := fan.GetPwmEnabled()
+	assert.NoError(t, err)
+	assert.Equal(t, int(ControlModePWM), mode)
+}
+
+func TestPluginFan_NotAdvertised(t *testing.T) {
+	// GIVEN
+	startMockPlugin(t, "usb_fans_2", &mockPluginHandler{})
+
+	// WHEN
+	_, err := NewFan(configuration.FanConfig{
+		ID: "fan",
+		Plugin: &configuration.PluginFanConfig{
+			ID:  "usb_fans_2",
+			Fan: "usb9",
+		},
+	})
+
+	// THEN
+	assert.EqualError(t, err, "plugin usb_fans_2 doesn't provide a fan with id 'usb9'")
+}
+
+func TestPluginFan_OwnFanCurveData(t *testing.T) {
+	// GIVEN
+	startMockPlugin(t, "usb_fans_3", &mockPluginHandler{})
+
+	config := configuration.FanConfig{
+		ID: "fan",
+		Plugin: &configuration.PluginFanConfig{
+			ID:  "usb_fans_3",
+			Fan: "usb1",
+		},
+	}
+	fan1, err := NewFan(config)
+	assert.NoError(t, err)
+	fan2, err := NewFan(config)
+	assert.NoError(t, err)
+
+	// WHEN
+	(*fan1.GetFanCurveData())[128] = 42
+
+	// THEN
+	assert.NotSame(t, fan1.GetFanCurveData(), fan2.GetFanCurveData())
+	assert.Equal(t, 128.0, (*fan2.GetFanCurveData())[128])
+}
+
+func TestPluginFan_Supports_DoesNotRetryDescribeWithinInterval(t *testing.T) {
+	// GIVEN
+	fan := &PluginFan{
+		Config: configuration.FanConfig{
+			ID:     "fan",
+			Plugin: &configuration.PluginFanConfig{ID: "unreachable", Fan: "usb1"},
+		},
+		lastDescribeFailure: time.Now(),
+	}
+
+	// WHEN
+	supported := fan.Supports(FeatureRpmSensor)
+
+	// THEN
+	// the plugin client is not used again until the retry interval has passed
+	assert.False(t, supported)
+}
@@ -0,0 +1,163 @@
+package fans
+
+import (
+	"math"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+// SimulatedFan replaces a configured fan when running a simulation.
+// Its RPM is derived from a pwm -> rpm curve instead of being read from hardware.
+type SimulatedFan struct {
+	Config    configuration.FanConfig `json:"config"`
+	MinPwm    *int                    `json:"minPwm"`
+	StartPwm  *int                    `json:"startPwm"`
+	MaxPwm    *int                    `json:"maxPwm"`
+	FanCurve  *map[int]float64        `json:"fanCurve"`
+	MovingAvg float64                 `json:"movingAvg"`
+
+	Pwm int `json:"pwm"`
+}
+
+// NewSimulatedFan creates a simulated fan with the given pwm -> rpm curve, using the pwm settings of the given config
+func NewSimulatedFan(config configuration.FanConfig, fanCurve map[int]float64) *SimulatedFan {
+	return &SimulatedFan{
+		Config:   config,
+		MinPwm:   config.MinPwm,
+		StartPwm: config.StartPwm,
+		MaxPwm:   config.MaxPwm,
+		FanCurve: &fanCurve,
+	}
+}
+
+func (fan *SimulatedFan) GetId() string {
+	return fan.Config.ID
+}
+
+func (fan *SimulatedFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
+func (fan *SimulatedFan) GetStartPwm() int {
+	if fan.StartPwm != nil {
+		return *fan.StartPwm
+	}
+	return 1
+}
+
+func (fan *SimulatedFan) SetStartPwm(pwm int, force bool) {
+	if fan.Config.StartPwm == nil || force {
+		fan.StartPwm = &pwm
+	}
+}
+
+func (fan *SimulatedFan) GetMinPwm() int {
+	if fan.MinPwm != nil {
+		return *fan.MinPwm
+	}
+	return MinPwmValue
+}
+
+func (fan *SimulatedFan) SetMinPwm(pwm int, force bool) {
+	if fan.Config.MinPwm == nil || force {
+		fan.MinPwm = &pwm
+	}
+}
+
+func (fan *SimulatedFan) GetMaxPwm() int {
+	if fan.MaxPwm != nil {
+		return *fan.MaxPwm
+	}
+	return MaxPwmValue
+}
+
+func (fan *SimulatedFan) SetMaxPwm(pwm int, force bool) {
+	if fan.Config.MaxPwm == nil || force {
+		fan.MaxPwm = &pwm
+	}
+}
+
+func (fan *SimulatedFan) GetRpm() (int, error) {
+	if fan.FanCurve == nil || len(*fan.FanCurve) <= 0 {
+		return 0, nil
+	}
+	rpm := util.CalculateInterpolatedCurveValue(*fan.FanCurve, util.InterpolationTypeLinear, float64(fan.Pwm))
+	return int(math.Round(rpm)), nil
+}
+
+// GetMaxRpm returns the highest RPM value of the fan curve
+func (fan *SimulatedFan) GetMaxRpm() float64 {
+	result := 0.0
+	if fan.FanCurve == nil {
+		return result
+	}
+	for _, rpm := range *fan.FanCurve {
+		result = math.Max(result, rpm)
+	}
+	return result
+}
+
+func (fan *SimulatedFan) GetRpmAvg() float64 {
+	return fan.MovingAvg
+}
+
+func (fan *SimulatedFan) SetRpmAvg(rpm float64) {
+	fan.MovingAvg = rpm
+}
+
+func (fan *SimulatedFan) GetPwm() (int, error) {
+	return fan.Pwm, nil
+}
+
+func (fan *SimulatedFan) SetPwm(pwm int) (err error) {
+	fan.Pwm = pwm
+	return nil
+}
+
+// GetPwmMap returns the pwm map of the fan, a simulated fan always applies the exact pwm value that is set
+func (fan *SimulatedFan) GetPwmMap() (map[int]int, error) {
+	result := map[int]int{}
+	for pwm := MinPwmValue; pwm <= MaxPwmValue; pwm++ {
+		result[pwm] = pwm
+	}
+	return result, nil
+}
+
+func (fan *SimulatedFan) GetFanCurveData() *map[int]float64 {
+	return fan.FanCurve
+}
+
+func (fan *SimulatedFan) AttachFanCurveData(curveData *map[int]float64) (err error) {
+	fan.FanCurve = curveData
+	return nil
+}
+
+func (fan *SimulatedFan) GetCurveId() string {
+	return fan.Config.Curve
+}
+
+func (fan *SimulatedFan) ShouldNeverStop() bool {
+	return fan.Config.NeverStop
+}
+
+func (fan *SimulatedFan) GetPwmEnabled() (int, error) {
+	return int(ControlModePWM), nil
+}
+
+func (fan *SimulatedFan) SetPwmEnabled(value ControlMode) (err error) {
+	// nothing to do
+	return nil
+}
+
+func (fan *SimulatedFan) IsPwmAuto() (bool, error) {
+	return false, nil
+}
+
+func (fan *SimulatedFan) Supports(feature FeatureFlag) bool {
+	switch feature {
+	case FeatureRpmSensor:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package fans
+
+import (
+	"math"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+// pwmToRawValue maps the given pwm value (0..255) onto the value range of a fan
+func pwmToRawValue(pwm int, valueRange *configuration.FanValueRange, invert bool) int {
+	pwm = int(util.Coerce(float64(pwm), MinPwmValue, MaxPwmValue))
+	if invert {
+		pwm = MaxPwmValue - pwm
+	}
+	if valueRange == nil {
+		return pwm
+	}
+
+	ratio := float64(pwm) / MaxPwmValue
+	return valueRange.Min + int(math.Round(ratio*float64(valueRange.Max-valueRange.Min)))
+}
+
+// rawValueToPwm maps the given value within the value range of a fan onto the pwm range (0..255)
+func rawValueToPwm(value float64, valueRange *configuration.FanValueRange, invert bool) int {
+	pwm := value
+	if valueRange != nil {
+		ratio := (value - float64(valueRange.Min)) / float64(valueRange.Max-valueRange.Min)
+		pwm = ratio * MaxPwmValue
+	}
+	pwm = util.Coerce(math.Round(pwm), MinPwmValue, MaxPwmValue)
+	if invert {
+		pwm = MaxPwmValue - pwm
+	}
+	return int(pwm)
+}
@@ -0,0 +1,95 @@
+package fans
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPwmToRawValue(t *testing.T) {
+	percent := &configuration.FanValueRange{Min: 0, Max: 100}
+	duty := &configuration.FanValueRange{Min: 0, Max: 10000}
+
+	assert.Equal(t, 128, pwmToRawValue(128, nil, false))
+	assert.Equal(t, 127, pwmToRawValue(128, nil, true))
+	assert.Equal(t, 0, pwmToRawValue(0, percent, false))
+	assert.Equal(t, 50, pwmToRawValue(128, percent, false))
+	assert.Equal(t, 100, pwmToRawValue(255, percent, false))
+	assert.Equal(t, 100, pwmToRawValue(300, percent, false))
+	assert.Equal(t, 0, pwmToRawValue(255, percent, true))
+	assert.Equal(t, 10000, pwmToRawValue(255, duty, false))
+}
+
+func TestRawValueToPwm(t *testing.T) {
+	percent := &configuration.FanValueRange{Min: 0, Max: 100}
+
+	assert.Equal(t, 128, rawValueToPwm(128, nil, false))
+	assert.Equal(t, 128, rawValueToPwm(50, percent, false))
+	assert.Equal(t, 255, rawValueToPwm(100, percent, false))
+	assert.Equal(t, 255, rawValueToPwm(120, percent, false))
+	assert.Equal(t, 0, rawValueToPwm(100, percent, true))
+}
+
+func TestFileFan_Range(t *testing.T) {
+	// GIVEN
+	filePath := path.Join(t.TempDir(), "fan")
+	err := os.WriteFile(filePath, []byte("0"), 0644)
+	assert.NoError(t, err)
+
+	minPwm := 30
+	fan, err := NewFan(configuration.FanConfig{
+		ID:     "fan",
+		MinPwm: &minPwm,
+		File: &configuration.FileFanConfig{
+			Path:   filePath,
+			Range:  &configuration.FanValueRange{Min: 0, Max: 100},
+			Invert: true,
+		},
+	})
+	assert.NoError(t, err)
+
+	// WHEN
+	err = fan.SetPwm(204)
+
+	// THEN
+	assert.NoError(t, err)
+	data, _ := os.ReadFile(filePath)
+	assert.Equal(t, "20", strings.TrimSpace(string(data)))
+
+	pwm, err := fan.GetPwm()
+	assert.NoError(t, err)
+	assert.Equal(t, 204, pwm)
+
+	assert.Equal(t, 30, fan.GetMinPwm())
+	assert.Equal(t, 1, fan.GetStartPwm())
+	assert.Equal(t, 255, fan.GetMaxPwm())
+}
+
+func TestFileAndCmdFan_OwnFanCurveData(t *testing.T) {
+	// GIVEN
+	fileFan, err := NewFan(configuration.FanConfig{
+		ID:   "file",
+		File: &configuration.FileFanConfig{Path: path.Join(t.TempDir(), "pwm")},
+	})
+	assert.NoError(t, err)
+	cmdFan, err := NewFan(configuration.FanConfig{
+		ID:  "cmd",
+		Cmd: &configuration.CmdFanConfig{},
+	})
+	assert.NoError(t, err)
+	curveData := map[int]float64{0: 0, 255: 100}
+
+	// WHEN
+	(*cmdFan.GetFanCurveData())[128] = 42
+	err = fileFan.AttachFanCurveData(&curveData)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Same(t, &curveData, fileFan.GetFanCurveData())
+	assert.NotSame(t, fileFan.GetFanCurveData(), cmdFan.GetFanCurveData())
+	assert.Equal(t, 42.0, (*cmdFan.GetFanCurveData())[128])
+}
@@ -0,0 +1,255 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/markusressel/fan2go/internal/hwmon"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultCurveId = "default_curve"
+	// DefaultCurveMin is the temperature (in °C) at which the default curve starts to increase the fan speed
+	DefaultCurveMin = 40
+	// DefaultCurveMax is the temperature (in °C) at which the default curve reaches full fan speed
+	DefaultCurveMax = 80
+)
+
+// labels of sensors that are likely to represent the CPU temperature
+var cpuSensorLabelRegex = regexp.MustCompile(`(?i)package|tctl|tdie|cpu`)
+
+// Sensor is a temperature input of a hwmon device
+type Sensor struct {
+	ID       string
+	Platform string
+	// BusAddress is only set if the platform alone does not identify the device
+	BusAddress string
+	Index      int
+	Label      string
+	// ConfigLabel is used instead of the index if it identifies the sensor unambiguously
+	ConfigLabel string
+	// Value is the current temperature in milli-degrees
+	Value float64
+}
+
+// Fan is a pwm controllable fan channel of a hwmon device
+type Fan struct {
+	ID       string
+	Platform string
+	// BusAddress is only set if the platform alone does not identify the device
+	BusAddress string
+	RpmChannel int
+	PwmChannel int
+	Label      string
+	// ConfigLabel is used instead of the rpm channel if it identifies the fan unambiguously
+	ConfigLabel string
+	Rpm         float64
+}
+
+// Candidates collects all temperature inputs and all pwm controllable fans of the given hwmon controllers
+func Candidates(controllers []*hwmon.HwMonController) (sensorList []Sensor, fanList []Fan) {
+	ids := map[string]bool{}
+	platforms := map[string]int{}
+	for _, controller := range controllers {
+		platforms[controller.Platform]++
+	}
+
+	for _, controller := range controllers {
+		if len(controller.Name) <= 0 {
+			continue
+		}
+		prefix := strings.SplitN(controller.Name, "-", 2)[0]
+		busAddress := ""
+		if platforms[controller.Platform] > 1 {
+			// f.ex. two identical GPUs
+			busAddress = controller.BusAddress
+		}
+
+		for _, fan := range controller.Fans {
+			if _, err := os.Stat(fan.Config.HwMon.PwmPath); err != nil {
+				// fan has no pwm output
+				continue
+			}
+			fanList = append(fanList, Fan{
+				ID:          uniqueId(ids, prefix, fan.Label),
+				Platform:    controller.Platform,
+				BusAddress:  busAddress,
+				RpmChannel:  fan.Config.HwMon.RpmChannel,
+				PwmChannel:  fan.Config.HwMon.PwmChannel,
+				Label:       fan.Label,
+				ConfigLabel: controller.FanConfigLabel(fan),
+				Rpm:         fan.RpmMovingAvg,
+			})
+		}
+
+		indices := make([]int, 0, len(controller.Sensors))
+		for index := range controller.Sensors {
+			indices = append(indices, index)
+		}
+		sort.Ints(indices)
+		for _, index := range indices {
+			sensor := controller.Sensors[index]
+			sensorList = append(sensorList, Sensor{
+				ID:          uniqueId(ids, prefix, sensor.Label),
+				Platform:    controller.Platform,
+				BusAddress:  busAddress,
+				Index:       sensor.Index,
+				Label:       sensor.Label,
+				ConfigLabel: controller.SensorConfigLabel(sensor),
+				Value:       sensor.MovingAvg,
+			})
+		}
+	}
+
+	return sensorList, fanList
+}
+
+// DefaultCurveSensor returns the sensor most likely representing the CPU temperature, or the first one
+func DefaultCurveSensor(sensorList []Sensor) *Sensor {
+	if len(sensorList) <= 0 {
+		return nil
+	}
+	for idx := range sensorList {
+		if cpuSensorLabelRegex.MatchString(sensorList[idx].Label) {
+			return &sensorList[idx]
+		}
+	}
+	return &sensorList[0]
+}
+
+// Render creates a commented configuration file containing the given sensors and fans,
+// all fans use a linear curve based on the given curve sensor
+func Render(sensorList []Sensor, fanList []Fan, curveSensor Sensor) ([]byte, error) {
+	var fanNodes []*yaml.Node
+	for _, fan := range fanList {
+		hwmonNode := mapping(
+			"platform", str(regexp.QuoteMeta(fan.Platform)),
+		)
+		if len(fan.ConfigLabel) > 0 {
+			hwmonNode.Content = append(hwmonNode.Content, str("label"), str(fan.ConfigLabel))
+		} else {
+			hwmonNode.Content = append(hwmonNode.Content, str("rpmChannel"), integer(fan.RpmChannel))
+		}
+		hwmonNode.Content = append(hwmonNode.Content, str("pwmChannel"), integer(fan.PwmChannel))
+		if len(fan.BusAddress) > 0 {
+			hwmonNode.Content = append(hwmonNode.Content, str("busAddress"), str(fan.BusAddress))
+		}
+		node := mapping(
+			"id", str(fan.ID),
+			"hwmon", hwmonNode,
+			"neverStop", boolean(true),
+			"curve", str(DefaultCurveId),
+		)
+		node.HeadComment = fmt.Sprintf("%s, current speed: %d rpm", fan.Label, int(fan.Rpm))
+		fanNodes = append(fanNodes, node)
+	}
+
+	var sensorNodes []*yaml.Node
+	for _, sensor := range sensorList {
+		hwmonNode := mapping(
+			"platform", str(regexp.QuoteMeta(sensor.Platform)),
+		)
+		if len(sensor.ConfigLabel) > 0 {
+			hwmonNode.Content = append(hwmonNode.Content, str("label"), str(sensor.ConfigLabel))
+		} else {
+			hwmonNode.Content = append(hwmonNode.Content, str("index"), integer(sensor.Index))
+		}
+		if len(sensor.BusAddress) > 0 {
+			hwmonNode.Content = append(hwmonNode.Content, str("busAddress"), str(sensor.BusAddress))
+		}
+		node := mapping(
+			"id", str(sensor.ID),
+			"hwmon", hwmonNode,
+		)
+		node.HeadComment = fmt.Sprintf("%s, current value: %.1f°C", sensor.Label, sensor.Value/1000)
+		sensorNodes = append(sensorNodes, node)
+	}
+
+	curveNode := mapping(
+		"id", str(DefaultCurveId),
+		"linear", mapping(
+			"sensor", str(curveSensor.ID),
+			"min", integer(DefaultCurveMin),
+			"max", integer(DefaultCurveMax),
+		),
+	)
+	curveNode.HeadComment = fmt.Sprintf("Fans run at their minimum speed below %d°C and at full speed above %d°C.\nAdjust this curve to your needs.", DefaultCurveMin, DefaultCurveMax)
+
+	root := mapping(
+		"fans", sequence(fanNodes...),
+		"sensors", sequence(sensorNodes...),
+		"curves", sequence(curveNode),
+	)
+	root.Content[0].HeadComment = "Fans controlled by fan2go. Fans with neverStop: true are never stopped completely."
+	root.Content[2].HeadComment = "Temperature sensors used by the curves."
+	root.Content[4].HeadComment = "Curves define the speed of the fans based on sensor values."
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err := encoder.Encode(&yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: "fan2go configuration generated by \"fan2go config generate\".\nSee https://github.com/markusressel/fan2go for all available options.",
+		Content:     []*yaml.Node{root},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Slug turns the given label into an identifier usable in the configuration
+func Slug(label string) string {
+	var builder strings.Builder
+	lastUnderscore := true
+	for _, r := range strings.ToLower(label) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			builder.WriteRune(r)
+			lastUnderscore = false
+		} else if !lastUnderscore {
+			builder.WriteRune('_')
+			lastUnderscore = true
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "_")
+}
+
+func uniqueId(ids map[string]bool, prefix string, label string) string {
+	id := Slug(prefix + " " + label)
+	result := id
+	for i := 2; ids[result]; i++ {
+		result = fmt.Sprintf("%s_%d", id, i)
+	}
+	ids[result] = true
+	return result
+}
+
+func mapping(keysAndValues ...interface{}) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for idx := 0; idx+1 < len(keysAndValues); idx += 2 {
+		node.Content = append(node.Content, str(keysAndValues[idx].(string)), keysAndValues[idx+1].(*yaml.Node))
+	}
+	return node
+}
+
+func sequence(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Content: items}
+}
+
+func str(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func integer(value int) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(value)}
+}
+
+func boolean(value bool) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value)}
+}
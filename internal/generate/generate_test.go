@@ -0,0 +1,163 @@
+package generate
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/hwmon"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func createController(t *testing.T) *hwmon.HwMonController {
+	dir := t.TempDir()
+	// only the first fan has a pwm output
+	err := os.WriteFile(path.Join(dir, "pwm1"), []byte("128"), 0644)
+	assert.NoError(t, err)
+
+	return &hwmon.HwMonController{
+		Name:     "nct6798-isa-0290",
+		Platform: "nct6798-isa-0290",
+		Path:     dir,
+		Fans: []fans.HwMonFan{
+			{
+				Label:        "CPU Fan",
+				Index:        1,
+				RpmMovingAvg: 800,
+				Config: configuration.FanConfig{
+					HwMon: &configuration.HwMonFanConfig{
+						RpmChannel: 1,
+						PwmChannel: 1,
+						PwmPath:    path.Join(dir, "pwm1"),
+					},
+				},
+			},
+			{
+				Label: "fan2",
+				Index: 2,
+				Config: configuration.FanConfig{
+					HwMon: &configuration.HwMonFanConfig{
+						RpmChannel: 2,
+						PwmChannel: 2,
+						PwmPath:    path.Join(dir, "pwm2"),
+					},
+				},
+			},
+		},
+		Sensors: map[int]*sensors.HwmonSensor{
+			2: {Label: "CPUTIN", Index: 2, MovingAvg: 45000},
+			1: {Label: "SYSTIN", Index: 1, MovingAvg: 30000},
+			3: {Label: "SYSTIN", Index: 3, MovingAvg: 31000},
+		},
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	// GIVEN
+	controller := createController(t)
+
+	// WHEN
+	sensorList, fanList := Candidates([]*hwmon.HwMonController{controller})
+
+	// THEN
+	assert.Equal(t, []Fan{
+		{ID: "nct6798_cpu_fan", Platform: "nct6798-isa-0290", RpmChannel: 1, PwmChannel: 1, Label: "CPU Fan", ConfigLabel: "CPU Fan", Rpm: 800},
+	}, fanList)
+	assert.Equal(t, []Sensor{
+		{ID: "nct6798_systin", Platform: "nct6798-isa-0290", Index: 1, Label: "SYSTIN", Value: 30000},
+		{ID: "nct6798_cputin", Platform: "nct6798-isa-0290", Index: 2, Label: "CPUTIN", ConfigLabel: "CPUTIN", Value: 45000},
+		{ID: "nct6798_systin_2", Platform: "nct6798-isa-0290", Index: 3, Label: "SYSTIN", Value: 31000},
+	}, sensorList)
+}
+
+func TestDefaultCurveSensor(t *testing.T) {
+	// GIVEN
+	sensorList := []Sensor{
+		{ID: "systin", Label: "SYSTIN"},
+		{ID: "cputin", Label: "CPUTIN"},
+	}
+
+	// WHEN
+	result := DefaultCurveSensor(sensorList)
+
+	// THEN
+	assert.Equal(t, "cputin", result.ID)
+	assert.Equal(t, "systin", DefaultCurveSensor(sensorList[:1]).ID)
+	assert.Nil(t, DefaultCurveSensor(nil))
+}
+
+func TestRender(t *testing.T) {
+	// GIVEN
+	sensorList, fanList := Candidates([]*hwmon.HwMonController{createController(t)})
+
+	// WHEN
+	data, err := Render(sensorList, fanList, *DefaultCurveSensor(sensorList))
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "# CPU Fan, current speed: 800 rpm")
+	assert.Contains(t, string(data), "# CPUTIN, current value: 45.0°C")
+
+	var config struct {
+		Fans []struct {
+			ID    string `yaml:"id"`
+			HwMon struct {
+				Platform   string `yaml:"platform"`
+				Label      string `yaml:"label"`
+				RpmChannel int    `yaml:"rpmChannel"`
+				PwmChannel int    `yaml:"pwmChannel"`
+			} `yaml:"hwmon"`
+			NeverStop bool   `yaml:"neverStop"`
+			Curve     string `yaml:"curve"`
+		} `yaml:"fans"`
+		Sensors []struct {
+			ID    string `yaml:"id"`
+			HwMon struct {
+				Platform string `yaml:"platform"`
+				Label    string `yaml:"label"`
+				Index    int    `yaml:"index"`
+			} `yaml:"hwmon"`
+		} `yaml:"sensors"`
+		Curves []struct {
+			ID     string `yaml:"id"`
+			Linear struct {
+				Sensor string `yaml:"sensor"`
+				Min    int    `yaml:"min"`
+				Max    int    `yaml:"max"`
+			} `yaml:"linear"`
+		} `yaml:"curves"`
+	}
+	err = yaml.Unmarshal(data, &config)
+	assert.NoError(t, err)
+
+	assert.Len(t, config.Fans, 1)
+	assert.Equal(t, "nct6798_cpu_fan", config.Fans[0].ID)
+	assert.Equal(t, "nct6798-isa-0290", config.Fans[0].HwMon.Platform)
+	assert.Equal(t, "CPU Fan", config.Fans[0].HwMon.Label)
+	assert.Equal(t, 0, config.Fans[0].HwMon.RpmChannel)
+	assert.Equal(t, 1, config.Fans[0].HwMon.PwmChannel)
+	assert.True(t, config.Fans[0].NeverStop)
+	assert.Equal(t, DefaultCurveId, config.Fans[0].Curve)
+
+	assert.Len(t, config.Sensors, 3)
+	assert.Equal(t, "nct6798_cputin", config.Sensors[1].ID)
+	assert.Equal(t, "CPUTIN", config.Sensors[1].HwMon.Label)
+	// labels of the other sensors are not unique
+	assert.Equal(t, 1, config.Sensors[0].HwMon.Index)
+	assert.Equal(t, "", config.Sensors[0].HwMon.Label)
+
+	assert.Len(t, config.Curves, 1)
+	assert.Equal(t, "nct6798_cputin", config.Curves[0].Linear.Sensor)
+	assert.Equal(t, DefaultCurveMin, config.Curves[0].Linear.Min)
+	assert.Equal(t, DefaultCurveMax, config.Curves[0].Linear.Max)
+}
+
+func TestSlug(t *testing.T) {
+	assert.Equal(t, "nct6798_cpu_fan", Slug("nct6798 CPU Fan"))
+	assert.Equal(t, "amdgpu_edge", Slug("amdgpu  -edge-"))
+	assert.Equal(t, "k10temp_tctl", Slug("k10temp Tctl"))
+}
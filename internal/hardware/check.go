@@ -0,0 +1,132 @@
+package hardware
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/hwmon"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+// checker collects the problems found while resolving a configuration against the live system
+type checker struct {
+	controllers []*hwmon.HwMonController
+	problems    []configuration.ValidationProblem
+}
+
+func (c *checker) error(path string, format string, a ...interface{}) {
+	c.problems = append(c.problems, configuration.ValidationProblem{Severity: configuration.SeverityError, Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+func (c *checker) warning(path string, format string, a ...interface{}) {
+	c.problems = append(c.problems, configuration.ValidationProblem{Severity: configuration.SeverityWarning, Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+// Check resolves all hwmon, file and cmd references of the given configuration against the given hwmon devices
+// and the filesystem, and returns all problems found. No fan is touched in the process.
+func Check(config *configuration.Configuration, controllers []*hwmon.HwMonController) []configuration.ValidationProblem {
+	c := &checker{controllers: controllers}
+	for idx, sensorConfig := range config.Sensors {
+		c.checkSensor(fmt.Sprintf("sensors[%d]", idx), sensorConfig)
+	}
+	for idx, fanConfig := range config.Fans {
+		c.checkFan(fmt.Sprintf("fans[%d]", idx), fanConfig)
+	}
+	return c.problems
+}
+
+func (c *checker) checkSensor(path string, config configuration.SensorConfig) {
+	name := fmt.Sprintf("sensor %s", config.ID)
+
+	if config.HwMon != nil {
+		// resolve a copy, to keep the given configuration as is
+		hwMonConfig := *config.HwMon
+		config.HwMon = &hwMonConfig
+		if err := hwmon.UpdateSensorConfigFromHwMonControllers(c.controllers, &config); err != nil {
+			c.error(path+".hwMon", "%v", err)
+		} else {
+			c.checkFile(path+".hwMon", name, hwMonConfig.TempInput, false)
+		}
+	}
+
+	if config.File != nil {
+		c.checkFile(path+".file.path", name, config.File.Path, false)
+	}
+
+	if config.Cmd != nil {
+		if config.Cmd.Coprocess != nil {
+			c.checkExecutable(path+".cmd.coprocess.exec", name, config.Cmd.Coprocess.Exec)
+		} else {
+			c.checkExecutable(path+".cmd.exec", name, config.Cmd.Exec)
+		}
+	}
+}
+
+func (c *checker) checkFan(path string, config configuration.FanConfig) {
+	name := fmt.Sprintf("fan %s", config.ID)
+
+	if config.HwMon != nil {
+		hwMonConfig := *config.HwMon
+		config.HwMon = &hwMonConfig
+		if err := hwmon.UpdateFanConfigFromHwMonControllers(c.controllers, &config); err != nil {
+			c.error(path+".hwMon", "%v", err)
+		} else {
+			c.checkHwMonFan(path+".hwMon", name, hwMonConfig)
+		}
+	}
+
+	if config.File != nil {
+		c.checkFile(path+".file.path", name, config.File.Path, true)
+		if len(config.File.RpmPath) > 0 {
+			c.checkFile(path+".file.rpmPath", name, config.File.RpmPath, false)
+		}
+	}
+
+	if config.Cmd != nil {
+		if config.Cmd.Coprocess != nil {
+			c.checkExecutable(path+".cmd.coprocess.exec", name, config.Cmd.Coprocess.Exec)
+		} else {
+			for _, command := range []struct {
+				key    string
+				config *configuration.ExecConfig
+			}{{"setPwm", config.Cmd.SetPwm}, {"getPwm", config.Cmd.GetPwm}, {"getRpm", config.Cmd.GetRpm}} {
+				if command.config != nil {
+					c.checkExecutable(path+".cmd."+command.key+".exec", name, command.config.Exec)
+				}
+			}
+		}
+	}
+}
+
+func (c *checker) checkHwMonFan(path string, name string, config configuration.HwMonFanConfig) {
+	c.checkFile(path, name, config.PwmPath, true)
+
+	if _, err := os.Stat(config.PwmEnablePath); os.IsNotExist(err) {
+		c.warning(path, "%s: %s does not exist, the control mode of the fan cannot be changed", name, config.PwmEnablePath)
+	} else {
+		c.checkFile(path, name, config.PwmEnablePath, true)
+	}
+
+	if _, err := os.Stat(config.RpmInputPath); os.IsNotExist(err) {
+		c.warning(path, "%s: %s does not exist, the rpm of the fan cannot be measured", name, config.RpmInputPath)
+	} else {
+		c.checkFile(path, name, config.RpmInputPath, false)
+	}
+}
+
+func (c *checker) checkFile(path string, name string, filePath string, write bool) {
+	if err := util.CheckFileAccess(filePath, write); err != nil {
+		c.error(path, "%s: cannot access %s: %v", name, filePath, err)
+	}
+}
+
+func (c *checker) checkExecutable(path string, name string, executable string) {
+	if len(executable) <= 0 {
+		// reported by the regular validation
+		return
+	}
+	if _, err := util.CheckFilePermissionsForExecution(executable); err != nil {
+		c.error(path, "%s: cannot execute %s: %v", name, executable, err)
+	}
+}
@@ -0,0 +1,121 @@
+package hardware
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/hwmon"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, filePath string, content string, perm os.FileMode) {
+	err := os.WriteFile(filePath, []byte(content), perm)
+	assert.NoError(t, err)
+}
+
+func createController(t *testing.T) *hwmon.HwMonController {
+	dir := t.TempDir()
+	writeFile(t, path.Join(dir, "temp1_input"), "42000", 0o444)
+	writeFile(t, path.Join(dir, "fan1_input"), "1200", 0o444)
+	writeFile(t, path.Join(dir, "pwm1"), "128", 0o644)
+	writeFile(t, path.Join(dir, "pwm1_enable"), "1", 0o644)
+	// pwm2 is read-only and has neither pwm2_enable nor fan2_input
+	writeFile(t, path.Join(dir, "pwm2"), "128", 0o444)
+
+	var fanList []fans.HwMonFan
+	for _, channel := range []int{1, 2} {
+		fanList = append(fanList, fans.HwMonFan{
+			Index: channel,
+			Config: configuration.FanConfig{
+				HwMon: &configuration.HwMonFanConfig{
+					Index:      channel,
+					RpmChannel: channel,
+					PwmChannel: channel,
+					SysfsPath:  dir,
+				},
+			},
+		})
+	}
+
+	return &hwmon.HwMonController{
+		Platform: "nct6798-isa-0290",
+		Path:     dir,
+		Fans:     fanList,
+		Sensors: map[int]*sensors.HwmonSensor{
+			1: {Index: 1, Label: "SYSTIN", Input: path.Join(dir, "temp1_input")},
+			2: {Index: 2, Label: "CPUTIN", Input: path.Join(dir, "temp2_input")},
+		},
+	}
+}
+
+func TestCheckValidConfig(t *testing.T) {
+	// GIVEN
+	controllers := []*hwmon.HwMonController{createController(t)}
+	config := configuration.Configuration{
+		Sensors: []configuration.SensorConfig{
+			{ID: "systin", HwMon: &configuration.HwMonSensorConfig{Platform: "nct6798", Label: "SYSTIN"}},
+		},
+		Fans: []configuration.FanConfig{
+			{ID: "cpu", HwMon: &configuration.HwMonFanConfig{Platform: "nct6798", Index: 1}},
+		},
+	}
+
+	// WHEN
+	problems := Check(&config, controllers)
+
+	// THEN
+	assert.Empty(t, problems)
+	assert.Empty(t, config.Fans[0].HwMon.PwmPath)
+	assert.Empty(t, config.Sensors[0].HwMon.TempInput)
+}
+
+func TestCheckReportsAllProblems(t *testing.T) {
+	// GIVEN
+	controller := createController(t)
+	controllers := []*hwmon.HwMonController{controller}
+	missingFile := path.Join(t.TempDir(), "missing")
+	config := configuration.Configuration{
+		Sensors: []configuration.SensorConfig{
+			{ID: "unknown", HwMon: &configuration.HwMonSensorConfig{Platform: "it8686", Index: 1}},
+			{ID: "cputin", HwMon: &configuration.HwMonSensorConfig{Platform: "nct6798", Index: 2}},
+			{ID: "file", File: &configuration.FileSensorConfig{Path: missingFile}},
+			{ID: "cmd", Cmd: &configuration.CmdSensorConfig{Exec: missingFile}},
+		},
+		Fans: []configuration.FanConfig{
+			{ID: "sys", HwMon: &configuration.HwMonFanConfig{Platform: "nct6798", Index: 2}},
+			{ID: "file", File: &configuration.FileFanConfig{Path: path.Join(controller.Path, "temp1_input")}},
+			{ID: "cmd", Cmd: &configuration.CmdFanConfig{
+				SetPwm: &configuration.ExecConfig{Exec: missingFile},
+				GetPwm: &configuration.ExecConfig{Exec: missingFile},
+			}},
+			{ID: "unknown", HwMon: &configuration.HwMonFanConfig{Platform: "nct6798", Label: "PUMP_FAN"}},
+		},
+	}
+
+	// WHEN
+	problems := Check(&config, controllers)
+
+	// THEN
+	var messages []string
+	for _, problem := range problems {
+		messages = append(messages, problem.String())
+	}
+	assert.Equal(t, []string{
+		"sensors[0].hwMon: couldn't find hwmon device with platform 'it8686' for sensor: unknown. Run 'fan2go detect' again and correct any mistake",
+		"sensors[1].hwMon: sensor cputin: cannot access " + path.Join(controller.Path, "temp2_input") + ": file not found",
+		"sensors[2].file.path: sensor file: cannot access " + missingFile + ": file not found",
+		"sensors[3].cmd.exec: sensor cmd: cannot execute " + missingFile + ": file not found",
+		"fans[0].hwMon: fan sys: cannot access " + path.Join(controller.Path, "pwm2") + ": file is read-only",
+		"fans[0].hwMon: fan sys: " + path.Join(controller.Path, "pwm2_enable") + " does not exist, the control mode of the fan cannot be changed",
+		"fans[0].hwMon: fan sys: " + path.Join(controller.Path, "fan2_input") + " does not exist, the rpm of the fan cannot be measured",
+		"fans[1].file.path: fan file: cannot access " + path.Join(controller.Path, "temp1_input") + ": file is read-only",
+		"fans[2].cmd.setPwm.exec: fan cmd: cannot execute " + missingFile + ": file not found",
+		"fans[2].cmd.getPwm.exec: fan cmd: cannot execute " + missingFile + ": file not found",
+		"fans[3].hwMon: fan unknown: no hwmon fan matched platform 'nct6798', label 'PUMP_FAN'. Run 'fan2go detect' again and correct any mistake",
+	}, messages)
+	assert.Equal(t, configuration.SeverityWarning, problems[5].Severity)
+}
@@ -2,25 +2,29 @@ package hwmon
 
 import (
 	"fmt"
-	"github.com/markusressel/fan2go/internal/ui"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/fans"
 	"github.com/markusressel/fan2go/internal/sensors"
-	"github.com/md14454/gosensors"
 )
 
+// bus types as defined by libsensors, which are used to compute the platform of a device
 const (
+	BusTypeUnknown = -1
+	BusTypeI2c     = 0
 	BusTypeIsa     = 1
 	BusTypePci     = 2
+	BusTypeSpi     = 3
 	BusTypeVirtual = 4
 	BusTypeAcpi    = 5
 	BusTypeHid     = 6
+	BusTypeMdio    = 7
 	BusTypeScsi    = 8
 )
 
@@ -30,6 +34,12 @@ type HwMonController struct {
 	Modalias string
 	Platform string
 	Path     string
+	// Driver is the name of the kernel driver of the device
+	Driver string
+	// BusAddress is the PCI address or USB port of the device, empty for other devices
+	BusAddress string
+	// Device is the target of the "device" symlink of the hwmon directory
+	Device string
 
 	// Fans (can be matched either by enumeration index or channel number)
 	Fans []fans.HwMonFan
@@ -37,44 +47,13 @@ type HwMonController struct {
 	Sensors map[int]*sensors.HwmonSensor
 }
 
+// GetChips returns all hwmon devices below the configured sysfs root
 func GetChips() []*HwMonController {
-	gosensors.Init()
-	defer gosensors.Cleanup()
-	chips := gosensors.GetDetectedChips()
-
-	var list []*HwMonController
-
-	for i := 0; i < len(chips); i++ {
-		chip := chips[i]
-
-		var identifier = computeIdentifier(chip)
-		dType := getDeviceType(chip.Path)
-		modalias := getDeviceModalias(chip.Path)
-		platform := findPlatform(chip.Path)
-		if len(platform) <= 0 {
-			platform = identifier
-		}
-
-		fanSlice := GetFans(chip)
-		sensorMap := GetTempSensors(chip)
-
-		if len(fanSlice) <= 0 && len(sensorMap) <= 0 {
-			continue
-		}
-
-		c := &HwMonController{
-			Name:     identifier,
-			DType:    dType,
-			Modalias: modalias,
-			Platform: platform,
-			Path:     chip.Path,
-			Fans:     fanSlice,
-			Sensors:  sensorMap,
-		}
-		list = append(list, c)
+	sysfsRoot := configuration.CurrentConfig.SysfsRoot
+	if len(sysfsRoot) <= 0 {
+		sysfsRoot = DefaultSysfsRoot
 	}
-
-	return list
+	return ReadChips(sysfsRoot)
 }
 
 // getDeviceName read the name of a device
@@ -92,147 +71,45 @@ func getDeviceModalias(devicePath string) string {
 	return strings.TrimSpace(string(content))
 }
 
-// getDeviceType read the type of a device
-func getDeviceType(devicePath string) string {
-	modaliasPath := path.Join(devicePath, "device", "type")
-	content, _ := os.ReadFile(modaliasPath)
-	return strings.TrimSpace(string(content))
-}
-
-func GetTempSensors(chip gosensors.Chip) map[int]*sensors.HwmonSensor {
-	result := map[int]*sensors.HwmonSensor{}
-
-	currentOutputIndex := 0
-	features := chip.GetFeatures()
-	for j := 0; j < len(features); j++ {
-		feature := features[j]
-
-		if feature.Type != gosensors.FeatureTypeTemp {
-			continue
-		}
-
-		subfeatures := feature.GetSubFeatures()
-
-		if containsSubFeature(subfeatures, gosensors.SubFeatureTypeTempInput) {
-			currentOutputIndex++
-
-			inputSubFeature := getSubFeature(subfeatures, gosensors.SubFeatureTypeTempInput)
-			sensorInputPath := path.Join(chip.Path, inputSubFeature.Name)
-
-			max := -1
-			if containsSubFeature(subfeatures, gosensors.SubFeatureTypeTempMax) {
-				maxSubFeature := getSubFeature(subfeatures, gosensors.SubFeatureTypeTempMax)
-				max = int(maxSubFeature.GetValue())
-			}
-
-			min := -1
-			if containsSubFeature(subfeatures, gosensors.SubFeatureTypeTempMin) {
-				minSubFeature := getSubFeature(subfeatures, gosensors.SubFeatureTypeTempMin)
-				min = int(minSubFeature.GetValue())
-			}
-
-			label := getLabel(chip.Path, feature.Name)
-
-			result[currentOutputIndex] = &sensors.HwmonSensor{
-				Label:     label,
-				Index:     currentOutputIndex,
-				Input:     sensorInputPath,
-				Max:       max,
-				Min:       min,
-				MovingAvg: inputSubFeature.GetValue(),
-			}
-		}
+// getDeviceDriver reads the name of the kernel driver of a device
+func getDeviceDriver(devicePath string) string {
+	target, err := os.Readlink(path.Join(devicePath, "device", "driver"))
+	if err != nil {
+		return ""
 	}
-
-	return result
+	return filepath.Base(target)
 }
 
-func GetFans(chip gosensors.Chip) []fans.HwMonFan {
-	var result = []fans.HwMonFan{}
-
-	features := chip.GetFeatures()
-	for j := 0; j < len(features); j++ {
-		feature := features[j]
-
-		if feature.Type != gosensors.FeatureTypeFan {
-			continue
-		}
-
-		subfeatures := feature.GetSubFeatures()
-
-		if containsSubFeature(subfeatures, gosensors.SubFeatureTypeFanInput) {
-			var channel int
-			_, err := fmt.Sscanf(feature.Name, "fan%d", &channel)
-			if err != nil {
-				ui.Warning("No channel found for '%s', ignoring.", feature.Name)
-				continue
-			}
-
-			rpmAverage := 0.0
-			inputSubFeature := getSubFeature(subfeatures, gosensors.SubFeatureTypeFanInput)
-			if inputSubFeature != nil {
-				rpmAverage = inputSubFeature.GetValue()
-			}
-
-			max := -1
-			if containsSubFeature(subfeatures, gosensors.SubFeatureTypeFanMax) {
-				maxSubFeature := getSubFeature(subfeatures, gosensors.SubFeatureTypeFanMax)
-				max = int(maxSubFeature.GetValue())
-			} else {
-				max = fans.MaxPwmValue
-			}
-
-			min := -1
-			if containsSubFeature(subfeatures, gosensors.SubFeatureTypeFanMin) {
-				minSubFeature := getSubFeature(subfeatures, gosensors.SubFeatureTypeFanMin)
-				min = int(minSubFeature.GetValue())
-			} else {
-				min = fans.MinPwmValue
-			}
-
-			label := getLabel(chip.Path, feature.Name)
-
-			fan := fans.HwMonFan{
-				Config: configuration.FanConfig{
-					ID:     label,
-					MinPwm: &min,
-					MaxPwm: &max,
-					HwMon: &configuration.HwMonFanConfig{
-						Index:      len(result) + 1,
-						RpmChannel: channel,
-						PwmChannel: channel,
-						SysfsPath:  chip.Path,
-					},
-				},
-				Label:        label,
-				Index:        len(result) + 1,
-				RpmMovingAvg: rpmAverage,
-			}
-			setFanConfigPaths(fan.Config.HwMon)
-
-			result = append(result, fan)
-		}
+// getDeviceTarget resolves the "device" symlink of a hwmon directory
+func getDeviceTarget(devicePath string) string {
+	target, err := filepath.EvalSymlinks(path.Join(devicePath, "device"))
+	if err != nil {
+		return ""
 	}
-
-	return result
+	return target
 }
 
-func getSubFeature(subfeatures []gosensors.SubFeature, input gosensors.SubFeatureType) *gosensors.SubFeature {
-	for _, a := range subfeatures {
-		if a.Type == input {
-			return &a
+var (
+	pciAddressRegex = regexp.MustCompile(`^[0-9a-f]{4,}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-9a-f]$`)
+	usbPortRegex    = regexp.MustCompile(`^\d+-\d+(\.\d+)*$`)
+)
+
+// findBusAddress returns the PCI address or USB port closest to the given device in the sysfs device tree
+func findBusAddress(device string) string {
+	parts := strings.Split(device, "/")
+	for idx := len(parts) - 1; idx >= 0; idx-- {
+		if pciAddressRegex.MatchString(parts[idx]) || usbPortRegex.MatchString(parts[idx]) {
+			return parts[idx]
 		}
 	}
-	return nil
+	return ""
 }
 
-func containsSubFeature(s []gosensors.SubFeature, e gosensors.SubFeatureType) bool {
-	for _, a := range s {
-		if a.Type == e {
-			return true
-		}
-	}
-	return false
+// getDeviceType read the type of a device
+func getDeviceType(devicePath string) string {
+	modaliasPath := path.Join(devicePath, "device", "type")
+	content, _ := os.ReadFile(modaliasPath)
+	return strings.TrimSpace(string(content))
 }
 
 // getLabel read the label of a feature
@@ -241,52 +118,190 @@ func getLabel(devicePath string, featureName string) string {
 	content, _ := os.ReadFile(labelPath)
 	label := string(content)
 	if len(label) <= 0 {
-		return path.Join(path.Base(devicePath), featureName)
+		return fallbackLabel(devicePath, featureName)
 	}
 	return strings.TrimSpace(label)
 }
 
-func computeIdentifier(chip gosensors.Chip) (name string) {
-	name = chip.Prefix
+// fallbackLabel is used for features without a label file, it contains the hwmon directory name
+// which is not stable across reboots and must not be used in a configuration
+func fallbackLabel(devicePath string, featureName string) string {
+	return path.Join(path.Base(devicePath), featureName)
+}
+
+// matchLabel checks if the label of a fan or sensor matches the label of a config,
+// which is either the exact label or a regex matching the whole label
+func matchLabel(pattern string, label string) (bool, error) {
+	if pattern == label {
+		return true, nil
+	}
+	return regexp.MatchString("^(?:"+pattern+")$", label)
+}
+
+// FanConfigLabel returns the label that can be used to select the given fan of this controller in a configuration,
+// or an empty string if the fan has no label or it doesn't identify the fan unambiguously
+func (c *HwMonController) FanConfigLabel(fan fans.HwMonFan) string {
+	if fan.Label == fallbackLabel(c.Path, fmt.Sprintf("fan%d", fan.Config.HwMon.RpmChannel)) {
+		return ""
+	}
+	var labels []string
+	for _, f := range c.Fans {
+		labels = append(labels, f.Label)
+	}
+	return uniqueLabel(fan.Label, labels)
+}
 
-	devicePath := chip.Path
-	if len(name) <= 0 {
-		name = getDeviceName(devicePath)
+// SensorConfigLabel returns the label that can be used to select the given sensor of this controller in a configuration,
+// or an empty string if the sensor has no label or it doesn't identify the sensor unambiguously
+func (c *HwMonController) SensorConfigLabel(sensor *sensors.HwmonSensor) string {
+	featureName := strings.TrimSuffix(filepath.Base(sensor.Input), "_input")
+	if sensor.Label == fallbackLabel(c.Path, featureName) {
+		return ""
+	}
+	var labels []string
+	for _, s := range c.Sensors {
+		labels = append(labels, s.Label)
 	}
+	return uniqueLabel(sensor.Label, labels)
+}
 
-	if len(name) <= 0 {
-		_, name = filepath.Split(devicePath)
+func uniqueLabel(label string, labels []string) string {
+	count := 0
+	for _, l := range labels {
+		if matched, _ := matchLabel(label, l); matched {
+			count++
+		}
 	}
+	if count != 1 {
+		return ""
+	}
+	return label
+}
 
+// computeIdentifier computes the platform of a device the same way as libsensors does,
+// f.ex. "nct6798-isa-0290" or "amdgpu-pci-0300"
+func computeIdentifier(name string, bus busInfo) string {
 	identifier := name
-	switch chip.Bus.Type {
+	switch bus.Type {
 	case BusTypeIsa:
-		identifier = fmt.Sprintf("%s-isa-%d%03x", name, chip.Bus.Nr, chip.Addr)
+		identifier = fmt.Sprintf("%s-isa-%d%03x", name, bus.Nr, bus.Addr)
 	case BusTypePci:
-		identifier = fmt.Sprintf("%s-pci-%d%03x", name, chip.Bus.Nr, chip.Addr)
+		identifier = fmt.Sprintf("%s-pci-%d%03x", name, bus.Nr, bus.Addr)
 	case BusTypeVirtual:
-		identifier = fmt.Sprintf("%s-virtual-%d", name, chip.Bus.Nr)
+		identifier = fmt.Sprintf("%s-virtual-%d", name, bus.Nr)
 	case BusTypeAcpi:
-		identifier = fmt.Sprintf("%s-acpi-%d", name, chip.Bus.Nr)
+		identifier = fmt.Sprintf("%s-acpi-%d", name, bus.Nr)
 	case BusTypeHid:
-		identifier = fmt.Sprintf("%s-hid-%d-%d", name, chip.Bus.Nr, chip.Addr)
+		identifier = fmt.Sprintf("%s-hid-%d-%d", name, bus.Nr, bus.Addr)
 	case BusTypeScsi:
-		identifier = fmt.Sprintf("%s-scsi-%d-%d", name, chip.Bus.Nr, chip.Addr)
+		identifier = fmt.Sprintf("%s-scsi-%d-%d", name, bus.Nr, bus.Addr)
 	}
 
 	return identifier
 }
 
-func findPlatform(devicePath string) string {
-	platformRegex := regexp.MustCompile(".*/platform/{}/.*")
-	return platformRegex.FindString(devicePath)
+// deviceSelector identifies a hwmon device, all non-empty fields have to match
+type deviceSelector struct {
+	Platform   string
+	Driver     string
+	Modalias   string
+	BusAddress string
+	Device     string
+}
+
+func (s deviceSelector) matches(controller *HwMonController) (bool, error) {
+	matched, err := regexp.MatchString("(?i)"+s.Platform, controller.Platform)
+	if err != nil {
+		return false, fmt.Errorf("failed to match platform regex %s against controller platform %s", s.Platform, controller.Platform)
+	}
+	if !matched {
+		return false, nil
+	}
+	if len(s.Driver) > 0 && !strings.EqualFold(s.Driver, controller.Driver) {
+		return false, nil
+	}
+	if len(s.Modalias) > 0 && s.Modalias != controller.Modalias {
+		return false, nil
+	}
+	if len(s.BusAddress) > 0 && !strings.EqualFold(s.BusAddress, controller.BusAddress) {
+		return false, nil
+	}
+	if len(s.Device) > 0 && s.Device != controller.Device {
+		return false, nil
+	}
+	return true, nil
+}
+
+// ambiguousDeviceError lists all devices matching the config of a fan or sensor
+func ambiguousDeviceError(kind string, id string, candidates []*HwMonController) error {
+	var lines []string
+	for _, c := range candidates {
+		lines = append(lines, fmt.Sprintf("  - %s (path: %s, driver: %s, busAddress: %s, device: %s)", c.Platform, c.Path, c.Driver, c.BusAddress, c.Device))
+	}
+	return fmt.Errorf("%s %s: hwmon config matches %d devices, use driver, modalias, busAddress or device to select one of:\n%s",
+		kind, id, len(candidates), strings.Join(lines, "\n"))
+}
+
+// ambiguousLabelError lists all fans or sensors of a single device matching the label of a config
+func ambiguousLabelError(kind string, id string, label string, labels []string) error {
+	return fmt.Errorf("%s %s: label '%s' matches %d inputs of the same device, use a more specific label: %s",
+		kind, id, label, len(labels), strings.Join(labels, ", "))
+}
+
+// describeFanCriteria lists the criteria used to find the hwmon fan of a fan config, f.ex. "platform 'nct6798', index 2"
+func describeFanCriteria(config *configuration.HwMonFanConfig) string {
+	var criteria []string
+	for _, criterion := range []struct {
+		name  string
+		value string
+	}{
+		{"platform", config.Platform},
+		{"driver", config.Driver},
+		{"modalias", config.Modalias},
+		{"busAddress", config.BusAddress},
+		{"device", config.Device},
+		{"label", config.Label},
+	} {
+		if len(criterion.value) > 0 {
+			criteria = append(criteria, fmt.Sprintf("%s '%s'", criterion.name, criterion.value))
+		}
+	}
+	if config.Index > 0 {
+		criteria = append(criteria, fmt.Sprintf("index %d", config.Index))
+	}
+	if config.RpmChannel > 0 {
+		criteria = append(criteria, fmt.Sprintf("rpmChannel %d", config.RpmChannel))
+	}
+	if len(criteria) <= 0 {
+		return "any criteria"
+	}
+	return strings.Join(criteria, ", ")
+}
+
+func isSingleController(candidates []*HwMonController) bool {
+	for _, c := range candidates {
+		if c != candidates[0] {
+			return false
+		}
+	}
+	return true
 }
 
 func UpdateFanConfigFromHwMonControllers(controllers []*HwMonController, config *configuration.FanConfig) error {
+	selector := deviceSelector{
+		Platform:   config.HwMon.Platform,
+		Driver:     config.HwMon.Driver,
+		Modalias:   config.HwMon.Modalias,
+		BusAddress: config.HwMon.BusAddress,
+		Device:     config.HwMon.Device,
+	}
+
+	var candidates []*HwMonController
+	var matches []fans.HwMonFan
 	for _, controller := range controllers {
-		matched, err := regexp.MatchString("(?i)"+config.HwMon.Platform, controller.Platform)
+		matched, err := selector.matches(controller)
 		if err != nil {
-			return fmt.Errorf("failed to match platform regex of %s (%s) against controller platform %s", config.ID, config.HwMon.Platform, controller.Platform)
+			return fmt.Errorf("fan %s: %v", config.ID, err)
 		}
 		if !matched {
 			continue
@@ -299,17 +314,113 @@ func UpdateFanConfigFromHwMonControllers(controllers []*HwMonController, config
 			if config.HwMon.RpmChannel > 0 && controllerConfig.RpmChannel != config.HwMon.RpmChannel {
 				continue
 			}
-			config.HwMon.Index = controllerConfig.Index
-			config.HwMon.RpmChannel = controllerConfig.RpmChannel
-			config.HwMon.SysfsPath = controllerConfig.SysfsPath
-			if config.HwMon.PwmChannel == 0 {
-				config.HwMon.PwmChannel = controllerConfig.PwmChannel
+			if len(config.HwMon.Label) > 0 {
+				matched, err := matchLabel(config.HwMon.Label, fan.Label)
+				if err != nil {
+					return fmt.Errorf("fan %s: invalid label regex: %v", config.ID, err)
+				}
+				if !matched {
+					continue
+				}
+			}
+			candidates = append(candidates, controller)
+			matches = append(matches, fan)
+		}
+	}
+
+	if len(matches) <= 0 {
+		return fmt.Errorf("fan %s: no hwmon fan matched %s. Run 'fan2go detect' again and correct any mistake", config.ID, describeFanCriteria(config.HwMon))
+	}
+	if len(matches) > 1 {
+		if isSingleController(candidates) {
+			var labels []string
+			for _, fan := range matches {
+				labels = append(labels, fan.Label)
+			}
+			return ambiguousLabelError("fan", config.ID, config.HwMon.Label, labels)
+		}
+		return ambiguousDeviceError("fan", config.ID, candidates)
+	}
+
+	controllerConfig := matches[0].Config.HwMon
+	config.HwMon.Index = controllerConfig.Index
+	config.HwMon.RpmChannel = controllerConfig.RpmChannel
+	config.HwMon.SysfsPath = controllerConfig.SysfsPath
+	if config.HwMon.PwmChannel == 0 {
+		config.HwMon.PwmChannel = controllerConfig.PwmChannel
+	}
+	setFanConfigPaths(config.HwMon)
+	return nil
+}
+
+// UpdateSensorConfigFromHwMonControllers resolves the temperature input of the given hwmon sensor config
+func UpdateSensorConfigFromHwMonControllers(controllers []*HwMonController, config *configuration.SensorConfig) error {
+	selector := deviceSelector{
+		Platform:   config.HwMon.Platform,
+		Driver:     config.HwMon.Driver,
+		Modalias:   config.HwMon.Modalias,
+		BusAddress: config.HwMon.BusAddress,
+		Device:     config.HwMon.Device,
+	}
+
+	var candidates []*HwMonController
+	var matches []*sensors.HwmonSensor
+	for _, controller := range controllers {
+		matched, err := selector.matches(controller)
+		if err != nil {
+			return fmt.Errorf("sensor %s: %v", config.ID, err)
+		}
+		if !matched {
+			continue
+		}
+		if len(config.HwMon.Label) <= 0 {
+			if sensor, ok := controller.Sensors[config.HwMon.Index]; ok {
+				candidates = append(candidates, controller)
+				matches = append(matches, sensor)
+			}
+			continue
+		}
+
+		indices := make([]int, 0, len(controller.Sensors))
+		for index := range controller.Sensors {
+			indices = append(indices, index)
+		}
+		sort.Ints(indices)
+		for _, index := range indices {
+			sensor := controller.Sensors[index]
+			matched, err := matchLabel(config.HwMon.Label, sensor.Label)
+			if err != nil {
+				return fmt.Errorf("sensor %s: invalid label regex: %v", config.ID, err)
+			}
+			if matched {
+				candidates = append(candidates, controller)
+				matches = append(matches, sensor)
 			}
-			setFanConfigPaths(config.HwMon)
-			return nil
 		}
 	}
-	return fmt.Errorf("no hwmon fan matched fan config: %+v", config)
+
+	if len(matches) <= 0 {
+		if len(config.HwMon.Label) > 0 {
+			return fmt.Errorf("couldn't find hwmon sensor with label '%s' on platform '%s' for sensor: %s. Run 'fan2go detect' again and correct any mistake", config.HwMon.Label, config.HwMon.Platform, config.ID)
+		}
+		return fmt.Errorf("couldn't find hwmon device with platform '%s' for sensor: %s. Run 'fan2go detect' again and correct any mistake", config.HwMon.Platform, config.ID)
+	}
+	if len(matches) > 1 {
+		if isSingleController(candidates) {
+			var labels []string
+			for _, sensor := range matches {
+				labels = append(labels, sensor.Label)
+			}
+			return ambiguousLabelError("sensor", config.ID, config.HwMon.Label, labels)
+		}
+		return ambiguousDeviceError("sensor", config.ID, candidates)
+	}
+	if len(matches[0].Input) <= 0 {
+		return fmt.Errorf("unable to find temp input for sensor %s", config.ID)
+	}
+
+	config.HwMon.TempInput = matches[0].Input
+	return nil
 }
 
 func setFanConfigPaths(config *configuration.HwMonFanConfig) {
@@ -6,25 +6,21 @@ import (
 
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/fans"
-	"github.com/md14454/gosensors"
+	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestComputeIdentifierIsa(t *testing.T) {
 	// GIVEN
-	c := gosensors.Chip{
-		Prefix: "ucsi_source_psy_USBC000:002",
-		Addr:   0x0f1,
-		Bus: gosensors.Bus{
-			Type: BusTypeIsa,
-			Nr:   1,
-		},
-		Path: "/sys/class/hwmon/hwmon7",
+	bus := busInfo{
+		Type: BusTypeIsa,
+		Nr:   1,
+		Addr: 0x0f1,
 	}
 	expected := "ucsi_source_psy_USBC000:002-isa-10f1"
 
 	// WHEN
-	result := computeIdentifier(c)
+	result := computeIdentifier("ucsi_source_psy_USBC000:002", bus)
 
 	// THEN
 	assert.Equal(t, expected, result)
@@ -32,19 +28,15 @@ func TestComputeIdentifierIsa(t *testing.T) {
 
 func TestComputeIdentifierPci(t *testing.T) {
 	// GIVEN
-	c := gosensors.Chip{
-		Prefix: "nvme",
-		Addr:   0x5,
-		Bus: gosensors.Bus{
-			Type: BusTypePci,
-			Nr:   1,
-		},
-		Path: "/sys/class/hwmon/hwmon4",
+	bus := busInfo{
+		Type: BusTypePci,
+		Nr:   1,
+		Addr: 0x5,
 	}
 	expected := "nvme-pci-1005"
 
 	// WHEN
-	result := computeIdentifier(c)
+	result := computeIdentifier("nvme", bus)
 
 	// THEN
 	assert.Equal(t, expected, result)
@@ -52,32 +44,190 @@ func TestComputeIdentifierPci(t *testing.T) {
 
 func TestComputeIdentifierAcpi(t *testing.T) {
 	// GIVEN
-	c := gosensors.Chip{
-		Prefix: "nvme",
-		Bus: gosensors.Bus{
-			Type: BusTypeAcpi,
-			Nr:   1,
-		},
-		Path: "/sys/class/hwmon/hwmon4",
+	bus := busInfo{
+		Type: BusTypeAcpi,
+		Nr:   1,
 	}
-	expected := fmt.Sprintf("%s-acpi-%d", c.Prefix, c.Bus.Nr)
+	expected := fmt.Sprintf("%s-acpi-%d", "nvme", bus.Nr)
 
 	// WHEN
-	result := computeIdentifier(c)
+	result := computeIdentifier("nvme", bus)
 
 	// THEN
 	assert.Equal(t, expected, result)
 }
 
-func TestFindPlatform(t *testing.T) {
+func TestFindBusAddress(t *testing.T) {
+	var tests = []struct {
+		device string
+		want   string
+	}{
+		{"/sys/devices/pci0000:00/0000:00:0e.0/pci10000:e0/10000:e0:06.0/10000:e1:00.0/nvme/nvme0", "10000:e1:00.0"},
+		{"/sys/devices/pci0000:00/0000:00:03.1/0000:0b:00.0/0000:0c:00.0/0000:0d:00.0", "0000:0d:00.0"},
+		{"/sys/devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/0003:1E71:2006.0003", "1-2"},
+		{"/sys/devices/platform/nct6775.656", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		// WHEN
+		result := findBusAddress(tt.device)
+
+		// THEN
+		assert.Equal(t, tt.want, result, tt.device)
+	}
+}
+
+func createGpuControllers() []*HwMonController {
+	var controllers []*HwMonController
+	for idx, busAddress := range []string{"0000:03:00.0", "0000:0c:00.0"} {
+		controllers = append(controllers, &HwMonController{
+			Platform:   "amdgpu-pci-0300",
+			Path:       fmt.Sprintf("/sys/class/hwmon/hwmon%d", idx),
+			Driver:     "amdgpu",
+			BusAddress: busAddress,
+			Device:     "/sys/devices/pci0000:00/" + busAddress,
+			Fans: []fans.HwMonFan{
+				{
+					Config: configuration.FanConfig{
+						HwMon: &configuration.HwMonFanConfig{
+							Index:      1,
+							RpmChannel: 1,
+							PwmChannel: 1,
+							SysfsPath:  fmt.Sprintf("/sys/class/hwmon/hwmon%d", idx),
+						},
+					},
+				},
+			},
+			Sensors: map[int]*sensors.HwmonSensor{
+				1: {Index: 1, Input: fmt.Sprintf("/sys/class/hwmon/hwmon%d/temp1_input", idx)},
+			},
+		})
+	}
+	return controllers
+}
+
+func TestUpdateFanConfigFromHwMonControllers_Ambiguous(t *testing.T) {
+	// GIVEN
+	controllers := createGpuControllers()
+	config := configuration.FanConfig{
+		ID: "gpu",
+		HwMon: &configuration.HwMonFanConfig{
+			Platform: "amdgpu",
+			Index:    1,
+		},
+	}
+
+	// WHEN
+	err := UpdateFanConfigFromHwMonControllers(controllers, &config)
+
+	// THEN
+	assert.ErrorContains(t, err, "fan gpu: hwmon config matches 2 devices")
+	assert.ErrorContains(t, err, "busAddress: 0000:03:00.0")
+	assert.ErrorContains(t, err, "busAddress: 0000:0c:00.0")
+}
+
+func TestUpdateFanConfigFromHwMonControllers_Selectors(t *testing.T) {
+	var tests = []struct {
+		tn       string
+		config   configuration.HwMonFanConfig
+		wantPath string
+		wantErr  string
+	}{{
+		tn:       "bus address",
+		config:   configuration.HwMonFanConfig{BusAddress: "0000:0C:00.0", Index: 1},
+		wantPath: "/sys/class/hwmon/hwmon1",
+	}, {
+		tn:       "device",
+		config:   configuration.HwMonFanConfig{Device: "/sys/devices/pci0000:00/0000:03:00.0", Index: 1},
+		wantPath: "/sys/class/hwmon/hwmon0",
+	}, {
+		tn:      "driver only",
+		config:  configuration.HwMonFanConfig{Driver: "amdgpu", Index: 1},
+		wantErr: "matches 2 devices",
+	}, {
+		tn:      "unknown driver",
+		config:  configuration.HwMonFanConfig{Driver: "nouveau", Index: 1},
+		wantErr: "fan gpu: no hwmon fan matched driver 'nouveau', index 1",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.tn, func(t *testing.T) {
+			// GIVEN
+			controllers := createGpuControllers()
+			config := configuration.FanConfig{
+				ID:    "gpu",
+				HwMon: &tt.config,
+			}
+
+			// WHEN
+			err := UpdateFanConfigFromHwMonControllers(controllers, &config)
+
+			// THEN
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantPath, config.HwMon.SysfsPath)
+			}
+		})
+	}
+}
+
+func TestUpdateSensorConfigFromHwMonControllers(t *testing.T) {
+	// GIVEN
+	controllers := createGpuControllers()
+	config := configuration.SensorConfig{
+		ID: "gpu",
+		HwMon: &configuration.HwMonSensorConfig{
+			Platform:   "amdgpu",
+			BusAddress: "0000:0c:00.0",
+			Index:      1,
+		},
+	}
+
+	// WHEN
+	err := UpdateSensorConfigFromHwMonControllers(controllers, &config)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "/sys/class/hwmon/hwmon1/temp1_input", config.HwMon.TempInput)
+}
+
+func TestUpdateSensorConfigFromHwMonControllers_Ambiguous(t *testing.T) {
+	// GIVEN
+	controllers := createGpuControllers()
+	config := configuration.SensorConfig{
+		ID: "gpu",
+		HwMon: &configuration.HwMonSensorConfig{
+			Platform: "amdgpu",
+			Index:    1,
+		},
+	}
+
+	// WHEN
+	err := UpdateSensorConfigFromHwMonControllers(controllers, &config)
+
+	// THEN
+	assert.ErrorContains(t, err, "sensor gpu: hwmon config matches 2 devices")
+}
+
+func TestUpdateSensorConfigFromHwMonControllers_NotFound(t *testing.T) {
 	// GIVEN
-	devicePath := "/sys/devices/pci0000:00/0000:00:0e.0/pci10000:e0/10000:e0:06.0/10000:e1:00.0/nvme/nvme0/hwmon3"
+	controllers := createGpuControllers()
+	config := configuration.SensorConfig{
+		ID: "cpu",
+		HwMon: &configuration.HwMonSensorConfig{
+			Platform: "k10temp",
+			Index:    1,
+		},
+	}
 
 	// WHEN
-	platform := findPlatform(devicePath)
+	err := UpdateSensorConfigFromHwMonControllers(controllers, &config)
 
 	// THEN
-	assert.Equal(t, "", platform)
+	assert.EqualError(t, err, "couldn't find hwmon device with platform 'k10temp' for sensor: cpu. Run 'fan2go detect' again and correct any mistake")
 }
 
 func TestUpdateFanConfigFromHwMonControllers(t *testing.T) {
@@ -160,7 +310,7 @@ func TestUpdateFanConfigFromHwMonControllers(t *testing.T) {
 		configConfig: configuration.HwMonFanConfig{
 			Index: 1,
 		},
-		wantErr: "no hwmon fan matched fan config",
+		wantErr: "no hwmon fan matched platform 'platform', index 1",
 	}, {
 		tn: "no matching index",
 		hwMonConfigs: []configuration.HwMonFanConfig{
@@ -171,7 +321,7 @@ func TestUpdateFanConfigFromHwMonControllers(t *testing.T) {
 		configConfig: configuration.HwMonFanConfig{
 			Index: 1,
 		},
-		wantErr: "no hwmon fan matched fan config",
+		wantErr: "no hwmon fan matched platform 'platform', index 1",
 	}, {
 		tn: "no matching platform",
 		hwMonConfigs: []configuration.HwMonFanConfig{
@@ -183,7 +333,7 @@ func TestUpdateFanConfigFromHwMonControllers(t *testing.T) {
 		configConfig: configuration.HwMonFanConfig{
 			Index: 1,
 		},
-		wantErr: "no hwmon fan matched fan config",
+		wantErr: "no hwmon fan matched platform 'platform', index 1",
 	}}
 
 	for _, tt := range tests {
@@ -231,3 +381,143 @@ func TestUpdateFanConfigFromHwMonControllers(t *testing.T) {
 		})
 	}
 }
+
+func createSuperIoController() *HwMonController {
+	controllerPath := "/sys/class/hwmon/hwmon2"
+	var fanList []fans.HwMonFan
+	for idx, label := range []string{"CPU_FAN", "SYS_FAN1", "SYS_FAN2", "hwmon2/fan4"} {
+		fanList = append(fanList, fans.HwMonFan{
+			Label: label,
+			Index: idx + 1,
+			Config: configuration.FanConfig{
+				HwMon: &configuration.HwMonFanConfig{
+					Index:      idx + 1,
+					RpmChannel: idx + 1,
+					PwmChannel: idx + 1,
+					SysfsPath:  controllerPath,
+				},
+			},
+		})
+	}
+	return &HwMonController{
+		Platform: "nct6798-isa-0290",
+		Path:     controllerPath,
+		Fans:     fanList,
+		Sensors: map[int]*sensors.HwmonSensor{
+			1: {Index: 1, Label: "SYSTIN", Input: controllerPath + "/temp1_input"},
+			2: {Index: 2, Label: "CPUTIN", Input: controllerPath + "/temp2_input"},
+			3: {Index: 3, Label: "AUXTIN0", Input: controllerPath + "/temp3_input"},
+			4: {Index: 4, Label: "AUXTIN0", Input: controllerPath + "/temp5_input"},
+		},
+	}
+}
+
+func TestUpdateFanConfigFromHwMonControllers_Label(t *testing.T) {
+	var tests = []struct {
+		tn          string
+		label       string
+		wantChannel int
+		wantErr     string
+	}{{
+		tn:          "exact",
+		label:       "SYS_FAN1",
+		wantChannel: 2,
+	}, {
+		tn:          "regex",
+		label:       "(?i)cpu.*",
+		wantChannel: 1,
+	}, {
+		tn:      "partial label",
+		label:   "SYS_FAN",
+		wantErr: "fan sys: no hwmon fan matched platform 'nct6798', label 'SYS_FAN'",
+	}, {
+		tn:      "ambiguous",
+		label:   "SYS_FAN\\d",
+		wantErr: "fan sys: label 'SYS_FAN\\d' matches 2 inputs of the same device, use a more specific label: SYS_FAN1, SYS_FAN2",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.tn, func(t *testing.T) {
+			// GIVEN
+			controllers := []*HwMonController{createSuperIoController()}
+			config := configuration.FanConfig{
+				ID: "sys",
+				HwMon: &configuration.HwMonFanConfig{
+					Platform: "nct6798",
+					Label:    tt.label,
+				},
+			}
+
+			// WHEN
+			err := UpdateFanConfigFromHwMonControllers(controllers, &config)
+
+			// THEN
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantChannel, config.HwMon.RpmChannel)
+				assert.Equal(t, tt.wantChannel, config.HwMon.PwmChannel)
+				assert.Equal(t, fmt.Sprintf("/sys/class/hwmon/hwmon2/pwm%d", tt.wantChannel), config.HwMon.PwmPath)
+			}
+		})
+	}
+}
+
+func TestUpdateSensorConfigFromHwMonControllers_Label(t *testing.T) {
+	// GIVEN
+	controllers := []*HwMonController{createSuperIoController()}
+	config := configuration.SensorConfig{
+		ID: "cpu",
+		HwMon: &configuration.HwMonSensorConfig{
+			Platform: "nct6798",
+			Label:    "CPUTIN",
+		},
+	}
+
+	// WHEN
+	err := UpdateSensorConfigFromHwMonControllers(controllers, &config)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "/sys/class/hwmon/hwmon2/temp2_input", config.HwMon.TempInput)
+}
+
+func TestUpdateSensorConfigFromHwMonControllers_LabelNotFound(t *testing.T) {
+	// GIVEN
+	controllers := []*HwMonController{createSuperIoController()}
+	config := configuration.SensorConfig{
+		ID: "cpu",
+		HwMon: &configuration.HwMonSensorConfig{
+			Platform: "nct6798",
+			Label:    "Tctl",
+		},
+	}
+
+	// WHEN
+	err := UpdateSensorConfigFromHwMonControllers(controllers, &config)
+
+	// THEN
+	assert.EqualError(t, err, "couldn't find hwmon sensor with label 'Tctl' on platform 'nct6798' for sensor: cpu. Run 'fan2go detect' again and correct any mistake")
+}
+
+func TestConfigLabel(t *testing.T) {
+	// GIVEN
+	controller := createSuperIoController()
+
+	// WHEN
+	var fanLabels []string
+	for _, fan := range controller.Fans {
+		fanLabels = append(fanLabels, controller.FanConfigLabel(fan))
+	}
+	var sensorLabels []string
+	for index := 1; index <= len(controller.Sensors); index++ {
+		sensorLabels = append(sensorLabels, controller.SensorConfigLabel(controller.Sensors[index]))
+	}
+
+	// THEN
+	// the last fan has no label file
+	assert.Equal(t, []string{"CPU_FAN", "SYS_FAN1", "SYS_FAN2", ""}, fanLabels)
+	// the label of the last two sensors is not unique
+	assert.Equal(t, []string{"SYSTIN", "CPUTIN", "", ""}, sensorLabels)
+}
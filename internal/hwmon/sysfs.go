@@ -0,0 +1,261 @@
+package hwmon
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+// DefaultSysfsRoot is the directory sysfs is usually mounted at
+const DefaultSysfsRoot = util.DefaultSysfsRoot
+
+var (
+	hwmonDirRegex    = regexp.MustCompile(`^hwmon(\d+)$`)
+	tempInputRegex   = regexp.MustCompile(`^temp(\d+)_input$`)
+	fanInputRegex    = regexp.MustCompile(`^fan(\d+)_input$`)
+	pwmRegex         = regexp.MustCompile(`^pwm(\d+)$`)
+	platformDevRegex = regexp.MustCompile(`^[a-z0-9_]+\.(\d+)$`)
+)
+
+// busInfo identifies the bus of a device, like libsensors does
+type busInfo struct {
+	Type int
+	Nr   int
+	Addr int
+}
+
+// ReadChips walks the hwmon class directory below the given sysfs root
+// and returns all devices providing fans or temperature inputs
+func ReadChips(sysfsRoot string) []*HwMonController {
+	classPath := path.Join(sysfsRoot, "class", "hwmon")
+	entries, err := os.ReadDir(classPath)
+	if err != nil {
+		ui.Warning("Unable to read hwmon devices from %s: %v", classPath, err)
+		return nil
+	}
+
+	var numbers []int
+	for _, entry := range entries {
+		match := hwmonDirRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		number, _ := strconv.Atoi(match[1])
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	var list []*HwMonController
+	for _, number := range numbers {
+		hwmonPath := path.Join(classPath, fmt.Sprintf("hwmon%d", number))
+		controller := readChip(hwmonPath)
+		if controller == nil {
+			continue
+		}
+		list = append(list, controller)
+	}
+	return list
+}
+
+// readChip reads a single hwmon device, returns nil if the device has neither fans nor temperature inputs
+func readChip(hwmonPath string) *HwMonController {
+	// attributes of old drivers are located in the device directory
+	attributePath := hwmonPath
+	name := getDeviceName(hwmonPath)
+	if len(name) <= 0 {
+		attributePath = path.Join(hwmonPath, "device")
+		name = getDeviceName(attributePath)
+	}
+	if len(name) <= 0 {
+		return nil
+	}
+
+	fanSlice := readFans(attributePath)
+	sensorMap := readTempSensors(attributePath)
+	if len(fanSlice) <= 0 && len(sensorMap) <= 0 {
+		return nil
+	}
+
+	device := getDeviceTarget(hwmonPath)
+	identifier := computeIdentifier(name, readBusInfo(hwmonPath, device))
+
+	return &HwMonController{
+		Name:       identifier,
+		DType:      getDeviceType(hwmonPath),
+		Modalias:   getDeviceModalias(hwmonPath),
+		Platform:   identifier,
+		Path:       attributePath,
+		Driver:     getDeviceDriver(hwmonPath),
+		BusAddress: findBusAddress(device),
+		Device:     device,
+		Fans:       fanSlice,
+		Sensors:    sensorMap,
+	}
+}
+
+// readBusInfo determines the bus of a device from its subsystem and device name, like libsensors does
+func readBusInfo(hwmonPath string, device string) busInfo {
+	if len(device) <= 0 {
+		return busInfo{Type: BusTypeVirtual}
+	}
+
+	subsystem, err := os.Readlink(path.Join(hwmonPath, "device", "subsystem"))
+	if err != nil {
+		return busInfo{Type: BusTypeUnknown}
+	}
+	deviceName := filepath.Base(device)
+
+	var bus busInfo
+	switch filepath.Base(subsystem) {
+	case "i2c":
+		bus.Type = BusTypeI2c
+		_, err = fmt.Sscanf(deviceName, "%d-%x", &bus.Nr, &bus.Addr)
+	case "pci":
+		var domain, pciBus, slot, function int
+		bus.Type = BusTypePci
+		_, err = fmt.Sscanf(deviceName, "%x:%x:%x.%x", &domain, &pciBus, &slot, &function)
+		bus.Addr = (domain << 16) + (pciBus << 8) + (slot << 3) + function
+	case "platform", "of_platform":
+		// platform drivers replaced the old ISA drivers, the address is part of the device name, f.ex. "nct6775.656"
+		bus.Type = BusTypeIsa
+		if match := platformDevRegex.FindStringSubmatch(deviceName); match != nil {
+			bus.Addr, _ = strconv.Atoi(match[1])
+		}
+	case "acpi":
+		bus.Type = BusTypeAcpi
+	case "hid":
+		var vendor, product int
+		bus.Type = BusTypeHid
+		_, err = fmt.Sscanf(deviceName, "%x:%x:%x.%x", &bus.Nr, &vendor, &product, &bus.Addr)
+	case "scsi":
+		var channel, id int
+		bus.Type = BusTypeScsi
+		_, err = fmt.Sscanf(deviceName, "%d:%d:%d:%x", &bus.Nr, &channel, &id, &bus.Addr)
+	default:
+		bus.Type = BusTypeUnknown
+	}
+	if err != nil {
+		ui.Debug("Unable to parse bus of device %s: %v", device, err)
+	}
+	return bus
+}
+
+// readTempSensors reads all temperature inputs of a device, indexed by their position starting at 1
+func readTempSensors(devicePath string) map[int]*sensors.HwmonSensor {
+	result := map[int]*sensors.HwmonSensor{}
+
+	for idx, number := range findAttributeNumbers(devicePath, tempInputRegex) {
+		featureName := fmt.Sprintf("temp%d", number)
+		inputPath := path.Join(devicePath, featureName+"_input")
+		value, err := util.ReadIntFromFile(inputPath)
+		if err != nil {
+			value = 0
+		}
+
+		result[idx+1] = &sensors.HwmonSensor{
+			Label:     getLabel(devicePath, featureName),
+			Index:     idx + 1,
+			Input:     inputPath,
+			Max:       readTempLimit(devicePath, featureName+"_max"),
+			Min:       readTempLimit(devicePath, featureName+"_min"),
+			Crit:      readTempLimit(devicePath, featureName+"_crit"),
+			MovingAvg: float64(value),
+		}
+	}
+
+	return result
+}
+
+// readTempLimit reads a temperature limit in degrees, returns -1 if the device doesn't provide it
+func readTempLimit(devicePath string, attribute string) int {
+	value, err := util.ReadIntFromFile(path.Join(devicePath, attribute))
+	if err != nil {
+		return -1
+	}
+	return value / 1000
+}
+
+// readFans reads all fan channels with an rpm input, followed by all pwm outputs without an rpm input
+func readFans(devicePath string) []fans.HwMonFan {
+	var result = []fans.HwMonFan{}
+
+	channels := findAttributeNumbers(devicePath, fanInputRegex)
+	for _, channel := range findAttributeNumbers(devicePath, pwmRegex) {
+		if _, err := os.Stat(path.Join(devicePath, fmt.Sprintf("fan%d_input", channel))); err != nil {
+			// a fan without tachometer
+			channels = append(channels, channel)
+		}
+	}
+
+	for _, channel := range channels {
+		featureName := fmt.Sprintf("fan%d", channel)
+
+		rpm, err := util.ReadIntFromFile(path.Join(devicePath, featureName+"_input"))
+		if err != nil {
+			rpm = 0
+		}
+
+		max, err := util.ReadIntFromFile(path.Join(devicePath, featureName+"_max"))
+		if err != nil {
+			max = fans.MaxPwmValue
+		}
+		min, err := util.ReadIntFromFile(path.Join(devicePath, featureName+"_min"))
+		if err != nil {
+			min = fans.MinPwmValue
+		}
+
+		label := getLabel(devicePath, featureName)
+
+		fan := fans.HwMonFan{
+			Config: configuration.FanConfig{
+				ID:     label,
+				MinPwm: &min,
+				MaxPwm: &max,
+				HwMon: &configuration.HwMonFanConfig{
+					Index:      len(result) + 1,
+					RpmChannel: channel,
+					PwmChannel: channel,
+					SysfsPath:  devicePath,
+				},
+			},
+			Label:        label,
+			Index:        len(result) + 1,
+			RpmMovingAvg: float64(rpm),
+		}
+		setFanConfigPaths(fan.Config.HwMon)
+
+		result = append(result, fan)
+	}
+
+	return result
+}
+
+// findAttributeNumbers returns the sorted numbers of all attributes of a device matching the given regex
+func findAttributeNumbers(devicePath string, attributeRegex *regexp.Regexp) []int {
+	entries, err := os.ReadDir(devicePath)
+	if err != nil {
+		return nil
+	}
+
+	var result []int
+	for _, entry := range entries {
+		match := attributeRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		number, _ := strconv.Atoi(match[1])
+		result = append(result, number)
+	}
+	sort.Ints(result)
+	return result
+}
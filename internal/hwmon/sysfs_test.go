@@ -0,0 +1,249 @@
+package hwmon
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixtureDevice describes a device of a fake sysfs tree
+type fixtureDevice struct {
+	// hwmon directory name, f.ex. "hwmon2"
+	hwmon string
+	// path of the device below /sys/devices, empty for virtual devices
+	device    string
+	subsystem string
+	driver    string
+	files     map[string]string
+}
+
+// createSysfsFixture creates a fake sysfs tree containing the given hwmon devices and returns its root
+func createSysfsFixture(t *testing.T, devices ...fixtureDevice) string {
+	root := t.TempDir()
+	classPath := path.Join(root, "class", "hwmon")
+	assert.NoError(t, os.MkdirAll(classPath, 0755))
+
+	for _, d := range devices {
+		hwmonPath := path.Join(root, "devices", "virtual", "hwmon", d.hwmon)
+		if len(d.device) > 0 {
+			devicePath := path.Join(root, "devices", d.device)
+			hwmonPath = path.Join(devicePath, "hwmon", d.hwmon)
+			assert.NoError(t, os.MkdirAll(hwmonPath, 0755))
+			assert.NoError(t, os.Symlink(devicePath, path.Join(hwmonPath, "device")))
+
+			subsystemPath := path.Join(root, "bus", d.subsystem)
+			driverPath := path.Join(subsystemPath, "drivers", d.driver)
+			assert.NoError(t, os.MkdirAll(driverPath, 0755))
+			assert.NoError(t, os.Symlink(subsystemPath, path.Join(devicePath, "subsystem")))
+			assert.NoError(t, os.Symlink(driverPath, path.Join(devicePath, "driver")))
+		}
+		assert.NoError(t, os.MkdirAll(hwmonPath, 0755))
+		assert.NoError(t, os.Symlink(hwmonPath, path.Join(classPath, d.hwmon)))
+
+		for name, content := range d.files {
+			assert.NoError(t, os.WriteFile(path.Join(hwmonPath, name), []byte(content+"\n"), 0644))
+		}
+	}
+	return root
+}
+
+func createDefaultSysfsFixture(t *testing.T) string {
+	return createSysfsFixture(t,
+		fixtureDevice{
+			hwmon:     "hwmon10",
+			device:    "platform/nct6775.656",
+			subsystem: "platform",
+			driver:    "nct6775",
+			files: map[string]string{
+				"name":         "nct6798",
+				"temp1_input":  "30000",
+				"temp1_label":  "SYSTIN",
+				"temp1_max":    "80000",
+				"temp1_crit":   "100000",
+				"temp2_input":  "45000",
+				"temp2_label":  "CPUTIN",
+				"temp10_input": "20000",
+				"fan1_input":   "850",
+				"fan1_label":   "CPU_FAN",
+				"fan1_min":     "200",
+				"pwm1":         "128",
+				"pwm1_enable":  "1",
+				"fan2_input":   "0",
+				"pwm3":         "255",
+			},
+		},
+		fixtureDevice{
+			hwmon:     "hwmon2",
+			device:    "pci0000:00/0000:00:01.1/0000:03:00.0",
+			subsystem: "pci",
+			driver:    "amdgpu",
+			files: map[string]string{
+				"name":        "amdgpu",
+				"temp1_input": "52000",
+				"temp1_label": "edge",
+				"fan1_input":  "1200",
+				"pwm1":        "100",
+			},
+		},
+		fixtureDevice{
+			hwmon: "hwmon0",
+			files: map[string]string{
+				"name":        "acpitz",
+				"temp1_input": "27800",
+			},
+		},
+		fixtureDevice{
+			hwmon:     "hwmon3",
+			device:    "pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/0003:1B1C:0C10.0005",
+			subsystem: "hid",
+			driver:    "corsair-cpro",
+			files: map[string]string{
+				"name":       "corsaircpro",
+				"fan1_input": "700",
+				"pwm1":       "90",
+			},
+		},
+		fixtureDevice{
+			// no inputs at all
+			hwmon: "hwmon1",
+			files: map[string]string{
+				"name": "BAT0",
+			},
+		},
+	)
+}
+
+func TestReadChips(t *testing.T) {
+	// GIVEN
+	root := createDefaultSysfsFixture(t)
+
+	// WHEN
+	controllers := ReadChips(root)
+
+	// THEN
+	var platforms []string
+	for _, c := range controllers {
+		platforms = append(platforms, c.Platform)
+	}
+	// ordered by hwmon number
+	assert.Equal(t, []string{"acpitz-virtual-0", "amdgpu-pci-0300", "corsaircpro-hid-3-5", "nct6798-isa-0290"}, platforms)
+
+	gpu := controllers[1]
+	assert.Equal(t, "amdgpu", gpu.Driver)
+	assert.Equal(t, "0000:03:00.0", gpu.BusAddress)
+	assert.Equal(t, path.Join(root, "class", "hwmon", "hwmon2"), gpu.Path)
+
+	hub := controllers[2]
+	assert.Equal(t, "corsair-cpro", hub.Driver)
+	assert.Equal(t, "1-2", hub.BusAddress)
+
+	virtual := controllers[0]
+	assert.Equal(t, "", virtual.Driver)
+	assert.Equal(t, "", virtual.Device)
+	assert.Equal(t, "hwmon0/temp1", virtual.Sensors[1].Label)
+}
+
+func TestReadChips_Sensors(t *testing.T) {
+	// GIVEN
+	root := createDefaultSysfsFixture(t)
+
+	// WHEN
+	controller := ReadChips(root)[3]
+
+	// THEN
+	assert.Len(t, controller.Sensors, 3)
+
+	systin := controller.Sensors[1]
+	assert.Equal(t, "SYSTIN", systin.Label)
+	assert.Equal(t, path.Join(controller.Path, "temp1_input"), systin.Input)
+	assert.Equal(t, 30000.0, systin.MovingAvg)
+	assert.Equal(t, 80, systin.Max)
+	assert.Equal(t, -1, systin.Min)
+	assert.Equal(t, 100, systin.Crit)
+
+	assert.Equal(t, "CPUTIN", controller.Sensors[2].Label)
+	// indices are assigned in order of the input numbers
+	assert.Equal(t, path.Join(controller.Path, "temp10_input"), controller.Sensors[3].Input)
+
+	value, err := controller.Sensors[2].GetValue()
+	assert.NoError(t, err)
+	assert.Equal(t, 45000.0, value)
+}
+
+func TestReadChips_Fans(t *testing.T) {
+	// GIVEN
+	root := createDefaultSysfsFixture(t)
+
+	// WHEN
+	controller := ReadChips(root)[3]
+
+	// THEN
+	assert.Len(t, controller.Fans, 3)
+
+	cpuFan := controller.Fans[0]
+	assert.Equal(t, "CPU_FAN", cpuFan.Label)
+	assert.Equal(t, 850.0, cpuFan.RpmMovingAvg)
+	assert.Equal(t, 200, *cpuFan.Config.MinPwm)
+	assert.Equal(t, configuration.HwMonFanConfig{
+		Index:         1,
+		RpmChannel:    1,
+		PwmChannel:    1,
+		SysfsPath:     controller.Path,
+		RpmInputPath:  path.Join(controller.Path, "fan1_input"),
+		PwmPath:       path.Join(controller.Path, "pwm1"),
+		PwmEnablePath: path.Join(controller.Path, "pwm1_enable"),
+	}, *cpuFan.Config.HwMon)
+	pwm, err := cpuFan.GetPwm()
+	assert.NoError(t, err)
+	assert.Equal(t, 128, pwm)
+
+	// fan without pwm output
+	assert.Equal(t, 2, controller.Fans[1].Config.HwMon.RpmChannel)
+	assert.Equal(t, "hwmon10/fan2", controller.Fans[1].Label)
+
+	// pwm output without tachometer, listed after all fans with an rpm input
+	pwmOnly := controller.Fans[2]
+	assert.Equal(t, 3, pwmOnly.Index)
+	assert.Equal(t, 3, pwmOnly.Config.HwMon.PwmChannel)
+	assert.Equal(t, 0.0, pwmOnly.RpmMovingAvg)
+}
+
+func TestReadChips_ResolveConfig(t *testing.T) {
+	// GIVEN
+	controllers := ReadChips(createDefaultSysfsFixture(t))
+	fanConfig := configuration.FanConfig{
+		ID: "cpu",
+		HwMon: &configuration.HwMonFanConfig{
+			Platform: "nct6798",
+			Label:    "CPU_FAN",
+		},
+	}
+	sensorConfig := configuration.SensorConfig{
+		ID: "gpu",
+		HwMon: &configuration.HwMonSensorConfig{
+			Platform: "amdgpu",
+			Label:    "edge",
+		},
+	}
+
+	// WHEN
+	fanErr := UpdateFanConfigFromHwMonControllers(controllers, &fanConfig)
+	sensorErr := UpdateSensorConfigFromHwMonControllers(controllers, &sensorConfig)
+
+	// THEN
+	assert.NoError(t, fanErr)
+	assert.Equal(t, path.Join(controllers[3].Path, "pwm1"), fanConfig.HwMon.PwmPath)
+	assert.NoError(t, sensorErr)
+	assert.Equal(t, path.Join(controllers[1].Path, "temp1_input"), sensorConfig.HwMon.TempInput)
+}
+
+func TestReadChips_MissingRoot(t *testing.T) {
+	// WHEN
+	controllers := ReadChips(path.Join(t.TempDir(), "missing"))
+
+	// THEN
+	assert.Empty(t, controllers)
+}
@@ -0,0 +1,200 @@
+package hwmon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+// Watcher periodically checks the devices of hwmon fans and sensors.
+// Devices that disappear (f.ex. USB fan hubs or GPUs after a driver reset) are marked as absent,
+// and re-bound as soon as a device matching their config reappears, which usually has a new hwmon number.
+//
+// sysfs doesn't emit inotify events when devices are added or removed, so the bound paths are polled instead.
+type Watcher struct {
+	pollingRate time.Duration
+	// scan returns the currently available hwmon devices
+	scan func() []*HwMonController
+
+	fans    []*watchedFan
+	sensors []*watchedSensor
+}
+
+type watchedFan struct {
+	// the hwmon config of the fan as configured by the user, before it was resolved
+	selector configuration.HwMonFanConfig
+	fan      *fans.HwMonFan
+	// the device the fan was bound to
+	device string
+	absent bool
+}
+
+type watchedSensor struct {
+	// the hwmon config of the sensor as configured by the user, before it was resolved
+	selector configuration.HwMonSensorConfig
+	sensor   *sensors.HwmonSensor
+	// the device the sensor was bound to
+	device string
+	absent bool
+}
+
+func NewWatcher(pollingRate time.Duration, scan func() []*HwMonController) *Watcher {
+	return &Watcher{
+		pollingRate: pollingRate,
+		scan:        scan,
+	}
+}
+
+// AddFan watches the device of the given fan, the selector is the unresolved hwmon config of the fan
+func (w *Watcher) AddFan(selector configuration.HwMonFanConfig, fan *fans.HwMonFan) {
+	w.fans = append(w.fans, &watchedFan{
+		selector: selector,
+		fan:      fan,
+		device:   getDeviceTarget(fan.GetPaths().SysfsPath),
+	})
+}
+
+// AddSensor watches the device of the given sensor, the selector is the unresolved hwmon config of the sensor
+func (w *Watcher) AddSensor(selector configuration.HwMonSensorConfig, sensor *sensors.HwmonSensor) {
+	w.sensors = append(w.sensors, &watchedSensor{
+		selector: selector,
+		sensor:   sensor,
+		device:   getDeviceTarget(filepath.Dir(sensor.GetInput())),
+	})
+}
+
+func (w *Watcher) Run(ctx context.Context) error {
+	if len(w.fans) <= 0 && len(w.sensors) <= 0 {
+		return nil
+	}
+
+	tick := time.NewTicker(w.pollingRate)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			ui.Info("Stopping hwmon watcher...")
+			return nil
+		case <-tick.C:
+			w.Check()
+		}
+	}
+}
+
+// Check marks fans and sensors whose device disappeared as absent and tries to re-bind all absent ones
+func (w *Watcher) Check() {
+	missing := false
+	for _, f := range w.fans {
+		paths := f.fan.GetPaths()
+		present := isPresent(paths.SysfsPath, paths.PwmPath, f.device)
+		if present != !f.absent {
+			f.setAbsent(!present)
+		}
+		missing = missing || f.absent
+	}
+	for _, s := range w.sensors {
+		input := s.sensor.GetInput()
+		present := isPresent(filepath.Dir(input), input, s.device)
+		if present != !s.absent {
+			s.setAbsent(!present)
+		}
+		missing = missing || s.absent
+	}
+	if !missing {
+		return
+	}
+
+	controllers := w.scan()
+	for _, f := range w.fans {
+		if f.absent {
+			f.rebind(controllers)
+		}
+	}
+	for _, s := range w.sensors {
+		if s.absent {
+			s.rebind(controllers)
+		}
+	}
+}
+
+func (f *watchedFan) setAbsent(absent bool) {
+	f.absent = absent
+	fans.SetAbsent(f.fan.GetId(), absent)
+	if absent {
+		ui.Warning("Device of fan %s disappeared (%s)", f.fan.GetId(), f.fan.GetPaths().SysfsPath)
+	} else {
+		ui.Info("Device of fan %s is available again (%s)", f.fan.GetId(), f.fan.GetPaths().SysfsPath)
+	}
+}
+
+func (f *watchedFan) rebind(controllers []*HwMonController) {
+	selector := f.selector
+	config := configuration.FanConfig{
+		ID:    f.fan.GetId(),
+		HwMon: &selector,
+	}
+	err := UpdateFanConfigFromHwMonControllers(controllers, &config)
+	if err != nil {
+		ui.Debug("Unable to re-bind fan %s: %v", config.ID, err)
+		return
+	}
+	device := getDeviceTarget(config.HwMon.SysfsPath)
+	if !isPresent(config.HwMon.SysfsPath, config.HwMon.PwmPath, "") {
+		return
+	}
+
+	f.fan.Rebind(*config.HwMon)
+	f.device = device
+	f.setAbsent(false)
+}
+
+func (s *watchedSensor) setAbsent(absent bool) {
+	s.absent = absent
+	sensors.SetAbsent(s.sensor.GetId(), absent)
+	if absent {
+		ui.Warning("Device of sensor %s disappeared (%s)", s.sensor.GetId(), s.sensor.GetInput())
+	} else {
+		ui.Info("Device of sensor %s is available again (%s)", s.sensor.GetId(), s.sensor.GetInput())
+	}
+}
+
+func (s *watchedSensor) rebind(controllers []*HwMonController) {
+	selector := s.selector
+	config := configuration.SensorConfig{
+		ID:    s.sensor.GetId(),
+		HwMon: &selector,
+	}
+	err := UpdateSensorConfigFromHwMonControllers(controllers, &config)
+	if err != nil {
+		ui.Debug("Unable to re-bind sensor %s: %v", config.ID, err)
+		return
+	}
+	input := config.HwMon.TempInput
+	if !isPresent(filepath.Dir(input), input, "") {
+		return
+	}
+
+	if value, err := util.ReadIntFromFile(input); err == nil {
+		// the moving average is outdated
+		s.sensor.SetMovingAvg(float64(value))
+	}
+	s.sensor.Rebind(input)
+	s.device = getDeviceTarget(filepath.Dir(input))
+	s.setAbsent(false)
+}
+
+// isPresent checks if the given file exists and the hwmon directory still belongs to the given device,
+// since hwmon numbers are reused when devices are removed and added
+func isPresent(hwmonPath string, file string, device string) bool {
+	if _, err := os.Stat(file); err != nil {
+		return false
+	}
+	return len(device) <= 0 || getDeviceTarget(hwmonPath) == device
+}
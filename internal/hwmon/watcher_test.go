@@ -0,0 +1,162 @@
+package hwmon
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/stretchr/testify/assert"
+)
+
+// createHwMonDir creates a fake hwmon directory containing a single fan and temperature input
+func createHwMonDir(t *testing.T) string {
+	dir := t.TempDir()
+	for file, value := range map[string]string{
+		"pwm1":        "128",
+		"pwm1_enable": "1",
+		"fan1_input":  "900",
+		"temp1_input": "42000",
+	} {
+		err := os.WriteFile(path.Join(dir, file), []byte(value), 0644)
+		assert.NoError(t, err)
+	}
+	return dir
+}
+
+func createHubController(dir string) *HwMonController {
+	fanConfig := &configuration.HwMonFanConfig{
+		Index:      1,
+		RpmChannel: 1,
+		PwmChannel: 1,
+		SysfsPath:  dir,
+	}
+	setFanConfigPaths(fanConfig)
+	return &HwMonController{
+		Platform: "corsaircpro-hid-3-1",
+		Path:     dir,
+		Fans: []fans.HwMonFan{
+			{Label: "fan1", Index: 1, Config: configuration.FanConfig{HwMon: fanConfig}},
+		},
+		Sensors: map[int]*sensors.HwmonSensor{
+			1: {Label: "temp1", Index: 1, Input: path.Join(dir, "temp1_input")},
+		},
+	}
+}
+
+func TestWatcher_RebindFan(t *testing.T) {
+	// GIVEN
+	oldDir := createHwMonDir(t)
+	newDir := createHwMonDir(t)
+	var scanned []*HwMonController
+
+	selector := configuration.HwMonFanConfig{Platform: "corsaircpro", RpmChannel: 1}
+	config := configuration.FanConfig{ID: "hub_fan", HwMon: &configuration.HwMonFanConfig{}}
+	*config.HwMon = selector
+	err := UpdateFanConfigFromHwMonControllers([]*HwMonController{createHubController(oldDir)}, &config)
+	assert.NoError(t, err)
+	fan := &fans.HwMonFan{Config: config}
+
+	watcher := NewWatcher(0, func() []*HwMonController { return scanned })
+	watcher.AddFan(selector, fan)
+	defer fans.SetAbsent(fan.GetId(), false)
+
+	// WHEN
+	err = os.RemoveAll(oldDir)
+	assert.NoError(t, err)
+	watcher.Check()
+
+	// THEN
+	assert.True(t, fans.IsAbsent(fan.GetId()))
+
+	// WHEN
+	scanned = []*HwMonController{createHubController(newDir)}
+	watcher.Check()
+
+	// THEN
+	assert.False(t, fans.IsAbsent(fan.GetId()))
+	assert.Equal(t, path.Join(newDir, "pwm1"), fan.GetPaths().PwmPath)
+	pwm, err := fan.GetPwm()
+	assert.NoError(t, err)
+	assert.Equal(t, 128, pwm)
+}
+
+func TestWatcher_RebindSensor(t *testing.T) {
+	// GIVEN
+	oldDir := createHwMonDir(t)
+	newDir := createHwMonDir(t)
+	var scanned []*HwMonController
+
+	selector := configuration.HwMonSensorConfig{Platform: "corsaircpro", Label: "temp1"}
+	config := configuration.SensorConfig{ID: "hub_temp", HwMon: &configuration.HwMonSensorConfig{}}
+	*config.HwMon = selector
+	err := UpdateSensorConfigFromHwMonControllers([]*HwMonController{createHubController(oldDir)}, &config)
+	assert.NoError(t, err)
+	sensor := &sensors.HwmonSensor{Input: config.HwMon.TempInput, Config: config}
+
+	watcher := NewWatcher(0, func() []*HwMonController { return scanned })
+	watcher.AddSensor(selector, sensor)
+	defer sensors.SetAbsent(sensor.GetId(), false)
+
+	// WHEN
+	err = os.RemoveAll(oldDir)
+	assert.NoError(t, err)
+	watcher.Check()
+
+	// THEN
+	assert.True(t, sensors.IsAbsent(sensor.GetId()))
+	_, err = sensor.GetValue()
+	assert.ErrorIs(t, err, sensors.ErrSensorAbsent)
+
+	// WHEN
+	scanned = []*HwMonController{createHubController(newDir)}
+	watcher.Check()
+
+	// THEN
+	assert.False(t, sensors.IsAbsent(sensor.GetId()))
+	assert.Equal(t, path.Join(newDir, "temp1_input"), sensor.GetInput())
+	value, err := sensor.GetValue()
+	assert.NoError(t, err)
+	assert.Equal(t, 42000.0, value)
+	assert.Equal(t, 42000.0, sensor.GetMovingAvg())
+}
+
+func TestWatcher_StillAbsent(t *testing.T) {
+	// GIVEN
+	dir := createHwMonDir(t)
+	scans := 0
+
+	selector := configuration.HwMonFanConfig{Platform: "corsaircpro", RpmChannel: 1}
+	config := configuration.FanConfig{ID: "hub_fan", HwMon: &configuration.HwMonFanConfig{}}
+	*config.HwMon = selector
+	err := UpdateFanConfigFromHwMonControllers([]*HwMonController{createHubController(dir)}, &config)
+	assert.NoError(t, err)
+	fan := &fans.HwMonFan{Config: config}
+
+	watcher := NewWatcher(0, func() []*HwMonController {
+		scans++
+		return nil
+	})
+	watcher.AddFan(selector, fan)
+	defer fans.SetAbsent(fan.GetId(), false)
+
+	// WHEN
+	watcher.Check()
+
+	// THEN
+	// nothing is rescanned as long as all devices are present
+	assert.Equal(t, 0, scans)
+
+	// WHEN
+	err = os.RemoveAll(dir)
+	assert.NoError(t, err)
+	watcher.Check()
+	watcher.Check()
+
+	// THEN
+	assert.Equal(t, 2, scans)
+	assert.True(t, fans.IsAbsent(fan.GetId()))
+	assert.Equal(t, path.Join(dir, "pwm1"), fan.GetPaths().PwmPath)
+}
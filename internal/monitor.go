@@ -2,6 +2,7 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/ui"
@@ -34,8 +35,20 @@ func (s sensorMonitor) Run(ctx context.Context) error {
 			return nil
 		case <-tick.C:
 			err := updateSensor(s.sensor)
+			if errors.Is(err, sensors.ErrSensorAbsent) {
+				// the moving average is outdated, mark the sensor as absent so curves using it fail safe.
+				// Missing hwmon devices have been marked and reported by the hwmon watcher already.
+				if !sensors.IsAbsent(s.sensor.GetId()) {
+					ui.Warning("Sensor %s is unavailable: %v", s.sensor.GetId(), err)
+					sensors.SetAbsent(s.sensor.GetId(), true)
+				}
+				continue
+			}
 			if err != nil {
 				ui.Warning("Error updating sensor: %v", err)
+			} else if sensors.IsAbsent(s.sensor.GetId()) {
+				ui.Info("Sensor %s is available again", s.sensor.GetId())
+				sensors.SetAbsent(s.sensor.GetId(), false)
 			}
 		}
 	}
@@ -0,0 +1,126 @@
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/ui"
+)
+
+const (
+	// time to wait for the broker to acknowledge an operation
+	operationTimeout = 5 * time.Second
+	// time between two attempts to connect to the broker
+	connectRetryInterval = 10 * time.Second
+
+	payloadOnline  = "online"
+	payloadOffline = "offline"
+)
+
+// MessageHandler is called for every message received on a subscribed topic
+type MessageHandler func(topic string, payload []byte)
+
+// Client is the subset of MQTT client functionality used by fan2go
+type Client interface {
+	Connect(ctx context.Context) error
+	Disconnect()
+	Publish(topic string, retained bool, payload []byte) error
+	Subscribe(topic string, handler MessageHandler) error
+}
+
+type pahoClient struct {
+	client paho.Client
+
+	// subscriptions are restored after a reconnect
+	subscriptions map[string]MessageHandler
+	mu            sync.Mutex
+}
+
+// NewClient creates a new client connecting to the broker of the given configuration.
+// The availability topic of fan2go is set to "offline" by the broker, if the connection is lost.
+func NewClient(config configuration.MqttConfig) Client {
+	c := &pahoClient{
+		subscriptions: map[string]MessageHandler{},
+	}
+
+	options := paho.NewClientOptions()
+	options.AddBroker(config.Broker)
+	options.SetClientID(config.ClientId)
+	options.SetUsername(config.Username)
+	options.SetPassword(config.Password)
+	options.SetAutoReconnect(true)
+	options.SetConnectRetry(true)
+	options.SetConnectRetryInterval(connectRetryInterval)
+	options.SetWill(availabilityTopic(config.TopicPrefix), payloadOffline, 1, true)
+	options.SetOnConnectHandler(func(client paho.Client) {
+		c.resubscribe()
+	})
+	options.SetConnectionLostHandler(func(client paho.Client, err error) {
+		ui.Warning("Lost connection to MQTT broker: %v", err)
+	})
+
+	c.client = paho.NewClient(options)
+	return c
+}
+
+// Connect waits until the connection to the broker is established.
+// Failed attempts are retried until ctx is done, so an unreachable broker doesn't stop fan2go.
+func (c *pahoClient) Connect(ctx context.Context) error {
+	token := c.client.Connect()
+	ticker := time.NewTicker(connectRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-token.Done():
+			return token.Error()
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			ui.Warning("MQTT broker is not reachable yet, retrying...")
+		}
+	}
+}
+
+func (c *pahoClient) Disconnect() {
+	c.client.Disconnect(uint(operationTimeout.Milliseconds()))
+}
+
+func (c *pahoClient) Publish(topic string, retained bool, payload []byte) error {
+	return waitFor(c.client.Publish(topic, 1, retained, payload))
+}
+
+func (c *pahoClient) Subscribe(topic string, handler MessageHandler) error {
+	c.mu.Lock()
+	c.subscriptions[topic] = handler
+	c.mu.Unlock()
+
+	return c.subscribe(topic, handler)
+}
+
+func (c *pahoClient) subscribe(topic string, handler MessageHandler) error {
+	return waitFor(c.client.Subscribe(topic, 1, func(client paho.Client, message paho.Message) {
+		handler(message.Topic(), message.Payload())
+	}))
+}
+
+func (c *pahoClient) resubscribe() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for topic, handler := range c.subscriptions {
+		if err := c.subscribe(topic, handler); err != nil {
+			ui.Warning("Unable to resubscribe to MQTT topic %s: %v", topic, err)
+		}
+	}
+}
+
+func waitFor(token paho.Token) error {
+	if !token.WaitTimeout(operationTimeout) {
+		return fmt.Errorf("timeout waiting for MQTT broker")
+	}
+	return token.Error()
+}
@@ -0,0 +1,131 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+)
+
+var invalidObjectIdCharacters = regexp.MustCompile("[^a-zA-Z0-9_-]")
+
+type HomeAssistantDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+}
+
+// HomeAssistantEntity is a Home Assistant MQTT discovery payload,
+// see https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+type HomeAssistantEntity struct {
+	Name              string              `json:"name"`
+	UniqueId          string              `json:"unique_id"`
+	StateTopic        string              `json:"state_topic"`
+	ValueTemplate     string              `json:"value_template"`
+	AvailabilityTopic string              `json:"availability_topic"`
+	UnitOfMeasurement string              `json:"unit_of_measurement,omitempty"`
+	StateClass        string              `json:"state_class,omitempty"`
+	CommandTopic      string              `json:"command_topic,omitempty"`
+	Min               *int                `json:"min,omitempty"`
+	Max               *int                `json:"max,omitempty"`
+	Options           []string            `json:"options,omitempty"`
+	Device            HomeAssistantDevice `json:"device"`
+}
+
+// publishDiscovery publishes Home Assistant discovery payloads for all sensors, curves and fans
+func (s *Service) publishDiscovery() {
+	for component, entities := range s.createDiscoveryEntities() {
+		for _, entity := range entities {
+			topic := fmt.Sprintf("%s/%s/%s/%s/config", s.config.HomeAssistant.DiscoveryPrefix, component, s.nodeId(), entity.UniqueId)
+			payload, err := json.Marshal(entity)
+			if err != nil {
+				continue
+			}
+			s.publish(topic, true, payload)
+		}
+	}
+}
+
+// createDiscoveryEntities creates the discovery payloads of all entities, grouped by Home Assistant component
+func (s *Service) createDiscoveryEntities() map[string][]HomeAssistantEntity {
+	result := map[string][]HomeAssistantEntity{}
+
+	for id := range sensors.SensorMap {
+		result["sensor"] = append(result["sensor"], s.createEntity("sensor", id, "", "value", fmt.Sprintf("Sensor %s", id)))
+	}
+
+	speedCurves := curves.SpeedCurves()
+	for id := range speedCurves {
+		result["sensor"] = append(result["sensor"], s.createEntity("curve", id, "", "value", fmt.Sprintf("Curve %s", id)))
+	}
+
+	curveIds := make([]string, 0, len(speedCurves))
+	for id := range speedCurves {
+		curveIds = append(curveIds, id)
+	}
+	sort.Strings(curveIds)
+
+	for id, fan := range fans.FanMap {
+		result["sensor"] = append(result["sensor"], s.createEntity("fan", id, "pwm", "pwm", fmt.Sprintf("Fan %s PWM", id)))
+		if fan.Supports(fans.FeatureRpmSensor) {
+			rpm := s.createEntity("fan", id, "rpm", "rpm", fmt.Sprintf("Fan %s RPM", id))
+			rpm.UnitOfMeasurement = "RPM"
+			result["sensor"] = append(result["sensor"], rpm)
+		}
+		mode := s.createEntity("fan", id, "mode", "mode", fmt.Sprintf("Fan %s Mode", id))
+		mode.StateClass = ""
+		result["sensor"] = append(result["sensor"], mode)
+
+		if _, controlled := s.controllers[id]; !controlled {
+			continue
+		}
+
+		minPwm := fans.MinPwmValue
+		maxPwm := fans.MaxPwmValue
+		pinned := s.createEntity("fan", id, "pinned_pwm", "pinned", fmt.Sprintf("Fan %s Pinned PWM", id))
+		pinned.StateClass = ""
+		pinned.ValueTemplate = "{{ value_json.pinned if value_json.pinned is not none else value_json.pwm }}"
+		pinned.CommandTopic = s.fanPwmCommandTopic(id)
+		pinned.Min = &minPwm
+		pinned.Max = &maxPwm
+		result["number"] = append(result["number"], pinned)
+
+		curve := s.createEntity("fan", id, "curve", "curve", fmt.Sprintf("Fan %s Curve", id))
+		curve.StateClass = ""
+		curve.CommandTopic = s.fanCurveCommandTopic(id)
+		curve.Options = curveIds
+		result["select"] = append(result["select"], curve)
+	}
+
+	return result
+}
+
+func (s *Service) createEntity(kind string, id string, suffix string, field string, name string) HomeAssistantEntity {
+	uniqueId := fmt.Sprintf("%s_%s_%s", s.nodeId(), kind, id)
+	if len(suffix) > 0 {
+		uniqueId = fmt.Sprintf("%s_%s", uniqueId, suffix)
+	}
+
+	return HomeAssistantEntity{
+		Name:              name,
+		UniqueId:          invalidObjectIdCharacters.ReplaceAllString(uniqueId, "_"),
+		StateTopic:        s.stateTopic(kind, id),
+		ValueTemplate:     fmt.Sprintf("{{ value_json.%s }}", field),
+		AvailabilityTopic: availabilityTopic(s.config.TopicPrefix),
+		StateClass:        "measurement",
+		Device: HomeAssistantDevice{
+			Identifiers:  []string{s.nodeId()},
+			Name:         s.config.ClientId,
+			Manufacturer: "fan2go",
+		},
+	}
+}
+
+// nodeId returns the id used to group all entities of this fan2go instance
+func (s *Service) nodeId() string {
+	return invalidObjectIdCharacters.ReplaceAllString(s.config.ClientId, "_")
+}
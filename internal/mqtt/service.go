@@ -0,0 +1,274 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/ui"
+)
+
+// payload of a pwm command which resumes curve based control of a fan
+const commandAuto = "auto"
+
+type SensorState struct {
+	// Value is the moving average of the sensor, which is what curves are computed from
+	Value float64 `json:"value"`
+}
+
+type CurveState struct {
+	Value int `json:"value"`
+}
+
+type FanState struct {
+	Pwm    int    `json:"pwm"`
+	Rpm    *int   `json:"rpm,omitempty"`
+	Mode   string `json:"mode"`
+	Curve  string `json:"curve"`
+	Pinned *int   `json:"pinned"`
+}
+
+// Service publishes the state of all sensors, curves and fans to an MQTT broker
+// and handles commands received from it
+type Service struct {
+	config      configuration.MqttConfig
+	client      Client
+	controllers map[string]controller.FanController
+}
+
+func NewService(config configuration.MqttConfig, client Client, fanControllers []controller.FanController) *Service {
+	controllers := map[string]controller.FanController{}
+	for _, c := range fanControllers {
+		controllers[c.GetFanId()] = c
+	}
+
+	return &Service{
+		config:      config,
+		client:      client,
+		controllers: controllers,
+	}
+}
+
+// Run connects to the broker and publishes the current state in the configured interval until ctx is done
+func (s *Service) Run(ctx context.Context) error {
+	ui.Info("Connecting to MQTT broker at %s...", s.config.Broker)
+	defer s.client.Disconnect()
+	err := s.client.Connect(ctx)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to connect to MQTT broker: %v", err)
+	}
+
+	// failed subscriptions are retried on reconnect
+	if err = s.subscribe(); err != nil {
+		ui.Warning("%v", err)
+	}
+
+	if s.config.HomeAssistant.Enabled {
+		s.publishDiscovery()
+	}
+	s.publish(availabilityTopic(s.config.TopicPrefix), true, []byte(payloadOnline))
+	s.PublishState()
+
+	tick := time.NewTicker(s.config.PublishInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			ui.Info("Stopping MQTT service...")
+			s.publish(availabilityTopic(s.config.TopicPrefix), true, []byte(payloadOffline))
+			return nil
+		case <-tick.C:
+			s.PublishState()
+		}
+	}
+}
+
+func (s *Service) subscribe() error {
+	for _, sensor := range sensors.SensorMap {
+		mqttSensor, ok := sensor.(*sensors.MqttSensor)
+		if !ok {
+			continue
+		}
+		err := s.client.Subscribe(mqttSensor.GetTopic(), func(topic string, payload []byte) {
+			if err := mqttSensor.HandleMessage(payload); err != nil {
+				ui.Warning("%v", err)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("unable to subscribe to topic of sensor %s: %v", mqttSensor.GetId(), err)
+		}
+	}
+
+	for fanId := range s.controllers {
+		id := fanId
+		err := s.client.Subscribe(s.fanPwmCommandTopic(id), func(topic string, payload []byte) {
+			s.handlePwmCommand(id, string(payload))
+		})
+		if err != nil {
+			return fmt.Errorf("unable to subscribe to pwm command topic of fan %s: %v", id, err)
+		}
+		err = s.client.Subscribe(s.fanCurveCommandTopic(id), func(topic string, payload []byte) {
+			s.handleCurveCommand(id, string(payload))
+		})
+		if err != nil {
+			return fmt.Errorf("unable to subscribe to curve command topic of fan %s: %v", id, err)
+		}
+	}
+
+	return nil
+}
+
+// PublishState publishes the current state of all sensors, curves and fans.
+// Sensors, curves and fans are not read here, since reading them can change their state,
+// instead the values computed by the sensor monitors and fan controllers are published.
+func (s *Service) PublishState() {
+	for id, sensor := range sensors.SensorMap {
+		s.publishJson(s.stateTopic("sensor", id), SensorState{
+			Value: sensor.GetMovingAvg(),
+		})
+	}
+
+	curveValues := map[string]int{}
+	for _, c := range s.controllers {
+		if value, ok := c.GetLastCurveValue(); ok {
+			curveValues[c.GetCurveId()] = value
+		}
+	}
+	for id, value := range curveValues {
+		s.publishJson(s.stateTopic("curve", id), CurveState{
+			Value: value,
+		})
+	}
+
+	for id, fan := range fans.FanMap {
+		s.publishJson(s.stateTopic("fan", id), s.getFanState(fan))
+	}
+}
+
+func (s *Service) getFanState(fan fans.Fan) FanState {
+	state := FanState{
+		Curve: fan.GetCurveId(),
+		Mode:  "unknown",
+	}
+
+	c, ok := s.controllers[fan.GetId()]
+	if !ok {
+		return state
+	}
+
+	// the fan itself is not read here, since it is controlled concurrently,
+	// instead the state tracked by its controller is published
+	controllerState := c.GetState()
+	if controllerState.Pwm != nil {
+		state.Pwm = *controllerState.Pwm
+	}
+	if controllerState.RpmAvg != nil {
+		rpm := int(*controllerState.RpmAvg)
+		state.Rpm = &rpm
+	}
+	if controllerState.Mode != nil {
+		state.Mode = controlModeName(*controllerState.Mode)
+	}
+	state.Curve = c.GetCurveId()
+	state.Pinned = c.GetPwmOverride()
+
+	return state
+}
+
+// handlePwmCommand pins the fan to the given pwm value, or resumes curve based control on "auto"
+func (s *Service) handlePwmCommand(fanId string, payload string) {
+	c, ok := s.controllers[fanId]
+	if !ok {
+		return
+	}
+
+	payload = strings.TrimSpace(payload)
+	if len(payload) <= 0 || strings.EqualFold(payload, commandAuto) {
+		ui.Info("MQTT: Resuming curve based control of fan %s", fanId)
+		c.SetPwmOverride(nil)
+		return
+	}
+
+	pwm, err := strconv.Atoi(payload)
+	if err != nil || pwm < fans.MinPwmValue || pwm > fans.MaxPwmValue {
+		ui.Warning("MQTT: Invalid pwm command for fan %s: %s", fanId, payload)
+		return
+	}
+
+	ui.Info("MQTT: Pinning fan %s to pwm %d", fanId, pwm)
+	c.SetPwmOverride(&pwm)
+}
+
+// handleCurveCommand switches the curve used to control the given fan
+func (s *Service) handleCurveCommand(fanId string, payload string) {
+	c, ok := s.controllers[fanId]
+	if !ok {
+		return
+	}
+
+	curveId := strings.TrimSpace(payload)
+	curve, exists := curves.GetSpeedCurve(curveId)
+	if !exists {
+		ui.Warning("MQTT: Unknown curve for fan %s: %s", fanId, curveId)
+		return
+	}
+
+	ui.Info("MQTT: Switching curve of fan %s to %s", fanId, curveId)
+	c.SetCurve(curve)
+}
+
+func (s *Service) publishJson(topic string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		ui.Warning("Unable to serialize MQTT payload for %s: %v", topic, err)
+		return
+	}
+	s.publish(topic, false, payload)
+}
+
+func (s *Service) publish(topic string, retained bool, payload []byte) {
+	err := s.client.Publish(topic, retained, payload)
+	if err != nil {
+		ui.Warning("Unable to publish to MQTT topic %s: %v", topic, err)
+	}
+}
+
+func (s *Service) stateTopic(kind string, id string) string {
+	return fmt.Sprintf("%s/%s/%s/state", s.config.TopicPrefix, kind, id)
+}
+
+func (s *Service) fanPwmCommandTopic(fanId string) string {
+	return fmt.Sprintf("%s/fan/%s/pwm/set", s.config.TopicPrefix, fanId)
+}
+
+func (s *Service) fanCurveCommandTopic(fanId string) string {
+	return fmt.Sprintf("%s/fan/%s/curve/set", s.config.TopicPrefix, fanId)
+}
+
+func availabilityTopic(topicPrefix string) string {
+	return fmt.Sprintf("%s/status", topicPrefix)
+}
+
+func controlModeName(mode fans.ControlMode) string {
+	switch mode {
+	case fans.ControlModeDisabled:
+		return "disabled"
+	case fans.ControlModePWM:
+		return "pwm"
+	case fans.ControlModeAutomatic:
+		return "auto"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,268 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/stretchr/testify/assert"
+)
+
+type message struct {
+	retained bool
+	payload  []byte
+}
+
+type mockClient struct {
+	published     map[string]message
+	subscriptions map[string]MessageHandler
+}
+
+func newMockClient() *mockClient {
+	return &mockClient{
+		published:     map[string]message{},
+		subscriptions: map[string]MessageHandler{},
+	}
+}
+
+func (c *mockClient) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (c *mockClient) Disconnect() {}
+
+func (c *mockClient) Publish(topic string, retained bool, payload []byte) error {
+	c.published[topic] = message{retained: retained, payload: payload}
+	return nil
+}
+
+func (c *mockClient) Subscribe(topic string, handler MessageHandler) error {
+	c.subscriptions[topic] = handler
+	return nil
+}
+
+func (c *mockClient) receive(topic string, payload string) {
+	c.subscriptions[topic](topic, []byte(payload))
+}
+
+type mockFan struct {
+	fans.Fan
+	ID string
+}
+
+func (fan mockFan) GetId() string {
+	return fan.ID
+}
+
+func (fan mockFan) GetCurveId() string {
+	return "curve"
+}
+
+func (fan mockFan) Supports(feature fans.FeatureFlag) bool {
+	return false
+}
+
+type mockController struct {
+	controller.FanController
+	fanId       string
+	curveId     string
+	pwmOverride *int
+	curveValue  *int
+	state       controller.FanControllerState
+}
+
+func (c *mockController) GetFanId() string {
+	return c.fanId
+}
+
+func (c *mockController) SetPwmOverride(pwm *int) {
+	c.pwmOverride = pwm
+}
+
+func (c *mockController) GetPwmOverride() *int {
+	return c.pwmOverride
+}
+
+func (c *mockController) SetCurve(curve curves.SpeedCurve) {
+	c.curveId = curve.GetId()
+}
+
+func (c *mockController) GetCurveId() string {
+	return c.curveId
+}
+
+func (c *mockController) GetLastCurveValue() (int, bool) {
+	if c.curveValue == nil {
+		return 0, false
+	}
+	return *c.curveValue, true
+}
+
+func (c *mockController) GetState() controller.FanControllerState {
+	return c.state
+}
+
+type mockCurve struct {
+	ID    string
+	Value int
+}
+
+func (c mockCurve) GetId() string {
+	return c.ID
+}
+
+func (c mockCurve) Evaluate() (int, error) {
+	return c.Value, nil
+}
+
+func createService(t *testing.T) (*Service, *mockClient, *mockController) {
+	sensors.SensorMap = map[string]sensors.Sensor{}
+	curves.SpeedCurveMap = map[string]curves.SpeedCurve{
+		"curve":  mockCurve{ID: "curve", Value: 100},
+		"silent": mockCurve{ID: "silent", Value: 50},
+	}
+	fans.FanMap = map[string]fans.Fan{
+		"fan": mockFan{ID: "fan"},
+	}
+	t.Cleanup(func() {
+		sensors.SensorMap = map[string]sensors.Sensor{}
+		curves.SpeedCurveMap = map[string]curves.SpeedCurve{}
+		fans.FanMap = map[string]fans.Fan{}
+	})
+
+	config := configuration.MqttConfig{
+		Enabled:         true,
+		ClientId:        "fan2go",
+		TopicPrefix:     "fan2go",
+		PublishInterval: time.Second,
+		HomeAssistant: configuration.MqttHomeAssistantConfig{
+			Enabled:         true,
+			DiscoveryPrefix: "homeassistant",
+		},
+	}
+	client := newMockClient()
+	c := &mockController{fanId: "fan", curveId: "curve"}
+
+	return NewService(config, client, []controller.FanController{c}), client, c
+}
+
+func TestService_PublishState(t *testing.T) {
+	// GIVEN
+	service, client, c := createService(t)
+	pinned := 200
+	c.pwmOverride = &pinned
+	curveValue := 80
+	c.curveValue = &curveValue
+	pwm := 120
+	rpmAvg := 1234.5
+	mode := fans.ControlModePWM
+	c.state = controller.FanControllerState{Pwm: &pwm, RpmAvg: &rpmAvg, Mode: &mode}
+
+	// WHEN
+	service.PublishState()
+
+	// THEN
+	curveState := CurveState{}
+	assert.NoError(t, json.Unmarshal(client.published["fan2go/curve/curve/state"].payload, &curveState))
+	assert.Equal(t, 80, curveState.Value)
+	// curves are never evaluated by the service itself
+	assert.NotContains(t, client.published, "fan2go/curve/silent/state")
+
+	fanState := FanState{}
+	assert.NoError(t, json.Unmarshal(client.published["fan2go/fan/fan/state"].payload, &fanState))
+	assert.Equal(t, 120, fanState.Pwm)
+	assert.Equal(t, 1234, *fanState.Rpm)
+	assert.Equal(t, "pwm", fanState.Mode)
+	assert.Equal(t, "curve", fanState.Curve)
+	assert.Equal(t, &pinned, fanState.Pinned)
+}
+
+func TestService_PwmCommand(t *testing.T) {
+	// GIVEN
+	service, client, c := createService(t)
+	assert.NoError(t, service.subscribe())
+
+	// WHEN
+	client.receive("fan2go/fan/fan/pwm/set", "180")
+
+	// THEN
+	assert.Equal(t, 180, *c.pwmOverride)
+
+	// WHEN
+	client.receive("fan2go/fan/fan/pwm/set", "300")
+
+	// THEN
+	assert.Equal(t, 180, *c.pwmOverride)
+
+	// WHEN
+	client.receive("fan2go/fan/fan/pwm/set", "auto")
+
+	// THEN
+	assert.Nil(t, c.pwmOverride)
+}
+
+func TestService_CurveCommand(t *testing.T) {
+	// GIVEN
+	service, client, c := createService(t)
+	assert.NoError(t, service.subscribe())
+
+	// WHEN
+	client.receive("fan2go/fan/fan/curve/set", "silent")
+	client.receive("fan2go/fan/fan/curve/set", "unknown")
+
+	// THEN
+	assert.Equal(t, "silent", c.curveId)
+}
+
+func TestService_SensorMessage(t *testing.T) {
+	// GIVEN
+	service, client, _ := createService(t)
+	sensor := &sensors.MqttSensor{
+		Config: configuration.SensorConfig{
+			ID: "room",
+			Mqtt: &configuration.MqttSensorConfig{
+				Topic: "home/room/temperature",
+			},
+		},
+	}
+	sensors.SensorMap[sensor.GetId()] = sensor
+	assert.NoError(t, service.subscribe())
+
+	// WHEN
+	client.receive("home/room/temperature", "21000")
+
+	// THEN
+	value, err := sensor.GetValue()
+	assert.NoError(t, err)
+	assert.Equal(t, 21000.0, value)
+}
+
+func TestService_PublishDiscovery(t *testing.T) {
+	// GIVEN
+	service, client, _ := createService(t)
+
+	// WHEN
+	service.publishDiscovery()
+
+	// THEN
+	msg, ok := client.published["homeassistant/select/fan2go/fan2go_fan_fan_curve/config"]
+	assert.True(t, ok)
+	assert.True(t, msg.retained)
+
+	entity := HomeAssistantEntity{}
+	assert.NoError(t, json.Unmarshal(msg.payload, &entity))
+	assert.Equal(t, "fan2go/fan/fan/curve/set", entity.CommandTopic)
+	assert.Equal(t, []string{"curve", "silent"}, entity.Options)
+	assert.Equal(t, "fan2go/status", entity.AvailabilityTopic)
+
+	_, ok = client.published["homeassistant/number/fan2go/fan2go_fan_fan_pinned_pwm/config"]
+	assert.True(t, ok)
+	_, ok = client.published["homeassistant/sensor/fan2go/fan2go_fan_fan_rpm/config"]
+	assert.False(t, ok)
+}
@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"path"
 	"testing"
 
 	"github.com/markusressel/fan2go/internal/configuration"
@@ -9,10 +10,6 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-const (
-	dbTestingPath = "./test.db"
-)
-
 var (
 	LinearFan = map[int]float64{
 		0:   0.0,
@@ -28,7 +25,7 @@ var (
 
 func TestPersistence_DeleteFanPwmData(t *testing.T) {
 	// GIVEN
-	p := NewPersistence(dbTestingPath)
+	p := newTestPersistence(t)
 	fan, _ := createFan(false, LinearFan)
 	_ = p.SaveFanPwmData(fan)
 
@@ -44,7 +41,7 @@ func TestPersistence_DeleteFanPwmData(t *testing.T) {
 
 func TestPersistence_SaveFanPwmData_LinearFanInterpolated(t *testing.T) {
 	// GIVEN
-	p := NewPersistence(dbTestingPath)
+	p := newTestPersistence(t)
 
 	expected := util.InterpolateLinearly(&LinearFan, 0, 255)
 	fan, _ := createFan(false, expected)
@@ -58,7 +55,7 @@ func TestPersistence_SaveFanPwmData_LinearFanInterpolated(t *testing.T) {
 
 func TestPersistence_LoadFanPwmData_LinearFanInterpolated(t *testing.T) {
 	// GIVEN
-	persistence := NewPersistence(dbTestingPath)
+	persistence := newTestPersistence(t)
 
 	expected := util.InterpolateLinearly(&LinearFan, 0, 255)
 	fan, _ := createFan(false, expected)
@@ -77,7 +74,7 @@ func TestPersistence_LoadFanPwmData_LinearFanInterpolated(t *testing.T) {
 
 func TestPersistence_SaveFanPwmData_SamplesNotInterpolated(t *testing.T) {
 	// GIVEN
-	p := NewPersistence(dbTestingPath)
+	p := newTestPersistence(t)
 
 	expected := NeverStoppingFan
 	fan, _ := createFan(false, expected)
@@ -91,7 +88,7 @@ func TestPersistence_SaveFanPwmData_SamplesNotInterpolated(t *testing.T) {
 
 func TestPersistence_LoadFanPwmData_SamplesNotInterpolated(t *testing.T) {
 	// GIVEN
-	persistence := NewPersistence(dbTestingPath)
+	persistence := newTestPersistence(t)
 
 	expected := NeverStoppingFan
 	fan, _ := createFan(false, expected)
@@ -108,6 +105,11 @@ func TestPersistence_LoadFanPwmData_SamplesNotInterpolated(t *testing.T) {
 	assert.Equal(t, expected, fanData)
 }
 
+// newTestPersistence creates a persistence backed by a database file in a temporary directory
+func newTestPersistence(t *testing.T) Persistence {
+	return NewPersistence(path.Join(t.TempDir(), "fan2go.db"))
+}
+
 func createFan(neverStop bool, curveData map[int]float64) (fan fans.Fan, err error) {
 	configuration.CurrentConfig.RpmRollingWindowSize = 10
 
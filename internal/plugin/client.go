@@ -0,0 +1,221 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+)
+
+// DefaultTimeout is the time to wait for the response to a single request
+const DefaultTimeout = 2 * time.Second
+
+var (
+	clients     = map[string]*Client{}
+	clientsLock sync.Mutex
+)
+
+// Client connects to a plugin over its Unix socket.
+// The connection is established lazily and re-established after an error.
+type Client struct {
+	Id      string
+	Socket  string
+	Timeout time.Duration
+
+	// serializes requests
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextId int
+
+	description *Description
+}
+
+// GetClient returns the client of the plugin with the given id, as configured in the "plugins" section
+func GetClient(id string) (*Client, error) {
+	clientsLock.Lock()
+	defer clientsLock.Unlock()
+
+	if c, ok := clients[id]; ok {
+		return c, nil
+	}
+
+	for _, config := range configuration.CurrentConfig.Plugins {
+		if config.ID == id {
+			c := NewClient(config)
+			clients[id] = c
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no plugin with id '%s' configured", id)
+}
+
+func NewClient(config configuration.PluginConfig) *Client {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Client{
+		Id:      config.ID,
+		Socket:  config.Socket,
+		Timeout: timeout,
+	}
+}
+
+// Describe returns the devices advertised by the plugin, the result is cached after the first successful call
+func (c *Client) Describe() (Description, error) {
+	c.mu.Lock()
+	description := c.description
+	c.mu.Unlock()
+	if description != nil {
+		return *description, nil
+	}
+
+	result := Description{}
+	err := c.Call(MethodDescribe, nil, &result)
+	if err != nil {
+		return result, err
+	}
+
+	c.mu.Lock()
+	c.description = &result
+	c.mu.Unlock()
+	return result, nil
+}
+
+func (c *Client) GetPwm(fanId string) (int, error) {
+	var result int
+	err := c.Call(MethodFanGetPwm, FanParams{Id: fanId}, &result)
+	return result, err
+}
+
+func (c *Client) SetPwm(fanId string, pwm int) error {
+	return c.Call(MethodFanSetPwm, FanParams{Id: fanId, Value: &pwm}, nil)
+}
+
+func (c *Client) GetRpm(fanId string) (int, error) {
+	var result int
+	err := c.Call(MethodFanGetRpm, FanParams{Id: fanId}, &result)
+	return result, err
+}
+
+func (c *Client) GetPwmEnabled(fanId string) (int, error) {
+	var result int
+	err := c.Call(MethodFanGetPwmEnabled, FanParams{Id: fanId}, &result)
+	return result, err
+}
+
+func (c *Client) SetPwmEnabled(fanId string, mode int) error {
+	return c.Call(MethodFanSetPwmEnabled, FanParams{Id: fanId, Value: &mode}, nil)
+}
+
+func (c *Client) GetValue(sensorId string) (float64, error) {
+	var result float64
+	err := c.Call(MethodSensorGetValue, SensorParams{Id: sensorId}, &result)
+	return result, err
+}
+
+// Call sends a request to the plugin and decodes the result of the response into result, if not nil
+func (c *Client) Call(method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	response, err := c.roundTrip(method, params)
+	if err != nil {
+		// the connection is in an unknown state, reconnect on the next call
+		c.disconnect()
+		return fmt.Errorf("plugin %s: %s: %v", c.Id, method, err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("plugin %s: %s: %v", c.Id, method, response.Error)
+	}
+
+	if result != nil {
+		err = json.Unmarshal(response.Result, result)
+		if err != nil {
+			return fmt.Errorf("plugin %s: %s: invalid result: %v", c.Id, method, err)
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the plugin
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.disconnect()
+}
+
+func (c *Client) roundTrip(method string, params interface{}) (Response, error) {
+	response := Response{}
+
+	c.nextId++
+	request := Request{
+		JsonRpc: jsonRpcVersion,
+		Id:      c.nextId,
+		Method:  method,
+		Params:  params,
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return response, err
+	}
+
+	err = c.conn.SetDeadline(time.Now().Add(c.Timeout))
+	if err != nil {
+		return response, err
+	}
+
+	_, err = c.conn.Write(append(data, '\n'))
+	if err != nil {
+		return response, err
+	}
+
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return response, err
+	}
+	err = json.Unmarshal(line, &response)
+	if err != nil {
+		return response, err
+	}
+	if response.Id != request.Id {
+		return response, fmt.Errorf("unexpected response id %d, expected %d", response.Id, request.Id)
+	}
+	return response, nil
+}
+
+func (c *Client) connect() error {
+	if c.conn != nil {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", c.Socket, c.Timeout)
+	if err != nil {
+		return fmt.Errorf("plugin %s: unable to connect to %s: %v", c.Id, c.Socket, err)
+	}
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+	return nil
+}
+
+func (c *Client) disconnect() {
+	if c.conn == nil {
+		return
+	}
+	_ = c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+}
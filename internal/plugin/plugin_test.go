@@ -0,0 +1,219 @@
+package plugin
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHandler struct {
+	mu          sync.Mutex
+	pwm         map[string]int
+	controlMode map[string]int
+}
+
+func newMockHandler() *mockHandler {
+	return &mockHandler{
+		pwm:         map[string]int{"fan1": 100},
+		controlMode: map[string]int{"fan1": 2},
+	}
+}
+
+func (h *mockHandler) Describe() Description {
+	return Description{
+		Fans: []FanDescription{
+			{Id: "fan1", Features: []string{FeatureRpmSensor, FeatureControlMode}},
+			{Id: "fan2"},
+		},
+		Sensors: []SensorDescription{
+			{Id: "probe1"},
+		},
+	}
+}
+
+func (h *mockHandler) GetPwm(fanId string) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	pwm, ok := h.pwm[fanId]
+	if !ok {
+		return 0, fmt.Errorf("unknown fan: %s", fanId)
+	}
+	return pwm, nil
+}
+
+func (h *mockHandler) SetPwm(fanId string, pwm int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pwm[fanId] = pwm
+	return nil
+}
+
+func (h *mockHandler) GetRpm(fanId string) (int, error) {
+	pwm, err := h.GetPwm(fanId)
+	return pwm * 10, err
+}
+
+func (h *mockHandler) GetPwmEnabled(fanId string) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.controlMode[fanId], nil
+}
+
+func (h *mockHandler) SetPwmEnabled(fanId string, mode int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.controlMode[fanId] = mode
+	return nil
+}
+
+func (h *mockHandler) GetValue(sensorId string) (float64, error) {
+	if sensorId != "probe1" {
+		return 0, fmt.Errorf("unknown sensor: %s", sensorId)
+	}
+	return 42000, nil
+}
+
+func startMockPlugin(t *testing.T, handler Handler) string {
+	socket := path.Join(t.TempDir(), "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go func() {
+		_ = Serve(listener, handler)
+	}()
+	return socket
+}
+
+func createClient(t *testing.T) (*Client, *mockHandler) {
+	handler := newMockHandler()
+	socket := startMockPlugin(t, handler)
+	c := NewClient(configuration.PluginConfig{
+		ID:     "mock",
+		Socket: socket,
+	})
+	t.Cleanup(c.Close)
+	return c, handler
+}
+
+func TestClient_Describe(t *testing.T) {
+	// GIVEN
+	c, _ := createClient(t)
+
+	// WHEN
+	description, err := c.Describe()
+
+	// THEN
+	assert.NoError(t, err)
+	fan, ok := description.GetFan("fan1")
+	assert.True(t, ok)
+	assert.True(t, fan.Supports(FeatureRpmSensor))
+	_, ok = description.GetSensor("probe1")
+	assert.True(t, ok)
+	_, ok = description.GetSensor("probe2")
+	assert.False(t, ok)
+}
+
+func TestClient_Fan(t *testing.T) {
+	// GIVEN
+	c, handler := createClient(t)
+
+	// WHEN
+	err := c.SetPwm("fan1", 200)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 200, handler.pwm["fan1"])
+
+	pwm, err := c.GetPwm("fan1")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, pwm)
+
+	rpm, err := c.GetRpm("fan1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2000, rpm)
+
+	err = c.SetPwmEnabled("fan1", 1)
+	assert.NoError(t, err)
+	mode, err := c.GetPwmEnabled("fan1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, mode)
+}
+
+func TestClient_Sensor(t *testing.T) {
+	// GIVEN
+	c, _ := createClient(t)
+
+	// WHEN
+	value, err := c.GetValue("probe1")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 42000.0, value)
+}
+
+func TestClient_DeviceError(t *testing.T) {
+	// GIVEN
+	c, _ := createClient(t)
+
+	// WHEN
+	_, err := c.GetPwm("fan3")
+
+	// THEN
+	assert.EqualError(t, err, "plugin mock: fan.getPwm: unknown fan: fan3 (-32000)")
+
+	// the connection is still usable
+	_, err = c.GetValue("probe1")
+	assert.NoError(t, err)
+}
+
+func TestClient_UnknownMethod(t *testing.T) {
+	// GIVEN
+	c, _ := createClient(t)
+
+	// WHEN
+	err := c.Call("fan.explode", FanParams{Id: "fan1"}, nil)
+
+	// THEN
+	assert.EqualError(t, err, "plugin mock: fan.explode: unknown method: fan.explode (-32601)")
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	// GIVEN
+	socket := path.Join(t.TempDir(), "plugin.sock")
+	c := NewClient(configuration.PluginConfig{
+		ID:      "mock",
+		Socket:  socket,
+		Timeout: time.Second,
+	})
+	t.Cleanup(c.Close)
+
+	// WHEN
+	_, err := c.GetValue("probe1")
+
+	// THEN
+	assert.Error(t, err)
+
+	// WHEN
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		_ = Serve(listener, newMockHandler())
+	}()
+	value, err := c.GetValue("probe1")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 42000.0, value)
+}
@@ -0,0 +1,113 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// fan2go talks to plugins using JSON-RPC 2.0 over a Unix socket.
+// Every request and response is a single line of JSON.
+
+const (
+	jsonRpcVersion = "2.0"
+
+	MethodDescribe         = "describe"
+	MethodFanGetPwm        = "fan.getPwm"
+	MethodFanSetPwm        = "fan.setPwm"
+	MethodFanGetRpm        = "fan.getRpm"
+	MethodFanGetPwmEnabled = "fan.getPwmEnabled"
+	MethodFanSetPwmEnabled = "fan.setPwmEnabled"
+	MethodSensorGetValue   = "sensor.getValue"
+
+	// FeatureRpmSensor is advertised by fans providing their current rpm
+	FeatureRpmSensor = "rpmSensor"
+	// FeatureControlMode is advertised by fans supporting different control modes (see fans.ControlMode)
+	FeatureControlMode = "controlMode"
+
+	// error codes defined by JSON-RPC 2.0
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	// error code used for errors reported by the plugin itself
+	codeDeviceError = -32000
+)
+
+type Request struct {
+	JsonRpc string      `json:"jsonrpc"`
+	Id      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type Response struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// Description is the result of the "describe" method, advertising all devices provided by a plugin
+type Description struct {
+	Fans    []FanDescription    `json:"fans"`
+	Sensors []SensorDescription `json:"sensors"`
+}
+
+type FanDescription struct {
+	Id       string   `json:"id"`
+	Features []string `json:"features"`
+}
+
+type SensorDescription struct {
+	Id string `json:"id"`
+}
+
+// FanParams are the params of all "fan.*" methods
+type FanParams struct {
+	Id string `json:"id"`
+	// Value is the pwm value for "fan.setPwm" and the control mode for "fan.setPwmEnabled"
+	Value *int `json:"value,omitempty"`
+}
+
+// SensorParams are the params of all "sensor.*" methods
+type SensorParams struct {
+	Id string `json:"id"`
+}
+
+// GetFan returns the description of the fan with the given id, if it is advertised
+func (d Description) GetFan(id string) (FanDescription, bool) {
+	for _, fan := range d.Fans {
+		if fan.Id == id {
+			return fan, true
+		}
+	}
+	return FanDescription{}, false
+}
+
+// GetSensor returns the description of the sensor with the given id, if it is advertised
+func (d Description) GetSensor(id string) (SensorDescription, bool) {
+	for _, sensor := range d.Sensors {
+		if sensor.Id == id {
+			return sensor, true
+		}
+	}
+	return SensorDescription{}, false
+}
+
+// Supports indicates whether the fan advertises the given feature
+func (d FanDescription) Supports(feature string) bool {
+	for _, f := range d.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Handler implements the devices of a plugin
+type Handler interface {
+	Describe() Description
+
+	GetPwm(fanId string) (int, error)
+	SetPwm(fanId string, pwm int) error
+	GetRpm(fanId string) (int, error)
+	GetPwmEnabled(fanId string) (int, error)
+	SetPwmEnabled(fanId string, mode int) error
+
+	GetValue(sensorId string) (float64, error)
+}
+
+// Serve accepts connections on the given listener and answers requests using the given handler,
+// until the listener is closed
+func Serve(listener net.Listener, handler Handler) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveConn(conn, handler)
+	}
+}
+
+func serveConn(conn net.Conn, handler Handler) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+
+		response := handleRequest(line, handler)
+		data, err := json.Marshal(response)
+		if err != nil {
+			return
+		}
+		_, err = conn.Write(append(data, '\n'))
+		if err != nil {
+			return
+		}
+	}
+}
+
+type rawRequest struct {
+	Id     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+func handleRequest(line []byte, handler Handler) Response {
+	request := rawRequest{}
+	err := json.Unmarshal(line, &request)
+	if err != nil {
+		return errorResponse(request.Id, codeParseError, err.Error())
+	}
+
+	var result interface{}
+	switch request.Method {
+	case MethodDescribe:
+		result = handler.Describe()
+	case MethodSensorGetValue:
+		params := SensorParams{}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return errorResponse(request.Id, codeInvalidParams, err.Error())
+		}
+		result, err = handler.GetValue(params.Id)
+	case MethodFanGetPwm, MethodFanSetPwm, MethodFanGetRpm, MethodFanGetPwmEnabled, MethodFanSetPwmEnabled:
+		params := FanParams{}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return errorResponse(request.Id, codeInvalidParams, err.Error())
+		}
+		result, err = handleFanRequest(request.Method, params, handler)
+		if errors.Is(err, errMissingValue) {
+			return errorResponse(request.Id, codeInvalidParams, err.Error())
+		}
+	default:
+		return errorResponse(request.Id, codeMethodNotFound, fmt.Sprintf("unknown method: %s", request.Method))
+	}
+
+	if err != nil {
+		return errorResponse(request.Id, codeDeviceError, err.Error())
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(request.Id, codeDeviceError, err.Error())
+	}
+	return Response{
+		JsonRpc: jsonRpcVersion,
+		Id:      request.Id,
+		Result:  data,
+	}
+}
+
+var errMissingValue = errors.New("missing value")
+
+func handleFanRequest(method string, params FanParams, handler Handler) (interface{}, error) {
+	switch method {
+	case MethodFanGetPwm:
+		return handler.GetPwm(params.Id)
+	case MethodFanGetRpm:
+		return handler.GetRpm(params.Id)
+	case MethodFanGetPwmEnabled:
+		return handler.GetPwmEnabled(params.Id)
+	}
+
+	if params.Value == nil {
+		return nil, errMissingValue
+	}
+	if method == MethodFanSetPwm {
+		return true, handler.SetPwm(params.Id, *params.Value)
+	}
+	return true, handler.SetPwmEnabled(params.Id, *params.Value)
+}
+
+func errorResponse(id int, code int, message string) Response {
+	return Response{
+		JsonRpc: jsonRpcVersion,
+		Id:      id,
+		Error: &Error{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/trace"
+	"github.com/markusressel/fan2go/internal/ui"
+)
+
+type Recorder interface {
+	Run(ctx context.Context) error
+}
+
+type recorder struct {
+	path     string
+	interval time.Duration
+	// creates the entry appended to the trace
+	capture func(now time.Time) trace.Entry
+}
+
+// NewRecorder creates a recorder, which reads all sensors and fans and appends their values to the trace file
+// at the given path. Reading sensors and fans can change their state, so this must only be used while
+// no fan controllers are running.
+func NewRecorder(path string, interval time.Duration) Recorder {
+	return recorder{
+		path:     path,
+		interval: interval,
+		capture:  captureTraceEntry,
+	}
+}
+
+// NewControllerRecorder creates a recorder, which appends the moving averages of all sensors and the fan state
+// tracked by the given controllers to the trace file at the given path, without reading any sensor or fan itself
+func NewControllerRecorder(path string, interval time.Duration, controllers []controller.FanController) Recorder {
+	return recorder{
+		path:     path,
+		interval: interval,
+		capture: func(now time.Time) trace.Entry {
+			return captureControllerTraceEntry(now, controllers)
+		},
+	}
+}
+
+func (r recorder) Run(ctx context.Context) error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := trace.NewWriter(file)
+
+	ui.Info("Recording sensor and fan values to %s...", r.path)
+	tick := time.NewTicker(r.interval)
+	defer tick.Stop()
+	for {
+		err = writer.Write(r.capture(time.Now()))
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			ui.Info("Stopping recorder...")
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+// captureTraceEntry reads the current values of all sensors and fans,
+// values that cannot be read are omitted
+func captureTraceEntry(now time.Time) trace.Entry {
+	entry := trace.Entry{
+		Time:    now,
+		Sensors: map[string]float64{},
+		Fans:    map[string]trace.FanEntry{},
+	}
+
+	for id, sensor := range sensors.SensorMap {
+		value, err := sensor.GetValue()
+		if err != nil {
+			ui.Debug("Unable to record value of sensor %s: %v", id, err)
+			continue
+		}
+		entry.Sensors[id] = value
+	}
+
+	for id, fan := range fans.FanMap {
+		pwm, err := fan.GetPwm()
+		if err != nil {
+			ui.Debug("Unable to record pwm of fan %s: %v", id, err)
+			continue
+		}
+		fanEntry := trace.FanEntry{Pwm: pwm}
+		if fan.Supports(fans.FeatureRpmSensor) {
+			if rpm, err := fan.GetRpm(); err == nil {
+				fanEntry.Rpm = &rpm
+			}
+		}
+		entry.Fans[id] = fanEntry
+	}
+
+	return entry
+}
+
+// captureControllerTraceEntry records the moving averages of all sensors, which are what curves are computed from,
+// and the state of all fans as tracked by the given controllers. Fans without a known pwm value are omitted.
+func captureControllerTraceEntry(now time.Time, controllers []controller.FanController) trace.Entry {
+	entry := trace.Entry{
+		Time:    now,
+		Sensors: map[string]float64{},
+		Fans:    map[string]trace.FanEntry{},
+	}
+
+	for id, sensor := range sensors.SensorMap {
+		entry.Sensors[id] = sensor.GetMovingAvg()
+	}
+
+	for _, c := range controllers {
+		state := c.GetState()
+		if state.Pwm == nil {
+			continue
+		}
+		fanEntry := trace.FanEntry{Pwm: *state.Pwm}
+		if state.RpmAvg != nil {
+			rpm := int(*state.RpmAvg)
+			fanEntry.Rpm = &rpm
+		}
+		entry.Fans[c.GetFanId()] = fanEntry
+	}
+
+	return entry
+}
@@ -24,6 +24,15 @@ func (sensor CmdSensor) GetConfig() configuration.SensorConfig {
 }
 
 func (sensor CmdSensor) GetValue() (float64, error) {
+	if conf := sensor.Config.Cmd.Coprocess; conf != nil {
+		coprocess := util.GetCoprocess(conf.Exec, conf.Args)
+		value, err := coprocess.Request("getValue", sensor.GetId(), nil, conf.Timeout)
+		if err != nil {
+			return 0, fmt.Errorf("sensor %s: %v", sensor.GetId(), err)
+		}
+		return value, nil
+	}
+
 	timeout := 2 * time.Second
 	exec := sensor.Config.Cmd.Exec
 	args := sensor.Config.Cmd.Args
@@ -1,14 +1,44 @@
 package sensors
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
+
 	"github.com/markusressel/fan2go/internal/configuration"
 )
 
 var (
 	SensorMap = map[string]Sensor{}
+
+	// ErrSensorAbsent is returned when the value of a sensor is requested, whose device has disappeared at runtime,
+	// or whose value is not available otherwise, f.ex. because an externally pushed value has expired
+	ErrSensorAbsent = errors.New("sensor value is unavailable")
+
+	// absentSensors contains the ids of all sensors whose device has disappeared at runtime, or whose value is not available
+	absentSensors      = map[string]bool{}
+	absentSensorsMutex sync.RWMutex
 )
 
+// SetAbsent marks the sensor with the given id as absent or present
+func SetAbsent(id string, absent bool) {
+	absentSensorsMutex.Lock()
+	defer absentSensorsMutex.Unlock()
+	if absent {
+		absentSensors[id] = true
+	} else {
+		delete(absentSensors, id)
+	}
+}
+
+// IsAbsent returns true if the sensor with the given id has disappeared at runtime, or its value is not available
+func IsAbsent(id string) bool {
+	absentSensorsMutex.RLock()
+	defer absentSensorsMutex.RUnlock()
+	return absentSensors[id]
+}
+
 type Sensor interface {
 	GetId() string
 
@@ -22,6 +52,17 @@ type Sensor interface {
 	SetMovingAvg(avg float64)
 }
 
+// BackgroundSensor is implemented by sensors whose value takes too long to read on every GetValue call.
+// GetValue only returns values while Run is running, Fetch can be used to read a single value instead.
+type BackgroundSensor interface {
+	Sensor
+
+	// Run updates the value returned by GetValue in the background, until the given context is done
+	Run(ctx context.Context) error
+	// Fetch reads the current value, blocking until it is available
+	Fetch() (float64, error)
+}
+
 func NewSensor(config configuration.SensorConfig) (Sensor, error) {
 	if config.HwMon != nil {
 		return &HwmonSensor{
@@ -43,5 +84,49 @@ func NewSensor(config configuration.SensorConfig) (Sensor, error) {
 		}, nil
 	}
 
+	if config.Rapl != nil {
+		return &RaplSensor{
+			Config: config,
+		}, nil
+	}
+
+	if config.Http != nil {
+		return &HttpSensor{
+			Config: config,
+		}, nil
+	}
+
+	if config.Push != nil {
+		return &PushSensor{
+			Config: config,
+		}, nil
+	}
+
+	if config.Mqtt != nil {
+		return &MqttSensor{
+			Config: config,
+		}, nil
+	}
+
+	if config.ThermalZone != nil {
+		return &ThermalZoneSensor{
+			Config: config,
+		}, nil
+	}
+
+	if config.Plugin != nil {
+		sensor, err := newPluginSensor(config)
+		if err != nil {
+			return nil, err
+		}
+		return sensor, nil
+	}
+
+	if config.Replay != nil {
+		return &ReplaySensor{
+			Config: config,
+		}, nil
+	}
+
 	return nil, fmt.Errorf("no matching sensor type for sensor: %s", config.ID)
 }
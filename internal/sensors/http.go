@@ -0,0 +1,211 @@
+package sensors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	DefaultHttpSensorInterval = 5 * time.Second
+	DefaultHttpSensorTimeout  = 2 * time.Second
+)
+
+// HttpSensor fetches its value from a JSON or Prometheus exposition format endpoint.
+// Since sensors are polled much more often than it is reasonable to fetch a remote
+// resource, the value is fetched in the background by Run at the configured interval,
+// and reading the sensor returns the last fetched value.
+type HttpSensor struct {
+	Config    configuration.SensorConfig `json:"configuration"`
+	MovingAvg float64                    `json:"movingAvg"`
+
+	// value of the last successful fetch
+	lastValue float64
+	// error of the last fetch, nil if it succeeded
+	lastErr error
+	// whether a fetch has completed yet
+	fetched bool
+
+	mu sync.Mutex
+}
+
+func (sensor *HttpSensor) GetId() string {
+	return sensor.Config.ID
+}
+
+func (sensor *HttpSensor) GetConfig() configuration.SensorConfig {
+	return sensor.Config
+}
+
+// GetValue returns the last fetched value, or the error of the last fetch if it failed.
+// It never blocks, until Run has fetched the first value the sensor is reported as absent.
+func (sensor *HttpSensor) GetValue() (float64, error) {
+	sensor.mu.Lock()
+	defer sensor.mu.Unlock()
+
+	if !sensor.fetched {
+		return 0, fmt.Errorf("sensor %s: no value fetched yet: %w", sensor.GetId(), ErrSensorAbsent)
+	}
+	return sensor.lastValue, sensor.lastErr
+}
+
+// Run fetches the value immediately and then at the configured interval, until the given context is done
+func (sensor *HttpSensor) Run(ctx context.Context) error {
+	interval := sensor.Config.Http.Interval
+	if interval <= 0 {
+		interval = DefaultHttpSensorInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		value, err := sensor.fetch(ctx)
+
+		sensor.mu.Lock()
+		sensor.fetched = true
+		sensor.lastErr = err
+		if err == nil {
+			sensor.lastValue = value
+		}
+		sensor.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Fetch reads the current value from the endpoint, blocking until it is received or the request timed out
+func (sensor *HttpSensor) Fetch() (float64, error) {
+	return sensor.fetch(context.Background())
+}
+
+func (sensor *HttpSensor) GetMovingAvg() (avg float64) {
+	return sensor.MovingAvg
+}
+
+func (sensor *HttpSensor) SetMovingAvg(avg float64) {
+	sensor.MovingAvg = avg
+}
+
+// fetch reads the current value from the endpoint and applies the configured scale
+func (sensor *HttpSensor) fetch(ctx context.Context) (float64, error) {
+	value, err := sensor.request(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("sensor %s: %v", sensor.GetId(), err)
+	}
+	if scale := sensor.Config.Http.Scale; scale != nil {
+		value = value * *scale
+	}
+	return value, nil
+}
+
+func (sensor *HttpSensor) request(ctx context.Context) (float64, error) {
+	conf := sensor.Config.Http
+
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHttpSensorTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, conf.Url, nil)
+	if err != nil {
+		return 0, err
+	}
+	for key, value := range conf.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code: %d", response.StatusCode)
+	}
+
+	if conf.Metric != nil {
+		return extractPrometheusMetric(response.Body, *conf.Metric)
+	}
+	return extractJsonValue(response.Body, conf.JsonPath)
+}
+
+func extractJsonValue(reader io.Reader, path string) (float64, error) {
+	var data interface{}
+	err := json.NewDecoder(reader).Decode(&data)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse JSON response: %v", err)
+	}
+
+	value, err := util.ExtractJsonPath(data, path)
+	if err != nil {
+		return 0, err
+	}
+	return util.JsonValueToFloat(value)
+}
+
+// extractPrometheusMetric returns the value of the first sample of the given metric
+// whose labels contain all of the configured labels
+func extractPrometheusMetric(reader io.Reader, metric configuration.HttpSensorMetricConfig) (float64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(reader)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse metrics response: %v", err)
+	}
+
+	family, exists := families[metric.Name]
+	if !exists {
+		return 0, fmt.Errorf("metric not found: %s", metric.Name)
+	}
+
+	for _, m := range family.GetMetric() {
+		if !matchesLabels(m.GetLabel(), metric.Labels) {
+			continue
+		}
+		switch family.GetType() {
+		case dto.MetricType_GAUGE:
+			return m.GetGauge().GetValue(), nil
+		case dto.MetricType_COUNTER:
+			return m.GetCounter().GetValue(), nil
+		case dto.MetricType_UNTYPED:
+			return m.GetUntyped().GetValue(), nil
+		default:
+			return 0, fmt.Errorf("unsupported metric type of %s: %s", metric.Name, family.GetType())
+		}
+	}
+
+	return 0, fmt.Errorf("no sample of metric %s matches labels: %v", metric.Name, metric.Labels)
+}
+
+func matchesLabels(labels []*dto.LabelPair, selector map[string]string) bool {
+	for name, value := range selector {
+		found := false
+		for _, label := range labels {
+			// label names are compared case-insensitively, since the config keys are lowercased
+			if strings.EqualFold(label.GetName(), name) && label.GetValue() == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
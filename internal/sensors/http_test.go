@@ -0,0 +1,255 @@
+package sensors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+)
+
+const prometheusResponse = `# HELP node_hwmon_temp_celsius Hardware monitor for temperature (input)
+# TYPE node_hwmon_temp_celsius gauge
+node_hwmon_temp_celsius{chip="platform_coretemp_0",sensor="temp1"} 45
+node_hwmon_temp_celsius{chip="platform_coretemp_0",sensor="temp2"} 52.5
+# HELP room_temperature Ambient temperature
+# TYPE room_temperature untyped
+room_temperature 21.25
+`
+
+func createHttpSensor(httpConfig configuration.HttpSensorConfig) *HttpSensor {
+	return &HttpSensor{
+		Config: configuration.SensorConfig{
+			ID:   "http",
+			Http: &httpConfig,
+		},
+	}
+}
+
+func TestHttpSensor_Fetch_JsonPath(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		_, _ = fmt.Fprint(w, `{"sensors": [{"temperature": 42000}]}`)
+	}))
+	defer server.Close()
+
+	sensor := createHttpSensor(configuration.HttpSensorConfig{
+		Url:      server.URL,
+		Headers:  map[string]string{"authorization": "Bearer token"},
+		JsonPath: "sensors[0].temperature",
+	})
+
+	// WHEN
+	value, err := sensor.Fetch()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 42000.0, value)
+}
+
+func TestHttpSensor_Fetch_Metric(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, prometheusResponse)
+	}))
+	defer server.Close()
+
+	scale := 1000.0
+	sensor := createHttpSensor(configuration.HttpSensorConfig{
+		Url: server.URL,
+		Metric: &configuration.HttpSensorMetricConfig{
+			Name:   "node_hwmon_temp_celsius",
+			Labels: map[string]string{"sensor": "temp2"},
+		},
+		Scale: &scale,
+	})
+
+	// WHEN
+	value, err := sensor.Fetch()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 52500.0, value)
+}
+
+func TestHttpSensor_Fetch_MetricWithoutLabels(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, prometheusResponse)
+	}))
+	defer server.Close()
+
+	sensor := createHttpSensor(configuration.HttpSensorConfig{
+		Url: server.URL,
+		Metric: &configuration.HttpSensorMetricConfig{
+			Name: "room_temperature",
+		},
+	})
+
+	// WHEN
+	value, err := sensor.Fetch()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 21.25, value)
+}
+
+func TestHttpSensor_Fetch_MetricNoMatchingLabels(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, prometheusResponse)
+	}))
+	defer server.Close()
+
+	sensor := createHttpSensor(configuration.HttpSensorConfig{
+		Url: server.URL,
+		Metric: &configuration.HttpSensorMetricConfig{
+			Name:   "node_hwmon_temp_celsius",
+			Labels: map[string]string{"sensor": "temp9"},
+		},
+	})
+
+	// WHEN
+	_, err := sensor.Fetch()
+
+	// THEN
+	assert.Error(t, err)
+}
+
+// runHttpSensor runs the given sensor in the background until the test has finished
+func runHttpSensor(t *testing.T, sensor *HttpSensor) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = sensor.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestHttpSensor_GetValue_CachedWithinInterval(t *testing.T) {
+	// GIVEN
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"value": %d}`, requests.Add(1))
+	}))
+	defer server.Close()
+
+	sensor := createHttpSensor(configuration.HttpSensorConfig{
+		Url:      server.URL,
+		Interval: time.Minute,
+		JsonPath: "value",
+	})
+	runHttpSensor(t, sensor)
+	assert.Eventually(t, func() bool {
+		_, err := sensor.GetValue()
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	// WHEN
+	first, _ := sensor.GetValue()
+	second, _ := sensor.GetValue()
+
+	// THEN
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, 1.0, first)
+	assert.Equal(t, first, second)
+}
+
+func TestHttpSensor_Fetch_Timeout(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `{"value": 1}`)
+	}))
+	defer server.Close()
+
+	sensor := createHttpSensor(configuration.HttpSensorConfig{
+		Url:      server.URL,
+		Timeout:  20 * time.Millisecond,
+		JsonPath: "value",
+	})
+
+	// WHEN
+	_, err := sensor.Fetch()
+
+	// THEN
+	assert.Error(t, err)
+}
+
+func TestHttpSensor_GetValue_BeforeFirstFetch(t *testing.T) {
+	// GIVEN
+	sensor := createHttpSensor(configuration.HttpSensorConfig{
+		Url:      "http://localhost:1",
+		JsonPath: "value",
+	})
+
+	// WHEN
+	_, err := sensor.GetValue()
+
+	// THEN
+	assert.ErrorIs(t, err, ErrSensorAbsent)
+}
+
+func TestHttpSensor_GetValue_SlowEndpointDoesNotBlock(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `{"value": 1}`)
+	}))
+	defer server.Close()
+
+	sensor := createHttpSensor(configuration.HttpSensorConfig{
+		Url:      server.URL,
+		Timeout:  time.Second,
+		JsonPath: "value",
+	})
+	runHttpSensor(t, sensor)
+
+	// WHEN
+	start := time.Now()
+	_, err := sensor.GetValue()
+
+	// THEN
+	assert.ErrorIs(t, err, ErrSensorAbsent)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestHttpSensor_Run_StopsWithContext(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"value": 1}`)
+	}))
+	defer server.Close()
+
+	sensor := createHttpSensor(configuration.HttpSensorConfig{
+		Url:      server.URL,
+		Interval: time.Minute,
+		JsonPath: "value",
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- sensor.Run(ctx)
+	}()
+
+	// WHEN
+	cancel()
+
+	// THEN
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "Run did not stop after the context was cancelled")
+	}
+}
@@ -1,16 +1,22 @@
 package sensors
 
 import (
+	"sync"
+
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/util"
 )
 
+// guards the Input of all hwmon sensors, which is changed when a device is re-bound at runtime
+var hwmonInputMutex sync.RWMutex
+
 type HwmonSensor struct {
 	Label     string                     `json:"label"`
 	Index     int                        `json:"index"`
 	Input     string                     `json:"string"`
 	Max       int                        `json:"max"`
 	Min       int                        `json:"min"`
+	Crit      int                        `json:"crit"`
 	Config    configuration.SensorConfig `json:"configuration"`
 	MovingAvg float64                    `json:"movingAvg"`
 }
@@ -23,8 +29,11 @@ func (sensor HwmonSensor) GetConfig() configuration.SensorConfig {
 	return sensor.Config
 }
 
-func (sensor HwmonSensor) GetValue() (result float64, err error) {
-	integer, err := util.ReadIntFromFile(sensor.Input)
+func (sensor *HwmonSensor) GetValue() (result float64, err error) {
+	if IsAbsent(sensor.Config.ID) {
+		return 0, ErrSensorAbsent
+	}
+	integer, err := util.ReadIntFromFile(sensor.GetInput())
 	if err != nil {
 		return 0, err
 	}
@@ -39,3 +48,20 @@ func (sensor HwmonSensor) GetMovingAvg() (avg float64) {
 func (sensor *HwmonSensor) SetMovingAvg(avg float64) {
 	sensor.MovingAvg = avg
 }
+
+// GetInput returns the path of the temperature input file of this sensor
+func (sensor *HwmonSensor) GetInput() string {
+	hwmonInputMutex.RLock()
+	defer hwmonInputMutex.RUnlock()
+	return sensor.Input
+}
+
+// Rebind changes the temperature input file of this sensor, f.ex. after its device reappeared with a new hwmon number
+func (sensor *HwmonSensor) Rebind(input string) {
+	hwmonInputMutex.Lock()
+	defer hwmonInputMutex.Unlock()
+	sensor.Input = input
+	if sensor.Config.HwMon != nil {
+		sensor.Config.HwMon.TempInput = input
+	}
+}
@@ -0,0 +1,89 @@
+package sensors
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+)
+
+// MqttSensor holds the last value received on an MQTT topic
+type MqttSensor struct {
+	Config    configuration.SensorConfig `json:"configuration"`
+	MovingAvg float64                    `json:"movingAvg"`
+
+	// Value is the last received value
+	Value *float64 `json:"value"`
+	// LastMessage is the time the last value was received at
+	LastMessage time.Time `json:"lastMessage"`
+
+	mu sync.Mutex
+}
+
+func (sensor *MqttSensor) GetId() string {
+	return sensor.Config.ID
+}
+
+func (sensor *MqttSensor) GetConfig() configuration.SensorConfig {
+	return sensor.Config
+}
+
+func (sensor *MqttSensor) GetValue() (float64, error) {
+	sensor.mu.Lock()
+	defer sensor.mu.Unlock()
+
+	conf := sensor.Config.Mqtt
+	return evaluatePushedValue(sensor.GetId(), sensor.Value, sensor.LastMessage, conf.Expiry, conf.Default)
+}
+
+// State returns a copy of the current state of this sensor
+func (sensor *MqttSensor) State() PushSensorState {
+	sensor.mu.Lock()
+	defer sensor.mu.Unlock()
+
+	return newPushSensorState(sensor.GetId(), sensor.Value, sensor.LastMessage)
+}
+
+// GetTopic returns the MQTT topic this sensor receives its values from
+func (sensor *MqttSensor) GetTopic() string {
+	return sensor.Config.Mqtt.Topic
+}
+
+// HandleMessage parses the given MQTT message payload and updates the current value of this sensor
+func (sensor *MqttSensor) HandleMessage(payload []byte) error {
+	conf := sensor.Config.Mqtt
+
+	var value float64
+	var err error
+	if len(conf.JsonPath) > 0 {
+		value, err = extractJsonValue(bytes.NewReader(payload), conf.JsonPath)
+	} else {
+		value, err = strconv.ParseFloat(strings.TrimSpace(string(payload)), 64)
+	}
+	if err != nil {
+		return fmt.Errorf("sensor %s: unable to parse message payload: %v", sensor.GetId(), err)
+	}
+
+	if conf.Scale != nil {
+		value = value * *conf.Scale
+	}
+
+	sensor.mu.Lock()
+	defer sensor.mu.Unlock()
+
+	sensor.Value = &value
+	sensor.LastMessage = time.Now()
+	return nil
+}
+
+func (sensor *MqttSensor) GetMovingAvg() (avg float64) {
+	return sensor.MovingAvg
+}
+
+func (sensor *MqttSensor) SetMovingAvg(avg float64) {
+	sensor.MovingAvg = avg
+}
@@ -0,0 +1,81 @@
+package sensors
+
+import (
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+)
+
+func createMqttSensor(mqttConfig configuration.MqttSensorConfig) *MqttSensor {
+	return &MqttSensor{
+		Config: configuration.SensorConfig{
+			ID:   "mqtt",
+			Mqtt: &mqttConfig,
+		},
+	}
+}
+
+func TestMqttSensor_GetValue_NoValue(t *testing.T) {
+	// GIVEN
+	sensor := createMqttSensor(configuration.MqttSensorConfig{
+		Topic: "room/temperature",
+	})
+
+	// WHEN
+	_, err := sensor.GetValue()
+
+	// THEN
+	assert.ErrorIs(t, err, ErrSensorAbsent)
+	assert.EqualError(t, err, "sensor mqtt: no value has been pushed yet: sensor value is unavailable")
+}
+
+func TestMqttSensor_HandleMessage_Plain(t *testing.T) {
+	// GIVEN
+	scale := 1000.0
+	sensor := createMqttSensor(configuration.MqttSensorConfig{
+		Topic: "room/temperature",
+		Scale: &scale,
+	})
+
+	// WHEN
+	err := sensor.HandleMessage([]byte(" 21.5\n"))
+	value, valueErr := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.NoError(t, valueErr)
+	assert.Equal(t, 21500.0, value)
+}
+
+func TestMqttSensor_HandleMessage_JsonPath(t *testing.T) {
+	// GIVEN
+	sensor := createMqttSensor(configuration.MqttSensorConfig{
+		Topic:    "room/sensor",
+		JsonPath: "$.data.temperature",
+	})
+
+	// WHEN
+	err := sensor.HandleMessage([]byte(`{"data": {"temperature": 23000}}`))
+	value, valueErr := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.NoError(t, valueErr)
+	assert.Equal(t, 23000.0, value)
+}
+
+func TestMqttSensor_HandleMessage_Invalid(t *testing.T) {
+	// GIVEN
+	sensor := createMqttSensor(configuration.MqttSensorConfig{
+		Topic: "room/temperature",
+	})
+
+	// WHEN
+	err := sensor.HandleMessage([]byte("warm"))
+
+	// THEN
+	assert.Error(t, err)
+	_, valueErr := sensor.GetValue()
+	assert.Error(t, valueErr)
+}
@@ -0,0 +1,60 @@
+package sensors
+
+import (
+	"fmt"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/plugin"
+	"github.com/markusressel/fan2go/internal/ui"
+)
+
+// PluginSensor is a sensor provided by an external driver plugin
+type PluginSensor struct {
+	Config    configuration.SensorConfig `json:"configuration"`
+	MovingAvg float64                    `json:"movingAvg"`
+
+	client *plugin.Client
+}
+
+func (sensor *PluginSensor) GetId() string {
+	return sensor.Config.ID
+}
+
+func (sensor *PluginSensor) GetConfig() configuration.SensorConfig {
+	return sensor.Config
+}
+
+func (sensor *PluginSensor) GetValue() (float64, error) {
+	value, err := sensor.client.GetValue(sensor.Config.Plugin.Sensor)
+	if err != nil {
+		return 0, fmt.Errorf("sensor %s: %v", sensor.GetId(), err)
+	}
+	return value, nil
+}
+
+func (sensor *PluginSensor) GetMovingAvg() (avg float64) {
+	return sensor.MovingAvg
+}
+
+func (sensor *PluginSensor) SetMovingAvg(avg float64) {
+	sensor.MovingAvg = avg
+}
+
+func newPluginSensor(config configuration.SensorConfig) (*PluginSensor, error) {
+	client, err := plugin.GetClient(config.Plugin.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	description, err := client.Describe()
+	if err != nil {
+		ui.Warning("Unable to query devices of plugin %s: %v", config.Plugin.ID, err)
+	} else if _, ok := description.GetSensor(config.Plugin.Sensor); !ok {
+		return nil, fmt.Errorf("plugin %s doesn't provide a sensor with id '%s'", config.Plugin.ID, config.Plugin.Sensor)
+	}
+
+	return &PluginSensor{
+		Config: config,
+		client: client,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package sensors
+
+import (
+	"net"
+	"path"
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPluginHandler struct {
+	plugin.Handler
+}
+
+func (h mockPluginHandler) Describe() plugin.Description {
+	return plugin.Description{
+		Sensors: []plugin.SensorDescription{
+			{Id: "probe1"},
+		},
+	}
+}
+
+func (h mockPluginHandler) GetValue(sensorId string) (float64, error) {
+	return 31500, nil
+}
+
+func TestPluginSensor_GetValue(t *testing.T) {
+	// GIVEN
+	socket := path.Join(t.TempDir(), "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		_ = plugin.Serve(listener, mockPluginHandler{})
+	}()
+
+	original := configuration.CurrentConfig.Plugins
+	configuration.CurrentConfig.Plugins = []configuration.PluginConfig{
+		{ID: "pdu", Socket: socket},
+	}
+	defer func() {
+		configuration.CurrentConfig.Plugins = original
+	}()
+
+	sensor, err := NewSensor(configuration.SensorConfig{
+		ID: "rack",
+		Plugin: &configuration.PluginSensorConfig{
+			ID:     "pdu",
+			Sensor: "probe1",
+		},
+	})
+	assert.NoError(t, err)
+
+	// WHEN
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 31500.0, value)
+}
@@ -0,0 +1,102 @@
+package sensors
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+)
+
+// PushSensor holds a value which is pushed by an external system through the REST api
+type PushSensor struct {
+	Config    configuration.SensorConfig `json:"configuration"`
+	MovingAvg float64                    `json:"movingAvg"`
+
+	// Value is the last pushed value
+	Value *float64 `json:"value"`
+	// LastPush is the time the last value was pushed at
+	LastPush time.Time `json:"lastPush"`
+
+	mu sync.Mutex
+}
+
+func (sensor *PushSensor) GetId() string {
+	return sensor.Config.ID
+}
+
+func (sensor *PushSensor) GetConfig() configuration.SensorConfig {
+	return sensor.Config
+}
+
+// GetValue returns the last pushed value, or the configured default if no value
+// has been pushed yet. If no value is available, or it has expired, the sensor is reported as absent.
+func (sensor *PushSensor) GetValue() (float64, error) {
+	sensor.mu.Lock()
+	defer sensor.mu.Unlock()
+
+	conf := sensor.Config.Push
+	return evaluatePushedValue(sensor.GetId(), sensor.Value, sensor.LastPush, conf.Expiry, conf.Default)
+}
+
+// evaluatePushedValue returns the given externally provided value, the default value
+// if none has been provided yet, or an error wrapping ErrSensorAbsent if no value is available or it has expired
+func evaluatePushedValue(id string, value *float64, lastUpdate time.Time, expiry time.Duration, defaultValue *float64) (float64, error) {
+	if value == nil {
+		if defaultValue != nil {
+			return *defaultValue, nil
+		}
+		return 0, fmt.Errorf("sensor %s: no value has been pushed yet: %w", id, ErrSensorAbsent)
+	}
+
+	if expiry > 0 && time.Since(lastUpdate) > expiry {
+		return *value, fmt.Errorf("sensor %s: last pushed value has expired at %s: %w", id, lastUpdate.Add(expiry).Format(time.RFC3339), ErrSensorAbsent)
+	}
+
+	return *value, nil
+}
+
+// PushSensorState is a copy of the state of a push or mqtt sensor, which can be serialized safely
+type PushSensorState struct {
+	ID       string    `json:"id"`
+	Value    *float64  `json:"value"`
+	LastPush time.Time `json:"lastPush"`
+}
+
+// State returns a copy of the current state of this sensor
+func (sensor *PushSensor) State() PushSensorState {
+	sensor.mu.Lock()
+	defer sensor.mu.Unlock()
+
+	return newPushSensorState(sensor.GetId(), sensor.Value, sensor.LastPush)
+}
+
+func newPushSensorState(id string, value *float64, lastPush time.Time) PushSensorState {
+	state := PushSensorState{
+		ID:       id,
+		LastPush: lastPush,
+	}
+	if value != nil {
+		v := *value
+		state.Value = &v
+	}
+	return state
+}
+
+// PushValue updates the current value of this sensor and returns the resulting state
+func (sensor *PushSensor) PushValue(value float64) PushSensorState {
+	sensor.mu.Lock()
+	defer sensor.mu.Unlock()
+
+	sensor.Value = &value
+	sensor.LastPush = time.Now()
+	return newPushSensorState(sensor.GetId(), sensor.Value, sensor.LastPush)
+}
+
+func (sensor *PushSensor) GetMovingAvg() (avg float64) {
+	return sensor.MovingAvg
+}
+
+func (sensor *PushSensor) SetMovingAvg(avg float64) {
+	sensor.MovingAvg = avg
+}
@@ -0,0 +1,104 @@
+package sensors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+)
+
+func createPushSensor(pushConfig configuration.PushSensorConfig) *PushSensor {
+	return &PushSensor{
+		Config: configuration.SensorConfig{
+			ID:   "push",
+			Push: &pushConfig,
+		},
+	}
+}
+
+func TestPushSensor_GetValue_NoValue(t *testing.T) {
+	// GIVEN
+	sensor := createPushSensor(configuration.PushSensorConfig{})
+
+	// WHEN
+	_, err := sensor.GetValue()
+
+	// THEN
+	assert.ErrorIs(t, err, ErrSensorAbsent)
+	assert.EqualError(t, err, "sensor push: no value has been pushed yet: sensor value is unavailable")
+}
+
+func TestPushSensor_State_NoValue(t *testing.T) {
+	// GIVEN
+	sensor := createPushSensor(configuration.PushSensorConfig{})
+
+	// WHEN
+	state := sensor.State()
+
+	// THEN
+	assert.Equal(t, PushSensorState{ID: sensor.GetId()}, state)
+}
+
+func TestPushSensor_GetValue_Default(t *testing.T) {
+	// GIVEN
+	defaultValue := 40000.0
+	sensor := createPushSensor(configuration.PushSensorConfig{
+		Default: &defaultValue,
+	})
+
+	// WHEN
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, defaultValue, value)
+}
+
+func TestPushSensor_PushValue(t *testing.T) {
+	// GIVEN
+	defaultValue := 40000.0
+	sensor := createPushSensor(configuration.PushSensorConfig{
+		Expiry:  time.Minute,
+		Default: &defaultValue,
+	})
+
+	// WHEN
+	state := sensor.PushValue(52000)
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 52000.0, value)
+	expectedValue := 52000.0
+	assert.Equal(t, PushSensorState{ID: sensor.GetId(), Value: &expectedValue, LastPush: sensor.LastPush}, state)
+}
+
+func TestPushSensor_GetValue_Expired(t *testing.T) {
+	// GIVEN
+	sensor := createPushSensor(configuration.PushSensorConfig{
+		Expiry: time.Minute,
+	})
+	sensor.PushValue(52000)
+	sensor.LastPush = time.Now().Add(-2 * time.Minute)
+
+	// WHEN
+	_, err := sensor.GetValue()
+
+	// THEN
+	assert.ErrorIs(t, err, ErrSensorAbsent)
+}
+
+func TestPushSensor_GetValue_NoExpiry(t *testing.T) {
+	// GIVEN
+	sensor := createPushSensor(configuration.PushSensorConfig{})
+	sensor.PushValue(52000)
+	sensor.LastPush = time.Now().Add(-24 * time.Hour)
+
+	// WHEN
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 52000.0, value)
+}
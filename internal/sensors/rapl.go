@@ -0,0 +1,106 @@
+package sensors
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+const (
+	// DefaultRaplPath is the powercap zone of the first CPU package
+	DefaultRaplPath = "/sys/class/powercap/intel-rapl:0"
+	// RaplMinSampleInterval is the minimum time between two reads of the energy counter,
+	// reads in between return the last computed value, so additional callers don't shorten the averaging window
+	RaplMinSampleInterval = 1 * time.Second
+
+	raplEnergyFile         = "energy_uj"
+	raplMaxEnergyRangeFile = "max_energy_range_uj"
+)
+
+// RaplSensor computes the average power draw (in milli-watts) of a RAPL powercap zone
+// between two consecutive reads of its energy counter.
+type RaplSensor struct {
+	Config    configuration.SensorConfig `json:"configuration"`
+	MovingAvg float64                    `json:"movingAvg"`
+
+	// last value of the energy counter in micro-joules
+	lastEnergy int
+	// time of the last counter read
+	lastTime time.Time
+	// last computed power value in milli-watts
+	lastValue float64
+
+	mu sync.Mutex
+}
+
+func (sensor *RaplSensor) GetId() string {
+	return sensor.Config.ID
+}
+
+func (sensor *RaplSensor) GetConfig() configuration.SensorConfig {
+	return sensor.Config
+}
+
+// GetValue returns the average power draw since the previous read of the energy counter in milli-watts.
+// The counter is read at most once per RaplMinSampleInterval, the first read only initializes it and returns 0.
+func (sensor *RaplSensor) GetValue() (float64, error) {
+	sensor.mu.Lock()
+	defer sensor.mu.Unlock()
+
+	now := util.Now()
+	if !sensor.lastTime.IsZero() && now.Sub(sensor.lastTime) < RaplMinSampleInterval {
+		return sensor.lastValue, nil
+	}
+
+	zonePath := sensor.getZonePath()
+
+	energy, err := util.ReadIntFromFile(path.Join(zonePath, raplEnergyFile))
+	if err != nil {
+		return 0, fmt.Errorf("sensor %s: unable to read energy counter: %v", sensor.GetId(), err)
+	}
+
+	if sensor.lastTime.IsZero() {
+		sensor.lastEnergy = energy
+		sensor.lastTime = now
+		return 0, nil
+	}
+
+	dt := now.Sub(sensor.lastTime).Seconds()
+	delta := energy - sensor.lastEnergy
+	if delta < 0 {
+		// the counter wrapped around
+		maxEnergyRange, err := util.ReadIntFromFile(path.Join(zonePath, raplMaxEnergyRangeFile))
+		if err != nil {
+			return sensor.lastValue, fmt.Errorf("sensor %s: energy counter wrapped around, but max range is unknown: %v", sensor.GetId(), err)
+		}
+		delta = maxEnergyRange - sensor.lastEnergy + energy
+	}
+
+	// micro-joules per second -> milli-watts
+	value := float64(delta) / dt / 1000
+
+	sensor.lastEnergy = energy
+	sensor.lastTime = now
+	sensor.lastValue = value
+
+	return value, nil
+}
+
+func (sensor *RaplSensor) GetMovingAvg() (avg float64) {
+	return sensor.MovingAvg
+}
+
+func (sensor *RaplSensor) SetMovingAvg(avg float64) {
+	sensor.MovingAvg = avg
+}
+
+func (sensor *RaplSensor) getZonePath() string {
+	if len(sensor.Config.Rapl.Path) > 0 {
+		return sensor.Config.Rapl.Path
+	}
+	return DefaultRaplPath
+}
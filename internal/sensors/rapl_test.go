@@ -0,0 +1,133 @@
+package sensors
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// useManualClock replaces the clock used to measure the time between two energy readings
+func useManualClock(t *testing.T) *util.ManualClock {
+	clock := util.NewManualClock(time.Now())
+	util.SetClock(clock)
+	t.Cleanup(func() { util.SetClock(nil) })
+	return clock
+}
+
+// helper function to create a fake powercap zone directory
+func createRaplZone(t *testing.T, energy int, maxEnergyRange int) string {
+	zonePath := t.TempDir()
+	writeRaplEnergy(t, zonePath, energy)
+	err := os.WriteFile(path.Join(zonePath, raplMaxEnergyRangeFile), []byte(strconv.Itoa(maxEnergyRange)), 0644)
+	assert.NoError(t, err)
+	return zonePath
+}
+
+func writeRaplEnergy(t *testing.T, zonePath string, energy int) {
+	err := os.WriteFile(path.Join(zonePath, raplEnergyFile), []byte(strconv.Itoa(energy)), 0644)
+	assert.NoError(t, err)
+}
+
+func createRaplSensor(zonePath string) *RaplSensor {
+	return &RaplSensor{
+		Config: configuration.SensorConfig{
+			ID: "rapl",
+			Rapl: &configuration.RaplSensorConfig{
+				Path: zonePath,
+			},
+		},
+	}
+}
+
+func TestRaplSensor_GetValue_FirstRead(t *testing.T) {
+	// GIVEN
+	zonePath := createRaplZone(t, 1000000, 262143328850)
+	sensor := createRaplSensor(zonePath)
+
+	// WHEN
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, value)
+	assert.Equal(t, 1000000, sensor.lastEnergy)
+}
+
+func TestRaplSensor_GetValue(t *testing.T) {
+	// GIVEN
+	zonePath := createRaplZone(t, 1000000, 262143328850)
+	clock := useManualClock(t)
+	sensor := createRaplSensor(zonePath)
+	_, _ = sensor.GetValue()
+	clock.Advance(2 * time.Second)
+
+	// 20 J in 2 s
+	writeRaplEnergy(t, zonePath, 21000000)
+
+	// WHEN
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.InDelta(t, 10000.0, value, 0.001)
+}
+
+func TestRaplSensor_GetValue_WithinSampleInterval(t *testing.T) {
+	// GIVEN
+	zonePath := createRaplZone(t, 1000000, 262143328850)
+	clock := useManualClock(t)
+	sensor := createRaplSensor(zonePath)
+	_, _ = sensor.GetValue()
+	clock.Advance(2 * time.Second)
+	writeRaplEnergy(t, zonePath, 21000000)
+	_, _ = sensor.GetValue()
+	clock.Advance(RaplMinSampleInterval / 2)
+
+	// 20 J in 0.5 s, which must not be sampled yet
+	writeRaplEnergy(t, zonePath, 41000000)
+
+	// WHEN
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.InDelta(t, 10000.0, value, 0.001)
+	assert.Equal(t, 21000000, sensor.lastEnergy)
+}
+
+func TestRaplSensor_GetValue_Wraparound(t *testing.T) {
+	// GIVEN
+	maxEnergyRange := 100000000
+	zonePath := createRaplZone(t, 95000000, maxEnergyRange)
+	clock := useManualClock(t)
+	sensor := createRaplSensor(zonePath)
+	_, _ = sensor.GetValue()
+	clock.Advance(1 * time.Second)
+
+	// 5 J until the wrap, 5 J after it
+	writeRaplEnergy(t, zonePath, 5000000)
+
+	// WHEN
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.InDelta(t, 10000.0, value, 0.001)
+}
+
+func TestRaplSensor_GetValue_MissingCounter(t *testing.T) {
+	// GIVEN
+	sensor := createRaplSensor(path.Join(t.TempDir(), "intel-rapl:9"))
+
+	// WHEN
+	_, err := sensor.GetValue()
+
+	// THEN
+	assert.Error(t, err)
+}
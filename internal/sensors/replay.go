@@ -0,0 +1,125 @@
+package sensors
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/trace"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+type replaySample struct {
+	// offset since the first entry of the trace
+	offset time.Duration
+	value  float64
+}
+
+// ReplaySensor replays the values of a sensor recorded to a trace file.
+// The replay starts with the first read, and follows the timing of the recording.
+type ReplaySensor struct {
+	Config    configuration.SensorConfig `json:"configuration"`
+	MovingAvg float64                    `json:"movingAvg"`
+
+	samples []replaySample
+	start   time.Time
+	// clock used to follow the timing of the recording, nil uses the global clock
+	clock util.Clock
+
+	mu sync.Mutex
+}
+
+// WithClock uses the given clock instead of the global one
+func (sensor *ReplaySensor) WithClock(clock util.Clock) *ReplaySensor {
+	sensor.clock = clock
+	return sensor
+}
+
+func (sensor *ReplaySensor) GetId() string {
+	return sensor.Config.ID
+}
+
+func (sensor *ReplaySensor) GetConfig() configuration.SensorConfig {
+	return sensor.Config
+}
+
+// GetValue returns the recorded value at the time elapsed since the first read
+func (sensor *ReplaySensor) GetValue() (float64, error) {
+	sensor.mu.Lock()
+	defer sensor.mu.Unlock()
+
+	if sensor.samples == nil {
+		samples, err := sensor.loadSamples()
+		if err != nil {
+			return 0, err
+		}
+		sensor.samples = samples
+	}
+
+	now := sensor.now()
+	if sensor.start.IsZero() {
+		sensor.start = now
+	}
+	elapsed := now.Sub(sensor.start)
+
+	last := sensor.samples[len(sensor.samples)-1]
+	if sensor.Config.Replay.Loop && last.offset > 0 {
+		elapsed = elapsed % (last.offset + 1)
+	}
+
+	// find the last sample recorded at or before elapsed
+	idx := sort.Search(len(sensor.samples), func(i int) bool {
+		return sensor.samples[i].offset > elapsed
+	})
+	if idx <= 0 {
+		return sensor.samples[0].value, nil
+	}
+	return sensor.samples[idx-1].value, nil
+}
+
+func (sensor *ReplaySensor) loadSamples() ([]replaySample, error) {
+	conf := sensor.Config.Replay
+	recordedId := conf.Sensor
+	if len(recordedId) <= 0 {
+		recordedId = sensor.Config.ID
+	}
+
+	entries, err := trace.ReadFile(conf.File)
+	if err != nil {
+		return nil, fmt.Errorf("sensor %s: unable to read trace: %v", sensor.GetId(), err)
+	}
+
+	var result []replaySample
+	for _, entry := range entries {
+		value, ok := entry.Sensors[recordedId]
+		if !ok {
+			continue
+		}
+		result = append(result, replaySample{
+			offset: entry.Time.Sub(entries[0].Time),
+			value:  value,
+		})
+	}
+
+	if len(result) <= 0 {
+		return nil, fmt.Errorf("sensor %s: trace %s contains no values of sensor %s", sensor.GetId(), conf.File, recordedId)
+	}
+	return result, nil
+}
+
+func (sensor *ReplaySensor) GetMovingAvg() (avg float64) {
+	return sensor.MovingAvg
+}
+
+func (sensor *ReplaySensor) SetMovingAvg(avg float64) {
+	sensor.MovingAvg = avg
+}
+
+func (sensor *ReplaySensor) now() time.Time {
+	if sensor.clock != nil {
+		return sensor.clock.Now()
+	}
+	return util.Now()
+}
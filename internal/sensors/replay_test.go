@@ -0,0 +1,104 @@
+package sensors
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/trace"
+	"github.com/markusressel/fan2go/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function to write a trace with a value of the "cpu" sensor every 10 seconds
+func createTrace(t *testing.T, values ...float64) string {
+	filePath := path.Join(t.TempDir(), "trace.jsonl")
+	file, err := os.Create(filePath)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writer := trace.NewWriter(file)
+	for i, value := range values {
+		err = writer.Write(trace.Entry{
+			Time:    start.Add(time.Duration(i) * 10 * time.Second),
+			Sensors: map[string]float64{"cpu": value},
+		})
+		assert.NoError(t, err)
+	}
+	return filePath
+}
+
+func createReplaySensor(t *testing.T, replayConfig configuration.ReplaySensorConfig) (*ReplaySensor, *util.ManualClock) {
+	clock := util.NewManualClock(time.Now())
+	sensor := (&ReplaySensor{
+		Config: configuration.SensorConfig{
+			ID:     "replay",
+			Replay: &replayConfig,
+		},
+	}).WithClock(clock)
+	return sensor, clock
+}
+
+func TestReplaySensor_GetValue(t *testing.T) {
+	// GIVEN
+	sensor, clock := createReplaySensor(t, configuration.ReplaySensorConfig{
+		File:   createTrace(t, 40000, 50000, 60000),
+		Sensor: "cpu",
+	})
+
+	// THEN
+	for _, expected := range []struct {
+		advance time.Duration
+		value   float64
+	}{
+		{0, 40000},
+		{9 * time.Second, 40000},
+		{1 * time.Second, 50000},
+		{15 * time.Second, 60000},
+		// the last value is kept after the end of the trace
+		{time.Minute, 60000},
+	} {
+		// WHEN
+		clock.Advance(expected.advance)
+		value, err := sensor.GetValue()
+
+		// THEN
+		assert.NoError(t, err)
+		assert.Equal(t, expected.value, value)
+	}
+}
+
+func TestReplaySensor_GetValue_Loop(t *testing.T) {
+	// GIVEN
+	sensor, clock := createReplaySensor(t, configuration.ReplaySensorConfig{
+		File:   createTrace(t, 40000, 50000, 60000),
+		Sensor: "cpu",
+		Loop:   true,
+	})
+	_, _ = sensor.GetValue()
+
+	// WHEN
+	clock.Advance(25 * time.Second)
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 40000.0, value)
+}
+
+func TestReplaySensor_GetValue_MissingSensor(t *testing.T) {
+	// GIVEN
+	filePath := createTrace(t, 40000)
+	sensor, _ := createReplaySensor(t, configuration.ReplaySensorConfig{
+		File: filePath,
+	})
+
+	// WHEN
+	_, err := sensor.GetValue()
+
+	// THEN
+	assert.EqualError(t, err, "sensor replay: trace "+filePath+" contains no values of sensor replay")
+}
@@ -0,0 +1,62 @@
+package sensors
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+const thermalZoneTempFile = "temp"
+
+// ThermalZoneSensor reads the temperature (or a trip point) of a thermal zone in /sys/class/thermal,
+// which is selected by its type.
+type ThermalZoneSensor struct {
+	Config    configuration.SensorConfig `json:"configuration"`
+	MovingAvg float64                    `json:"movingAvg"`
+
+	// path of the resolved thermal zone directory
+	zonePath string
+}
+
+func (sensor *ThermalZoneSensor) GetId() string {
+	return sensor.Config.ID
+}
+
+func (sensor *ThermalZoneSensor) GetConfig() configuration.SensorConfig {
+	return sensor.Config
+}
+
+func (sensor *ThermalZoneSensor) GetValue() (float64, error) {
+	if len(sensor.zonePath) <= 0 {
+		zonePath, err := util.FindThermalDevice(util.ThermalClassPath(configuration.CurrentConfig.SysfsRoot), util.ThermalZonePrefix, sensor.Config.ThermalZone.Type)
+		if err != nil {
+			return 0, fmt.Errorf("sensor %s: %v", sensor.GetId(), err)
+		}
+		sensor.zonePath = zonePath
+	}
+
+	fileName := thermalZoneTempFile
+	if tripPoint := sensor.Config.ThermalZone.TripPoint; tripPoint != nil {
+		fileName = fmt.Sprintf("trip_point_%d_temp", *tripPoint)
+	}
+
+	// values are already in millidegree Celsius
+	value, err := util.ReadIntFromFile(path.Join(sensor.zonePath, fileName))
+	if err != nil {
+		// the zone might have disappeared, resolve it again on the next read
+		sensor.zonePath = ""
+		return 0, fmt.Errorf("sensor %s: unable to read %s: %v", sensor.GetId(), fileName, err)
+	}
+
+	return float64(value), nil
+}
+
+func (sensor *ThermalZoneSensor) GetMovingAvg() (avg float64) {
+	return sensor.MovingAvg
+}
+
+func (sensor *ThermalZoneSensor) SetMovingAvg(avg float64) {
+	sensor.MovingAvg = avg
+}
@@ -0,0 +1,102 @@
+package sensors
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function to create a fake /sys/class/thermal tree
+func createThermalZone(t *testing.T, name string, zoneType string, files map[string]string) {
+	zonePath := path.Join(util.ThermalClassPath(configuration.CurrentConfig.SysfsRoot), name)
+	err := os.MkdirAll(zonePath, 0755)
+	assert.NoError(t, err)
+	files["type"] = zoneType + "\n"
+	for file, content := range files {
+		err = os.WriteFile(path.Join(zonePath, file), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+}
+
+func useFakeSysfsRoot(t *testing.T) {
+	original := configuration.CurrentConfig.SysfsRoot
+	configuration.CurrentConfig.SysfsRoot = t.TempDir()
+	t.Cleanup(func() {
+		configuration.CurrentConfig.SysfsRoot = original
+	})
+}
+
+func TestThermalZoneSensor_GetValue(t *testing.T) {
+	// GIVEN
+	useFakeSysfsRoot(t)
+	createThermalZone(t, "thermal_zone0", "acpitz", map[string]string{"temp": "27800\n"})
+	createThermalZone(t, "thermal_zone1", "x86_pkg_temp", map[string]string{"temp": "52000\n"})
+
+	sensor := &ThermalZoneSensor{
+		Config: configuration.SensorConfig{
+			ID: "cpu",
+			ThermalZone: &configuration.ThermalZoneSensorConfig{
+				Type: "x86_pkg_temp",
+			},
+		},
+	}
+
+	// WHEN
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 52000.0, value)
+}
+
+func TestThermalZoneSensor_GetValue_TripPoint(t *testing.T) {
+	// GIVEN
+	useFakeSysfsRoot(t)
+	createThermalZone(t, "thermal_zone0", "acpitz", map[string]string{
+		"temp":              "27800\n",
+		"trip_point_0_temp": "95000\n",
+	})
+
+	tripPoint := 0
+	sensor := &ThermalZoneSensor{
+		Config: configuration.SensorConfig{
+			ID: "acpi_critical",
+			ThermalZone: &configuration.ThermalZoneSensorConfig{
+				Type:      "acpitz",
+				TripPoint: &tripPoint,
+			},
+		},
+	}
+
+	// WHEN
+	value, err := sensor.GetValue()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 95000.0, value)
+}
+
+func TestThermalZoneSensor_GetValue_UnknownType(t *testing.T) {
+	// GIVEN
+	useFakeSysfsRoot(t)
+	createThermalZone(t, "thermal_zone0", "acpitz", map[string]string{"temp": "27800\n"})
+
+	sensor := &ThermalZoneSensor{
+		Config: configuration.SensorConfig{
+			ID: "gpu",
+			ThermalZone: &configuration.ThermalZoneSensorConfig{
+				Type: "gpu_thermal",
+			},
+		},
+	}
+
+	// WHEN
+	_, err := sensor.GetValue()
+
+	// THEN
+	assert.Error(t, err)
+}
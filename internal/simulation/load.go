@@ -0,0 +1,103 @@
+package simulation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLoadProfile idles for a minute, runs at full load for three minutes and idles again
+const DefaultLoadProfile = "1m:10%,3m:100%,2m:10%"
+
+// LoadStep is a period of constant load, in 0..1
+type LoadStep struct {
+	Duration time.Duration
+	Load     float64
+}
+
+// LoadProfile is a sequence of load steps, which is applied to the thermal model during a simulation
+type LoadProfile []LoadStep
+
+// ParseLoadProfile parses a profile of the form "<duration>:<load>,...", f.ex. "30s:0.1,5m:80%".
+// The load is given either as a fraction (0..1) or as a percentage (0%..100%).
+func ParseLoadProfile(text string) (LoadProfile, error) {
+	var result LoadProfile
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) <= 0 {
+			continue
+		}
+
+		durationText, loadText, found := strings.Cut(part, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid load step '%s', expected <duration>:<load>", part)
+		}
+
+		duration, err := time.ParseDuration(strings.TrimSpace(durationText))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration of load step '%s': %v", part, err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("invalid duration of load step '%s', must be positive", part)
+		}
+
+		load, err := parseLoad(strings.TrimSpace(loadText))
+		if err != nil {
+			return nil, fmt.Errorf("invalid load of load step '%s': %v", part, err)
+		}
+
+		result = append(result, LoadStep{
+			Duration: duration,
+			Load:     load,
+		})
+	}
+
+	if len(result) <= 0 {
+		return nil, fmt.Errorf("load profile is empty")
+	}
+	return result, nil
+}
+
+func parseLoad(text string) (float64, error) {
+	factor := 1.0
+	if strings.HasSuffix(text, "%") {
+		text = strings.TrimSuffix(text, "%")
+		factor = 0.01
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, err
+	}
+	value *= factor
+	if value < 0 || value > 1 {
+		return 0, fmt.Errorf("must be within 0..1 (0%%..100%%)")
+	}
+	return value, nil
+}
+
+// Duration returns the total duration of the profile
+func (p LoadProfile) Duration() time.Duration {
+	var result time.Duration
+	for _, step := range p {
+		result += step.Duration
+	}
+	return result
+}
+
+// LoadAt returns the load at the given time since the start of the profile,
+// the load of the last step is kept after the end of the profile
+func (p LoadProfile) LoadAt(t time.Duration) float64 {
+	var end time.Duration
+	for _, step := range p {
+		end += step.Duration
+		if t < end {
+			return step.Load
+		}
+	}
+	if len(p) <= 0 {
+		return 0
+	}
+	return p[len(p)-1].Load
+}
@@ -0,0 +1,49 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLoadProfile(t *testing.T) {
+	// GIVEN
+	text := "30s:0.1, 2m:80%,1m30s:1"
+
+	// WHEN
+	profile, err := ParseLoadProfile(text)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, LoadProfile{
+		{Duration: 30 * time.Second, Load: 0.1},
+		{Duration: 2 * time.Minute, Load: 0.8},
+		{Duration: 90 * time.Second, Load: 1},
+	}, profile)
+	assert.Equal(t, 4*time.Minute, profile.Duration())
+}
+
+func TestParseLoadProfile_Invalid(t *testing.T) {
+	for _, text := range []string{"", "30s", "abc:0.5", "-1s:0.5", "30s:1.5", "30s:x"} {
+		// WHEN
+		_, err := ParseLoadProfile(text)
+
+		// THEN
+		assert.Error(t, err, text)
+	}
+}
+
+func TestLoadProfile_LoadAt(t *testing.T) {
+	// GIVEN
+	profile := LoadProfile{
+		{Duration: 10 * time.Second, Load: 0.1},
+		{Duration: 10 * time.Second, Load: 0.9},
+	}
+
+	// THEN
+	assert.Equal(t, 0.1, profile.LoadAt(0))
+	assert.Equal(t, 0.1, profile.LoadAt(9*time.Second))
+	assert.Equal(t, 0.9, profile.LoadAt(10*time.Second))
+	assert.Equal(t, 0.9, profile.LoadAt(time.Minute))
+}
@@ -0,0 +1,67 @@
+package simulation
+
+import (
+	"os"
+
+	"github.com/markusressel/fan2go/internal/fans"
+)
+
+// memoryPersistence keeps the data of simulated fans in memory,
+// so a simulation never modifies the persisted data of the real fans
+type memoryPersistence struct {
+	fanPwmData map[string]map[int]float64
+	fanPwmMap  map[string]map[int]int
+}
+
+func newMemoryPersistence() *memoryPersistence {
+	return &memoryPersistence{
+		fanPwmData: map[string]map[int]float64{},
+		fanPwmMap:  map[string]map[int]int{},
+	}
+}
+
+func (p *memoryPersistence) LoadFanPwmData(fan fans.Fan) (map[int]float64, error) {
+	data, ok := p.fanPwmData[fan.GetId()]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	result := map[int]float64{}
+	for pwm, rpm := range data {
+		result[pwm] = rpm
+	}
+	return result, nil
+}
+
+func (p *memoryPersistence) SaveFanPwmData(fan fans.Fan) (err error) {
+	data := map[int]float64{}
+	if curveData := fan.GetFanCurveData(); curveData != nil {
+		for pwm, rpm := range *curveData {
+			data[pwm] = rpm
+		}
+	}
+	p.fanPwmData[fan.GetId()] = data
+	return nil
+}
+
+func (p *memoryPersistence) DeleteFanPwmData(fan fans.Fan) (err error) {
+	delete(p.fanPwmData, fan.GetId())
+	return nil
+}
+
+func (p *memoryPersistence) LoadFanPwmMap(fanId string) (map[int]int, error) {
+	pwmMap, ok := p.fanPwmMap[fanId]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return pwmMap, nil
+}
+
+func (p *memoryPersistence) SaveFanPwmMap(fanId string, pwmMap map[int]int) (err error) {
+	p.fanPwmMap[fanId] = pwmMap
+	return nil
+}
+
+func (p *memoryPersistence) DeleteFanPwmMap(fanId string) (err error) {
+	delete(p.fanPwmMap, fanId)
+	return nil
+}
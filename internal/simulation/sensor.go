@@ -0,0 +1,35 @@
+package simulation
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+)
+
+// SimulatedSensor replaces a configured sensor when running a simulation,
+// its value is taken from a node of the thermal model
+type SimulatedSensor struct {
+	Config    configuration.SensorConfig `json:"config"`
+	MovingAvg float64                    `json:"movingAvg"`
+
+	node *ThermalNode
+}
+
+func (s *SimulatedSensor) GetId() string {
+	return s.Config.ID
+}
+
+func (s *SimulatedSensor) GetConfig() configuration.SensorConfig {
+	return s.Config
+}
+
+func (s *SimulatedSensor) GetValue() (float64, error) {
+	// sensor values are in milli-degrees
+	return s.node.Temperature * 1000, nil
+}
+
+func (s *SimulatedSensor) GetMovingAvg() (avg float64) {
+	return s.MovingAvg
+}
+
+func (s *SimulatedSensor) SetMovingAvg(avg float64) {
+	s.MovingAvg = avg
+}
@@ -0,0 +1,321 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/persistence"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+const (
+	// SyntheticMaxRpm is the highest RPM of the synthetic fan curve
+	SyntheticMaxRpm = 2000
+	// relative speed of a fan with a synthetic fan curve at its startPwm
+	syntheticStartRpmFactor = 0.2
+
+	defaultTickRate    = 100 * time.Millisecond
+	defaultPollingRate = 200 * time.Millisecond
+)
+
+// Sample is the state of the simulation at a point in time
+type Sample struct {
+	// Time since the start of the simulation
+	Time time.Duration
+	Load float64
+	// Temperatures of all sensors in °C
+	Temperatures map[string]float64
+	Pwm          map[string]int
+	Rpm          map[string]int
+}
+
+// Simulation runs the fan controllers of a configuration against a thermal model, faster than real time
+type Simulation struct {
+	config      configuration.Configuration
+	profile     LoadProfile
+	sampleRate  time.Duration
+	clock       *util.ManualClock
+	model       *ThermalModel
+	sensors     map[string]sensors.Sensor
+	fans        map[string]*fans.SimulatedFan
+	controllers []controller.FanController
+}
+
+// NewSimulation replaces all sensors, curves and fans of the given configuration with simulated ones,
+// except for replay sensors, which replay their recorded values following the simulated time.
+// Sensors and curves are registered globally, since curves look up their inputs by id.
+// All time dependent components use the clock of the simulation, the global clock is left untouched.
+// The fan curves of the simulated fans are loaded from the given persistence, if available,
+// a synthetic fan curve is used otherwise.
+func NewSimulation(
+	config configuration.Configuration,
+	calibration persistence.Persistence,
+	profile LoadProfile,
+	model *ThermalModel,
+	sampleRate time.Duration,
+) (*Simulation, error) {
+	s := &Simulation{
+		config:     config,
+		profile:    profile,
+		sampleRate: sampleRate,
+		clock:      util.NewManualClock(time.Now()),
+		model:      model,
+		sensors:    map[string]sensors.Sensor{},
+		fans:       map[string]*fans.SimulatedFan{},
+	}
+
+	for _, sensorConfig := range config.Sensors {
+		var sensor sensors.Sensor = &SimulatedSensor{
+			Config: sensorConfig,
+			node:   model.AddNode(sensorConfig.ID),
+		}
+		if sensorConfig.Replay != nil {
+			// recorded values are replayed as is, following the simulated time
+			delete(model.Nodes, sensorConfig.ID)
+			sensor = (&sensors.ReplaySensor{
+				Config: sensorConfig,
+			}).WithClock(s.clock)
+		}
+		s.sensors[sensorConfig.ID] = sensor
+		sensors.SensorMap[sensorConfig.ID] = sensor
+	}
+
+	for _, curveConfig := range config.Curves {
+		curve, err := curves.NewSpeedCurve(curveConfig)
+		if err != nil {
+			return nil, err
+		}
+		if pidCurve, ok := curve.(*curves.PidSpeedCurve); ok {
+			pidCurve.WithClock(s.clock)
+		}
+		curves.ReplaceSpeedCurve(curve)
+	}
+
+	memory := newMemoryPersistence()
+	for _, fanConfig := range config.Fans {
+		fan := fans.NewSimulatedFan(fanConfig, map[int]float64{})
+
+		fanCurve := loadFanCurve(calibration, fan)
+		if fanCurve == nil {
+			ui.Info("Using synthetic fan curve for fan '%s'", fanConfig.ID)
+			fanCurve = SyntheticFanCurve(fan.GetStartPwm(), SyntheticMaxRpm)
+		} else {
+			ui.Info("Using measured fan curve for fan '%s'", fanConfig.ID)
+		}
+		err := fan.AttachFanCurveData(&fanCurve)
+		if err != nil {
+			return nil, err
+		}
+		err = memory.SaveFanPwmData(fan)
+		if err != nil {
+			return nil, err
+		}
+
+		linked := map[string]bool{}
+		for _, sensorId := range getCurveSensors(config.Curves, fanConfig.Curve, map[string]bool{}) {
+			if node, ok := model.Nodes[sensorId]; ok && !linked[sensorId] {
+				node.Fans = append(node.Fans, fan)
+				linked[sensorId] = true
+			}
+		}
+
+		s.fans[fanConfig.ID] = fan
+
+		controlLoop, err := controller.NewControlLoop(fanConfig.ControlLoop, s.clock)
+		if err != nil {
+			return nil, fmt.Errorf("fan %s: %v", fanConfig.ID, err)
+		}
+		s.controllers = append(s.controllers, controller.NewFanController(memory, fan, controlLoop, s.tickRate()))
+	}
+
+	for _, c := range s.controllers {
+		err := c.Initialize()
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize controller of fan %s: %v", c.GetFanId(), err)
+		}
+	}
+
+	return s, nil
+}
+
+// Run runs the simulation over the whole load profile and returns the recorded samples
+func (s *Simulation) Run() ([]Sample, error) {
+	step := s.tickRate()
+	sensorPollingRate := positiveOrDefault(s.config.TempSensorPollingRate, defaultPollingRate)
+	rpmPollingRate := positiveOrDefault(s.config.RpmPollingRate, defaultPollingRate)
+	sampleRate := positiveOrDefault(s.sampleRate, time.Second)
+
+	for _, sensor := range s.sensors {
+		value, _ := sensor.GetValue()
+		sensor.SetMovingAvg(value)
+	}
+
+	var result []Sample
+	var elapsed, nextSensorUpdate, nextRpmUpdate, nextSample time.Duration
+	for elapsed <= s.profile.Duration() {
+		load := s.profile.LoadAt(elapsed)
+
+		if elapsed >= nextSensorUpdate {
+			s.updateSensors()
+			nextSensorUpdate += sensorPollingRate
+		}
+		if elapsed >= nextRpmUpdate {
+			s.updateRpm()
+			nextRpmUpdate += rpmPollingRate
+		}
+
+		for _, c := range s.controllers {
+			err := c.UpdateFanSpeed()
+			if err != nil {
+				return result, fmt.Errorf("fan %s: %v", c.GetFanId(), err)
+			}
+		}
+
+		if elapsed >= nextSample {
+			result = append(result, s.createSample(elapsed, load))
+			nextSample += sampleRate
+		}
+
+		s.model.Step(step, load)
+		s.clock.Advance(step)
+		elapsed += step
+	}
+
+	return result, nil
+}
+
+// SensorIds returns the sorted ids of all simulated sensors
+func (s *Simulation) SensorIds() []string {
+	result := make([]string, 0, len(s.sensors))
+	for id := range s.sensors {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// FanIds returns the sorted ids of all simulated fans
+func (s *Simulation) FanIds() []string {
+	result := make([]string, 0, len(s.fans))
+	for id := range s.fans {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (s *Simulation) tickRate() time.Duration {
+	return positiveOrDefault(s.config.ControllerAdjustmentTickRate, defaultTickRate)
+}
+
+func (s *Simulation) updateSensors() {
+	windowSize := max(s.config.TempRollingWindowSize, 1)
+	for _, sensor := range s.sensors {
+		value, _ := sensor.GetValue()
+		sensor.SetMovingAvg(util.UpdateSimpleMovingAvg(sensor.GetMovingAvg(), windowSize, value))
+	}
+}
+
+func (s *Simulation) updateRpm() {
+	windowSize := max(s.config.RpmRollingWindowSize, 1)
+	for _, fan := range s.fans {
+		rpm, _ := fan.GetRpm()
+		fan.SetRpmAvg(util.UpdateSimpleMovingAvg(fan.GetRpmAvg(), windowSize, float64(rpm)))
+	}
+}
+
+func (s *Simulation) createSample(elapsed time.Duration, load float64) Sample {
+	sample := Sample{
+		Time:         elapsed,
+		Load:         load,
+		Temperatures: map[string]float64{},
+		Pwm:          map[string]int{},
+		Rpm:          map[string]int{},
+	}
+	for id, sensor := range s.sensors {
+		value, err := sensor.GetValue()
+		if err != nil {
+			continue
+		}
+		sample.Temperatures[id] = value / 1000
+	}
+	for id, fan := range s.fans {
+		sample.Pwm[id], _ = fan.GetPwm()
+		sample.Rpm[id], _ = fan.GetRpm()
+	}
+	return sample
+}
+
+// SyntheticFanCurve creates a pwm -> rpm curve of a fan, which stands still below startPwm
+// and speeds up linearly from there up to maxRpm at the maximum pwm value
+func SyntheticFanCurve(startPwm int, maxRpm float64) map[int]float64 {
+	startPwm = int(util.Coerce(float64(startPwm), fans.MinPwmValue+1, fans.MaxPwmValue))
+	startRpm := maxRpm * syntheticStartRpmFactor
+
+	result := map[int]float64{}
+	for pwm := fans.MinPwmValue; pwm <= fans.MaxPwmValue; pwm++ {
+		if pwm < startPwm {
+			result[pwm] = 0
+			continue
+		}
+		ratio := 1.0
+		if startPwm < fans.MaxPwmValue {
+			ratio = float64(pwm-startPwm) / float64(fans.MaxPwmValue-startPwm)
+		}
+		result[pwm] = startRpm + ratio*(maxRpm-startRpm)
+	}
+	return result
+}
+
+// loadFanCurve returns the measured fan curve of the given fan, or nil if there is none
+func loadFanCurve(calibration persistence.Persistence, fan fans.Fan) map[int]float64 {
+	if calibration == nil {
+		return nil
+	}
+	fanCurve, err := calibration.LoadFanPwmData(fan)
+	if err != nil || len(fanCurve) <= 0 {
+		return nil
+	}
+	return fanCurve
+}
+
+// getCurveSensors returns the ids of all sensors the given curve depends on
+func getCurveSensors(curveConfigs []configuration.CurveConfig, curveId string, visited map[string]bool) []string {
+	if visited[curveId] {
+		return nil
+	}
+	visited[curveId] = true
+
+	var result []string
+	for _, curveConfig := range curveConfigs {
+		if curveConfig.ID != curveId {
+			continue
+		}
+		if curveConfig.Linear != nil {
+			result = append(result, curveConfig.Linear.Sensor)
+		}
+		if curveConfig.PID != nil {
+			result = append(result, curveConfig.PID.Sensor)
+		}
+		if curveConfig.Function != nil {
+			for _, id := range curveConfig.Function.Curves {
+				result = append(result, getCurveSensors(curveConfigs, id, visited)...)
+			}
+		}
+	}
+	return result
+}
+
+func positiveOrDefault(value time.Duration, defaultValue time.Duration) time.Duration {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
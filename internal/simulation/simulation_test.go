@@ -0,0 +1,165 @@
+package simulation
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/trace"
+	"github.com/markusressel/fan2go/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func resetMaps(t *testing.T) {
+	sensors.SensorMap = map[string]sensors.Sensor{}
+	curves.SpeedCurveMap = map[string]curves.SpeedCurve{}
+	fans.FanMap = map[string]fans.Fan{}
+	t.Cleanup(func() {
+		sensors.SensorMap = map[string]sensors.Sensor{}
+		curves.SpeedCurveMap = map[string]curves.SpeedCurve{}
+		fans.FanMap = map[string]fans.Fan{}
+	})
+}
+
+func createConfig() configuration.Configuration {
+	return configuration.Configuration{
+		TempSensorPollingRate:        200 * time.Millisecond,
+		TempRollingWindowSize:        10,
+		RpmPollingRate:               1 * time.Second,
+		RpmRollingWindowSize:         10,
+		ControllerAdjustmentTickRate: 200 * time.Millisecond,
+		Sensors: []configuration.SensorConfig{
+			{
+				ID: "cpu",
+				File: &configuration.FileSensorConfig{
+					Path: "/does/not/exist",
+				},
+			},
+		},
+		Curves: []configuration.CurveConfig{
+			{
+				ID: "cpu_curve",
+				Linear: &configuration.LinearCurveConfig{
+					Sensor: "cpu",
+					Min:    30,
+					Max:    70,
+				},
+			},
+			{
+				ID: "max_curve",
+				Function: &configuration.FunctionCurveConfig{
+					Type:   configuration.FunctionMaximum,
+					Curves: []string{"cpu_curve"},
+				},
+			},
+		},
+		Fans: []configuration.FanConfig{
+			{
+				ID:    "cpu_fan",
+				Curve: "max_curve",
+				HwMon: &configuration.HwMonFanConfig{
+					Platform: "nct6798",
+					Index:    1,
+				},
+			},
+		},
+	}
+}
+
+func TestNewSimulation(t *testing.T) {
+	// GIVEN
+	resetMaps(t)
+	config := createConfig()
+	model := NewThermalModel(DefaultThermalModelConfig)
+
+	// WHEN
+	s, err := NewSimulation(config, nil, LoadProfile{{Duration: time.Minute, Load: 1}}, model, time.Second)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cpu"}, s.SensorIds())
+	assert.Equal(t, []string{"cpu_fan"}, s.FanIds())
+	assert.IsType(t, &SimulatedSensor{}, sensors.SensorMap["cpu"])
+	// simulated fans are only known to the simulation
+	assert.Empty(t, fans.FanMap)
+	// the fan is linked through the function curve
+	assert.Equal(t, []*fans.SimulatedFan{s.fans["cpu_fan"]}, model.Nodes["cpu"].Fans)
+}
+
+func TestSimulation_Run(t *testing.T) {
+	// GIVEN
+	resetMaps(t)
+	config := createConfig()
+	model := NewThermalModel(DefaultThermalModelConfig)
+	profile := LoadProfile{
+		{Duration: 30 * time.Second, Load: 0},
+		{Duration: 5 * time.Minute, Load: 1},
+	}
+	s, err := NewSimulation(config, nil, profile, model, 10*time.Second)
+	assert.NoError(t, err)
+
+	// WHEN
+	start := time.Now()
+	samples, err := s.Run()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), profile.Duration())
+	// only the clock of the simulation is advanced
+	assert.WithinDuration(t, time.Now(), util.Now(), time.Since(start))
+	assert.Len(t, samples, 34)
+
+	first := samples[0]
+	assert.Equal(t, time.Duration(0), first.Time)
+	assert.Equal(t, DefaultThermalModelConfig.Ambient, first.Temperatures["cpu"])
+	assert.Equal(t, 0, first.Pwm["cpu_fan"])
+
+	last := samples[len(samples)-1]
+	assert.Equal(t, 5*time.Minute+30*time.Second, last.Time)
+	assert.Equal(t, 1.0, last.Load)
+	// the fan spins up under load and keeps the temperature below the uncooled steady state
+	assert.Greater(t, last.Pwm["cpu_fan"], 0)
+	assert.Greater(t, last.Rpm["cpu_fan"], 0)
+	assert.Greater(t, last.Temperatures["cpu"], DefaultThermalModelConfig.Ambient)
+	assert.Less(t, last.Temperatures["cpu"], model.SteadyStateTemperature(1, 0))
+}
+
+func TestSimulation_Run_ReplaySensor(t *testing.T) {
+	// GIVEN
+	resetMaps(t)
+	filePath := path.Join(t.TempDir(), "trace.jsonl")
+	file, err := os.Create(filePath)
+	assert.NoError(t, err)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writer := trace.NewWriter(file)
+	_ = writer.Write(trace.Entry{Time: start, Sensors: map[string]float64{"cpu": 40000}})
+	_ = writer.Write(trace.Entry{Time: start.Add(10 * time.Second), Sensors: map[string]float64{"cpu": 80000}})
+	_ = file.Close()
+
+	config := createConfig()
+	config.Sensors[0] = configuration.SensorConfig{
+		ID: "cpu",
+		Replay: &configuration.ReplaySensorConfig{
+			File: filePath,
+		},
+	}
+	model := NewThermalModel(DefaultThermalModelConfig)
+	s, err := NewSimulation(config, nil, LoadProfile{{Duration: time.Minute, Load: 0}}, model, 10*time.Second)
+	assert.NoError(t, err)
+
+	// WHEN
+	samples, err := s.Run()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Empty(t, model.Nodes)
+	assert.Equal(t, 40.0, samples[0].Temperatures["cpu"])
+	assert.Equal(t, 80.0, samples[1].Temperatures["cpu"])
+	// the replayed temperature is above the max of the curve
+	assert.Equal(t, fans.MaxPwmValue, samples[len(samples)-1].Pwm["cpu_fan"])
+}
@@ -0,0 +1,98 @@
+package simulation
+
+import (
+	"math"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/fans"
+)
+
+// ThermalModelConfig defines the parameters of the first order thermal model
+type ThermalModelConfig struct {
+	// Ambient temperature in °C, which is reached without any load
+	Ambient float64
+	// LoadRise is the temperature rise in °C above ambient at full load, without any cooling
+	LoadRise float64
+	// Cooling is the fraction of LoadRise, which is removed when all linked fans run at their maximum RPM
+	Cooling float64
+	// TimeConstant is the time it takes to cover ~63% of the distance to the steady state temperature
+	TimeConstant time.Duration
+}
+
+// DefaultThermalModelConfig models a typical CPU cooler
+var DefaultThermalModelConfig = ThermalModelConfig{
+	Ambient:      25,
+	LoadRise:     80,
+	Cooling:      0.6,
+	TimeConstant: 30 * time.Second,
+}
+
+// ThermalNode is the simulated temperature of a single sensor, which is cooled by the linked fans
+type ThermalNode struct {
+	// Temperature in °C
+	Temperature float64
+	Fans        []*fans.SimulatedFan
+}
+
+// ThermalModel is a first order thermal model: the temperature of each node approaches
+// ambient + load * loadRise * (1 - cooling * airflow) exponentially,
+// where airflow (0..1) is the average relative RPM of the fans linked to the node.
+type ThermalModel struct {
+	Config ThermalModelConfig
+	Nodes  map[string]*ThermalNode
+}
+
+func NewThermalModel(config ThermalModelConfig) *ThermalModel {
+	return &ThermalModel{
+		Config: config,
+		Nodes:  map[string]*ThermalNode{},
+	}
+}
+
+// AddNode adds a node for the given sensor, starting at ambient temperature
+func (m *ThermalModel) AddNode(sensorId string) *ThermalNode {
+	node := &ThermalNode{
+		Temperature: m.Config.Ambient,
+	}
+	m.Nodes[sensorId] = node
+	return node
+}
+
+// Step advances the model by dt at the given load (0..1)
+func (m *ThermalModel) Step(dt time.Duration, load float64) {
+	alpha := 1.0
+	if m.Config.TimeConstant > 0 {
+		alpha = 1 - math.Exp(-dt.Seconds()/m.Config.TimeConstant.Seconds())
+	}
+
+	for _, node := range m.Nodes {
+		target := m.SteadyStateTemperature(load, node.airflow())
+		node.Temperature += (target - node.Temperature) * alpha
+	}
+}
+
+// SteadyStateTemperature returns the temperature a node settles at for the given load and airflow (both 0..1)
+func (m *ThermalModel) SteadyStateTemperature(load float64, airflow float64) float64 {
+	return m.Config.Ambient + load*m.Config.LoadRise*(1-m.Config.Cooling*airflow)
+}
+
+// airflow returns the average relative speed (0..1) of all fans linked to this node
+func (n *ThermalNode) airflow() float64 {
+	if len(n.Fans) <= 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, fan := range n.Fans {
+		maxRpm := fan.GetMaxRpm()
+		if maxRpm <= 0 {
+			continue
+		}
+		rpm, err := fan.GetRpm()
+		if err != nil {
+			continue
+		}
+		sum += math.Min(float64(rpm)/maxRpm, 1)
+	}
+	return sum / float64(len(n.Fans))
+}
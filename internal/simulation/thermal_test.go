@@ -0,0 +1,66 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThermalModel_Step(t *testing.T) {
+	// GIVEN
+	model := NewThermalModel(ThermalModelConfig{
+		Ambient:      20,
+		LoadRise:     60,
+		Cooling:      0.5,
+		TimeConstant: 10 * time.Second,
+	})
+	node := model.AddNode("cpu")
+
+	// WHEN
+	model.Step(10*time.Second, 1)
+
+	// THEN
+	// one time constant covers ~63% of the distance to 80°C
+	assert.InDelta(t, 20+60*0.632, node.Temperature, 0.1)
+
+	// WHEN
+	model.Step(10*time.Minute, 1)
+
+	// THEN
+	assert.InDelta(t, 80, node.Temperature, 0.001)
+}
+
+func TestThermalModel_Step_Cooling(t *testing.T) {
+	// GIVEN
+	model := NewThermalModel(ThermalModelConfig{
+		Ambient:      20,
+		LoadRise:     60,
+		Cooling:      0.5,
+		TimeConstant: 10 * time.Second,
+	})
+	node := model.AddNode("cpu")
+	fan := fans.NewSimulatedFan(configuration.FanConfig{ID: "fan"}, SyntheticFanCurve(1, 1000))
+	_ = fan.SetPwm(fans.MaxPwmValue)
+	node.Fans = append(node.Fans, fan)
+
+	// WHEN
+	model.Step(10*time.Minute, 1)
+
+	// THEN
+	assert.InDelta(t, 50, node.Temperature, 0.001)
+}
+
+func TestSyntheticFanCurve(t *testing.T) {
+	// WHEN
+	curve := SyntheticFanCurve(51, 1000)
+
+	// THEN
+	assert.Len(t, curve, 256)
+	assert.Equal(t, 0.0, curve[0])
+	assert.Equal(t, 0.0, curve[50])
+	assert.Equal(t, 200.0, curve[51])
+	assert.Equal(t, 1000.0, curve[255])
+}
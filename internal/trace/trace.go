@@ -0,0 +1,80 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// Entry is a single recording of the values of all sensors and fans.
+// A trace file contains one entry per line, encoded as JSON.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Sensors contains the raw value of each sensor, f.ex. milli-degrees
+	Sensors map[string]float64  `json:"sensors"`
+	Fans    map[string]FanEntry `json:"fans"`
+}
+
+type FanEntry struct {
+	Pwm int  `json:"pwm"`
+	Rpm *int `json:"rpm,omitempty"`
+}
+
+type Writer struct {
+	encoder *json.Encoder
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// Write appends the given entry as a single line
+func (w *Writer) Write(entry Entry) error {
+	return w.encoder.Encode(entry)
+}
+
+// Read reads all entries of a trace, sorted by time
+func Read(r io.Reader) ([]Entry, error) {
+	var result []Entry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) <= 0 {
+			continue
+		}
+		entry := Entry{}
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trace entry in line %d: %v", line, err)
+		}
+		result = append(result, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// ReadFile reads all entries of the trace file at the given path, sorted by time
+func ReadFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
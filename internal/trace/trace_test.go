@@ -0,0 +1,54 @@
+package trace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRead(t *testing.T) {
+	// GIVEN
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rpm := 1200
+	entries := []Entry{
+		{
+			Time:    start.Add(time.Second),
+			Sensors: map[string]float64{"cpu": 51000},
+			Fans:    map[string]FanEntry{"cpu_fan": {Pwm: 120, Rpm: &rpm}},
+		},
+		{
+			Time:    start,
+			Sensors: map[string]float64{"cpu": 50000},
+			Fans:    map[string]FanEntry{"cpu_fan": {Pwm: 100}},
+		},
+	}
+	var buf bytes.Buffer
+	writer := NewWriter(&buf)
+
+	// WHEN
+	for _, entry := range entries {
+		assert.NoError(t, writer.Write(entry))
+	}
+	result, err := Read(&buf)
+
+	// THEN
+	assert.NoError(t, err)
+	// entries are sorted by time
+	assert.Equal(t, []Entry{entries[1], entries[0]}, result)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	// GIVEN
+	text := `{"time": "2024-01-01T00:00:00Z", "sensors": {"cpu": 50000}}
+not json
+`
+
+	// WHEN
+	_, err := Read(strings.NewReader(text))
+
+	// THEN
+	assert.ErrorContains(t, err, "invalid trace entry in line 2")
+}
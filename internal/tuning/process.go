@@ -0,0 +1,111 @@
+package tuning
+
+import (
+	"math"
+
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/ui"
+)
+
+type fanState struct {
+	pwm        int
+	pwmEnabled *fans.ControlMode
+}
+
+// FanProcess drives the given fans and measures the temperature of the given sensor
+type FanProcess struct {
+	Sensor sensors.Sensor
+	Fans   []fans.Fan
+
+	original map[string]fanState
+}
+
+func NewFanProcess(sensor sensors.Sensor, fanList []fans.Fan) *FanProcess {
+	p := &FanProcess{
+		Sensor:   sensor,
+		Fans:     fanList,
+		original: map[string]fanState{},
+	}
+
+	for _, fan := range fanList {
+		state := fanState{}
+		if pwm, err := fan.GetPwm(); err == nil {
+			state.pwm = pwm
+		} else {
+			state.pwm = fans.MaxPwmValue
+		}
+		if fan.Supports(fans.FeatureControlMode) {
+			if pwmEnabled, err := fan.GetPwmEnabled(); err == nil {
+				mode := fans.ControlMode(pwmEnabled)
+				state.pwmEnabled = &mode
+			}
+		}
+		p.original[fan.GetId()] = state
+	}
+
+	return p
+}
+
+// Measure returns the temperature of the sensor in °C
+func (p *FanProcess) Measure() (float64, error) {
+	var value float64
+	var err error
+	if backgroundSensor, ok := p.Sensor.(sensors.BackgroundSensor); ok {
+		// the daemon isn't running, so nothing updates the sensor in the background
+		value, err = backgroundSensor.Fetch()
+	} else {
+		value, err = p.Sensor.GetValue()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return value / 1000, nil
+}
+
+// Apply maps the given output (0..1) onto the pwm range of each fan, like the fan controller does with curve values
+func (p *FanProcess) Apply(output float64) error {
+	for _, fan := range p.Fans {
+		if fan.Supports(fans.FeatureControlMode) {
+			err := fan.SetPwmEnabled(fans.ControlModePWM)
+			if err != nil {
+				return err
+			}
+		}
+
+		minPwm := fan.GetMinPwm()
+		maxPwm := fan.GetMaxPwm()
+		pwm := minPwm + int(math.Round(output*float64(maxPwm-minPwm)))
+		err := fan.SetPwm(pwm)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore resets all fans to the state they were in before the experiment.
+// If the experiment was aborted because the temperature exceeded its limit, all fans are set to max pwm instead.
+func (p *FanProcess) Restore(limitExceeded bool) {
+	for _, fan := range p.Fans {
+		if limitExceeded {
+			err := fan.SetPwm(fans.MaxPwmValue)
+			if err != nil {
+				ui.Warning("Unable to set pwm of fan %s to max: %v", fan.GetId(), err)
+			}
+			continue
+		}
+
+		state := p.original[fan.GetId()]
+		err := fan.SetPwm(state.pwm)
+		if err != nil {
+			ui.Warning("Unable to restore pwm of fan %s: %v", fan.GetId(), err)
+		}
+		if state.pwmEnabled != nil {
+			err = fan.SetPwmEnabled(*state.pwmEnabled)
+			if err != nil {
+				ui.Warning("Unable to restore pwm_enable of fan %s: %v", fan.GetId(), err)
+			}
+		}
+	}
+}
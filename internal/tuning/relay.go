@@ -0,0 +1,190 @@
+package tuning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/markusressel/fan2go/internal/util"
+)
+
+// ErrLimitExceeded is returned by RelayExperiment.Run, if the process value exceeded the configured limit
+var ErrLimitExceeded = errors.New("aborted")
+
+// Process is the system under test of a relay experiment
+type Process interface {
+	// Measure returns the current process value, f.ex. a temperature in °C
+	Measure() (float64, error)
+	// Apply sets the output of the controller, in 0..1
+	Apply(output float64) error
+}
+
+// RelayConfig defines the parameters of a relay feedback experiment
+type RelayConfig struct {
+	// SetPoint the process value oscillates around
+	SetPoint float64
+	// High is the output applied while the process value is above the set point, in 0..1
+	High float64
+	// Low is the output applied while the process value is below the set point, in 0..1
+	Low float64
+	// Hysteresis around the set point, which prevents switching caused by sensor noise
+	Hysteresis float64
+	// Cycles is the number of oscillations to measure, after the first one has been discarded
+	Cycles int
+	// Interval between two measurements
+	Interval time.Duration
+	// Timeout of the whole experiment
+	Timeout time.Duration
+	// Limit aborts the experiment if the process value exceeds it
+	Limit float64
+}
+
+// RelayResult contains the characteristics of the oscillation caused by the relay
+type RelayResult struct {
+	// Amplitude of the oscillation of the process value
+	Amplitude float64
+	// Period of the oscillation
+	Period time.Duration
+	// UltimateGain is the gain at which a proportional controller would cause a sustained oscillation
+	UltimateGain float64
+}
+
+// RelayExperiment implements the relay feedback experiment by Åström and Hägglund:
+// the output is switched between High and Low whenever the process value crosses the set point,
+// which causes an oscillation whose amplitude and period characterize the process.
+type RelayExperiment struct {
+	Config  RelayConfig
+	Process Process
+
+	// sleep is a variable, so it can be replaced in tests
+	sleep func(d time.Duration)
+}
+
+func NewRelayExperiment(config RelayConfig, process Process) *RelayExperiment {
+	return &RelayExperiment{
+		Config:  config,
+		Process: process,
+		sleep:   time.Sleep,
+	}
+}
+
+// Run performs the experiment until the configured number of cycles has been measured
+func (e *RelayExperiment) Run(ctx context.Context) (result RelayResult, err error) {
+	c := e.Config
+	if c.Cycles <= 0 {
+		return result, fmt.Errorf("invalid number of cycles, must be > 0")
+	}
+	if c.High <= c.Low {
+		return result, fmt.Errorf("invalid relay output, high must be > low")
+	}
+
+	value, err := e.Process.Measure()
+	if err != nil {
+		return result, err
+	}
+	high := value > c.SetPoint
+	err = e.apply(high)
+	if err != nil {
+		return result, err
+	}
+
+	start := util.Now()
+	var risingSwitches []time.Time
+	var peaks, valleys []float64
+	extreme := value
+
+	// one more rising switch than cycles is needed to measure the periods, and the first cycle is discarded
+	for len(risingSwitches) < c.Cycles+2 {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
+
+		e.sleep(c.Interval)
+		now := util.Now()
+		if c.Timeout > 0 && now.Sub(start) > c.Timeout {
+			return result, fmt.Errorf("timeout after %v, no sustained oscillation detected", c.Timeout)
+		}
+
+		value, err = e.Process.Measure()
+		if err != nil {
+			return result, err
+		}
+		if c.Limit > 0 && value > c.Limit {
+			return result, fmt.Errorf("%w, process value %.2f exceeds the limit of %.2f", ErrLimitExceeded, value, c.Limit)
+		}
+
+		if high {
+			extreme = math.Max(extreme, value)
+			if value < c.SetPoint-c.Hysteresis {
+				peaks = append(peaks, extreme)
+				high = false
+				extreme = value
+				err = e.apply(high)
+			}
+		} else {
+			extreme = math.Min(extreme, value)
+			if value > c.SetPoint+c.Hysteresis {
+				valleys = append(valleys, extreme)
+				high = true
+				extreme = value
+				risingSwitches = append(risingSwitches, now)
+				ui.Info("Relay experiment: cycle %d of %d", len(risingSwitches)-1, c.Cycles+1)
+				err = e.apply(high)
+			}
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// discard the first cycle, which is affected by the initial state of the process
+	risingSwitches = risingSwitches[1:]
+	period := risingSwitches[len(risingSwitches)-1].Sub(risingSwitches[0]) / time.Duration(len(risingSwitches)-1)
+	amplitude := (average(lastN(peaks, c.Cycles)) - average(lastN(valleys, c.Cycles))) / 2
+
+	return calculateRelayResult(c, amplitude, period)
+}
+
+func (e *RelayExperiment) apply(high bool) error {
+	if high {
+		return e.Process.Apply(e.Config.High)
+	}
+	return e.Process.Apply(e.Config.Low)
+}
+
+// calculateRelayResult computes the ultimate gain from the describing function of a relay with hysteresis
+func calculateRelayResult(c RelayConfig, amplitude float64, period time.Duration) (result RelayResult, err error) {
+	if amplitude <= c.Hysteresis || period <= 0 {
+		return result, fmt.Errorf("oscillation is too small (amplitude %.2f, period %v), increase the relay output range or decrease the hysteresis", amplitude, period)
+	}
+
+	d := (c.High - c.Low) / 2
+	return RelayResult{
+		Amplitude:    amplitude,
+		Period:       period,
+		UltimateGain: 4 * d / (math.Pi * math.Sqrt(amplitude*amplitude-c.Hysteresis*c.Hysteresis)),
+	}, nil
+}
+
+func lastN(values []float64, n int) []float64 {
+	if len(values) > n {
+		return values[len(values)-n:]
+	}
+	return values
+}
+
+func average(values []float64) float64 {
+	if len(values) <= 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,127 @@
+package tuning
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// firstOrderProcess is a thermal process with dead time, which is cooled by the applied output
+type firstOrderProcess struct {
+	clock       *util.ManualClock
+	temperature float64
+	// outputs applied in the past, the oldest one is effective,
+	// which results in a dead time of len(delayed) - 1 seconds
+	delayed []float64
+	applied []float64
+}
+
+func (p *firstOrderProcess) Measure() (float64, error) {
+	return p.temperature, nil
+}
+
+func (p *firstOrderProcess) Apply(output float64) error {
+	p.applied = append(p.applied, output)
+	p.delayed[len(p.delayed)-1] = output
+	return nil
+}
+
+// step advances the process by one second
+func (p *firstOrderProcess) step() {
+	output := p.delayed[0]
+	p.delayed = append(p.delayed[1:], p.delayed[len(p.delayed)-1])
+
+	steadyState := 80 - 40*output
+	p.temperature += (steadyState - p.temperature) * (1 - math.Exp(-1.0/30))
+	p.clock.Advance(time.Second)
+}
+
+func createExperiment(t *testing.T, config RelayConfig) (*RelayExperiment, *firstOrderProcess) {
+	clock := util.NewManualClock(time.Now())
+	util.SetClock(clock)
+	t.Cleanup(func() {
+		util.SetClock(nil)
+	})
+
+	process := &firstOrderProcess{
+		clock:       clock,
+		temperature: 50,
+		delayed:     make([]float64, 5),
+	}
+	experiment := NewRelayExperiment(config, process)
+	experiment.sleep = func(d time.Duration) {
+		for i := 0; i < int(d.Seconds()); i++ {
+			process.step()
+		}
+	}
+	return experiment, process
+}
+
+func TestRelayExperiment_Run(t *testing.T) {
+	// GIVEN
+	config := RelayConfig{
+		SetPoint:   60,
+		High:       1,
+		Low:        0,
+		Hysteresis: 0.2,
+		Cycles:     3,
+		Interval:   time.Second,
+		Timeout:    time.Hour,
+		Limit:      90,
+	}
+	experiment, process := createExperiment(t, config)
+
+	// WHEN
+	result, err := experiment.Run(context.Background())
+
+	// THEN
+	assert.NoError(t, err)
+	// the relay switches between high and low output
+	assert.Equal(t, []float64{0, 1, 0, 1}, process.applied[:4])
+	// the dead time of 4s causes an oscillation with a period of about 4 * 4s
+	assert.InDelta(t, 16, result.Period.Seconds(), 3)
+	assert.Greater(t, result.Amplitude, config.Hysteresis)
+	assert.InDelta(t, 4*0.5/(math.Pi*math.Sqrt(result.Amplitude*result.Amplitude-0.04)), result.UltimateGain, 0.0001)
+}
+
+func TestRelayExperiment_Run_Limit(t *testing.T) {
+	// GIVEN
+	experiment, _ := createExperiment(t, RelayConfig{
+		SetPoint: 60,
+		High:     1,
+		Low:      0,
+		Cycles:   3,
+		Interval: time.Second,
+		Limit:    55,
+	})
+
+	// WHEN
+	_, err := experiment.Run(context.Background())
+
+	// THEN
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.ErrorContains(t, err, "exceeds the limit of 55.00")
+}
+
+func TestRelayExperiment_Run_Timeout(t *testing.T) {
+	// GIVEN
+	experiment, _ := createExperiment(t, RelayConfig{
+		// unreachable set point
+		SetPoint: 95,
+		High:     1,
+		Low:      0,
+		Cycles:   3,
+		Interval: time.Second,
+		Timeout:  time.Minute,
+	})
+
+	// WHEN
+	_, err := experiment.Run(context.Background())
+
+	// THEN
+	assert.EqualError(t, err, "timeout after 1m0s, no sustained oscillation detected")
+}
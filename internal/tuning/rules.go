@@ -0,0 +1,81 @@
+package tuning
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Gains of a PID loop in parallel form, as used by util.PidLoop
+type Gains struct {
+	P float64 `json:"p"`
+	I float64 `json:"i"`
+	D float64 `json:"d"`
+}
+
+// Rule computes PID gains from the ultimate gain and period (in seconds) of a process
+type Rule struct {
+	Name        string
+	Description string
+	// factors of the standard form: Kp = kp * Ku, Ti = ti * Tu, Td = td * Tu
+	kp, ti, td float64
+}
+
+const DefaultRule = "tyreus-luyben"
+
+var Rules = map[string]Rule{
+	"ziegler-nichols": {
+		Name:        "ziegler-nichols",
+		Description: "Classic Ziegler-Nichols, aggressive with noticeable overshoot",
+		kp:          0.6, ti: 0.5, td: 0.125,
+	},
+	"no-overshoot": {
+		Name:        "no-overshoot",
+		Description: "Ziegler-Nichols variant without overshoot",
+		kp:          0.2, ti: 0.5, td: 1.0 / 3,
+	},
+	"tyreus-luyben": {
+		Name:        "tyreus-luyben",
+		Description: "Tyreus-Luyben, conservative and robust for slow processes like temperatures",
+		kp:          1 / 2.2, ti: 2.2, td: 1 / 6.3,
+	},
+	"pi": {
+		Name:        "pi",
+		Description: "Ziegler-Nichols PI controller, without a derivative term",
+		kp:          0.45, ti: 1 / 1.2, td: 0,
+	},
+}
+
+// RuleNames returns the sorted names of all tuning rules
+func RuleNames() []string {
+	result := make([]string, 0, len(Rules))
+	for name := range Rules {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// SuggestGains computes the gains for the given experiment result using the named rule.
+// Since more fan speed lowers the temperature, the process has a negative gain, which results in negative PID gains.
+func SuggestGains(result RelayResult, ruleName string) (Gains, error) {
+	rule, ok := Rules[ruleName]
+	if !ok {
+		return Gains{}, fmt.Errorf("unknown tuning rule: %s, options: %v", ruleName, RuleNames())
+	}
+	return rule.Apply(result.UltimateGain, result.Period.Seconds()), nil
+}
+
+func (r Rule) Apply(ultimateGain float64, ultimatePeriod float64) Gains {
+	kp := r.kp * ultimateGain
+	ti := r.ti * ultimatePeriod
+	td := r.td * ultimatePeriod
+
+	gains := Gains{
+		P: -kp,
+		D: -kp * td,
+	}
+	if ti > 0 {
+		gains.I = -kp / ti
+	}
+	return gains
+}
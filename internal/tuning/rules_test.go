@@ -0,0 +1,51 @@
+package tuning
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestGains(t *testing.T) {
+	// GIVEN
+	result := RelayResult{
+		UltimateGain: 0.1,
+		Period:       60 * time.Second,
+	}
+
+	// WHEN
+	gains, err := SuggestGains(result, "ziegler-nichols")
+
+	// THEN
+	assert.NoError(t, err)
+	// Kp = 0.6 * Ku, Ti = Tu / 2, Td = Tu / 8
+	assert.InDelta(t, -0.06, gains.P, 0.000001)
+	assert.InDelta(t, -0.06/30, gains.I, 0.000001)
+	assert.InDelta(t, -0.06*7.5, gains.D, 0.000001)
+}
+
+func TestSuggestGains_PI(t *testing.T) {
+	// GIVEN
+	result := RelayResult{
+		UltimateGain: 0.1,
+		Period:       60 * time.Second,
+	}
+
+	// WHEN
+	gains, err := SuggestGains(result, "pi")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.InDelta(t, -0.045, gains.P, 0.000001)
+	assert.InDelta(t, -0.045/50, gains.I, 0.000001)
+	assert.Equal(t, 0.0, gains.D)
+}
+
+func TestSuggestGains_UnknownRule(t *testing.T) {
+	// WHEN
+	_, err := SuggestGains(RelayResult{}, "unknown")
+
+	// THEN
+	assert.EqualError(t, err, "unknown tuning rule: unknown, options: [no-overshoot pi tyreus-luyben ziegler-nichols]")
+}
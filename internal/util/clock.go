@@ -0,0 +1,63 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time to time dependent computations, it can be replaced in tests and simulations
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+var (
+	clock     Clock = systemClock{}
+	clockLock sync.RWMutex
+)
+
+// Now returns the current time of the active clock
+func Now() time.Time {
+	clockLock.RLock()
+	defer clockLock.RUnlock()
+	return clock.Now()
+}
+
+// SetClock replaces the active clock, nil restores the system clock
+func SetClock(c Clock) {
+	clockLock.Lock()
+	defer clockLock.Unlock()
+	if c == nil {
+		c = systemClock{}
+	}
+	clock = c
+}
+
+// ManualClock is a clock that only advances when told to,
+// which allows running control loops faster than real time
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by the given duration
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
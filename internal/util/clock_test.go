@@ -0,0 +1,47 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetClock(t *testing.T) {
+	// GIVEN
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	SetClock(clock)
+	t.Cleanup(func() {
+		SetClock(nil)
+	})
+
+	// WHEN
+	clock.Advance(5 * time.Second)
+
+	// THEN
+	assert.Equal(t, start.Add(5*time.Second), Now())
+}
+
+func TestPidLoop_ManualClock(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	SetClock(clock)
+	t.Cleanup(func() {
+		SetClock(nil)
+	})
+	pid := NewPidLoop(1, 0.5, 0)
+
+	// WHEN
+	first := pid.Loop(10, 0)
+	clock.Advance(2 * time.Second)
+	second := pid.Loop(10, 0)
+	// the clock did not advance
+	third := pid.Loop(10, 0)
+
+	// THEN
+	assert.Equal(t, 0.0, first)
+	// p * 10 + i * (10 * 2s)
+	assert.Equal(t, 20.0, second)
+	assert.Equal(t, second, third)
+}
@@ -0,0 +1,259 @@
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markusressel/fan2go/internal/ui"
+)
+
+const (
+	// DefaultCoprocessTimeout is the time to wait for the response to a single request
+	DefaultCoprocessTimeout = 2 * time.Second
+
+	coprocessMinBackoff = 1 * time.Second
+	coprocessMaxBackoff = 1 * time.Minute
+)
+
+var (
+	coprocesses     = map[string]*Coprocess{}
+	coprocessesLock sync.Mutex
+)
+
+// CoprocessRequest is sent to the stdin of a coprocess, as a single line of JSON
+type CoprocessRequest struct {
+	Id     int    `json:"id"`
+	Method string `json:"method"`
+	// Target is the id of the fan or sensor this request refers to
+	Target string   `json:"target"`
+	Value  *float64 `json:"value,omitempty"`
+}
+
+// CoprocessResponse is expected on the stdout of a coprocess, as a single line of JSON
+type CoprocessResponse struct {
+	Id    int      `json:"id"`
+	Value *float64 `json:"value,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// Coprocess is a long-running helper process, which answers requests
+// using line-delimited JSON over stdin/stdout.
+// The process is started lazily, and restarted with an exponential backoff if it exits.
+type Coprocess struct {
+	executable string
+	args       []string
+
+	// serializes requests
+	mu sync.Mutex
+
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan CoprocessResponse
+	nextId    int
+
+	// number of consecutive failures, used to compute the backoff
+	failures int
+	// the process is not restarted before this time
+	nextStart time.Time
+
+	minBackoff          time.Duration
+	maxBackoff          time.Duration
+	skipPermissionCheck bool
+}
+
+// GetCoprocess returns the coprocess for the given command, which is shared by all fans and sensors using it
+func GetCoprocess(executable string, args []string) *Coprocess {
+	coprocessesLock.Lock()
+	defer coprocessesLock.Unlock()
+
+	key := strings.Join(append([]string{executable}, args...), "\x00")
+	if c, ok := coprocesses[key]; ok {
+		return c
+	}
+
+	c := NewCoprocess(executable, args)
+	coprocesses[key] = c
+	return c
+}
+
+// StopCoprocesses stops all coprocesses returned by GetCoprocess
+func StopCoprocesses() {
+	coprocessesLock.Lock()
+	defer coprocessesLock.Unlock()
+
+	for _, c := range coprocesses {
+		c.Stop()
+	}
+}
+
+func NewCoprocess(executable string, args []string) *Coprocess {
+	return &Coprocess{
+		executable: executable,
+		args:       args,
+		minBackoff: coprocessMinBackoff,
+		maxBackoff: coprocessMaxBackoff,
+	}
+}
+
+// Request sends a request to the coprocess and waits for the corresponding response
+func (c *Coprocess) Request(method string, target string, value *float64, timeout time.Duration) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if timeout <= 0 {
+		timeout = DefaultCoprocessTimeout
+	}
+
+	err := c.ensureStarted()
+	if err != nil {
+		return 0, err
+	}
+
+	c.nextId++
+	request := CoprocessRequest{
+		Id:     c.nextId,
+		Method: method,
+		Target: target,
+		Value:  value,
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = c.stdin.Write(append(data, '\n'))
+	if err != nil {
+		c.fail()
+		return 0, fmt.Errorf("coprocess %s: unable to send request: %v", c.executable, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case response, ok := <-c.responses:
+			if !ok {
+				c.fail()
+				return 0, fmt.Errorf("coprocess %s: exited unexpectedly", c.executable)
+			}
+			if response.Id != request.Id {
+				// late response to a previous request
+				continue
+			}
+			c.failures = 0
+			if len(response.Error) > 0 {
+				return 0, fmt.Errorf("coprocess %s: %s", c.executable, response.Error)
+			}
+			if response.Value == nil {
+				return 0, fmt.Errorf("coprocess %s: response to %s is missing a value", c.executable, method)
+			}
+			return *response.Value, nil
+		case <-timer.C:
+			// the process might be stuck, restart it to get back into a known state
+			c.fail()
+			return 0, fmt.Errorf("coprocess %s: timeout waiting for response to %s", c.executable, method)
+		}
+	}
+}
+
+// Stop terminates the coprocess, it is restarted on the next request
+func (c *Coprocess) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.kill()
+}
+
+func (c *Coprocess) ensureStarted() error {
+	if c.cmd != nil {
+		return nil
+	}
+
+	if wait := time.Until(c.nextStart); wait > 0 {
+		return fmt.Errorf("coprocess %s: waiting %v before restarting", c.executable, wait.Round(time.Millisecond))
+	}
+
+	if !c.skipPermissionCheck {
+		if _, err := CheckFilePermissionsForExecution(c.executable); err != nil {
+			c.fail()
+			return fmt.Errorf("cannot execute %s: %s", c.executable, err)
+		}
+	}
+
+	cmd := exec.Command(c.executable, c.args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	ui.Debug("Starting coprocess %s...", c.executable)
+	err = cmd.Start()
+	if err != nil {
+		c.fail()
+		return fmt.Errorf("coprocess %s: unable to start: %v", c.executable, err)
+	}
+
+	responses := make(chan CoprocessResponse)
+	go func() {
+		defer close(responses)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			response := CoprocessResponse{}
+			if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+				ui.Warning("Coprocess %s: invalid response: %s", c.executable, scanner.Text())
+				continue
+			}
+			responses <- response
+		}
+		_ = cmd.Wait()
+	}()
+
+	c.cmd = cmd
+	c.stdin = stdin
+	c.responses = responses
+	return nil
+}
+
+// fail kills the current process and schedules the next start using an exponential backoff
+func (c *Coprocess) fail() {
+	c.kill()
+
+	backoff := c.minBackoff << c.failures
+	if backoff <= 0 || backoff > c.maxBackoff {
+		backoff = c.maxBackoff
+	} else {
+		c.failures++
+	}
+	c.nextStart = time.Now().Add(backoff)
+	ui.Warning("Coprocess %s failed, restarting in %v", c.executable, backoff)
+}
+
+func (c *Coprocess) kill() {
+	if c.cmd == nil {
+		return
+	}
+
+	_ = c.stdin.Close()
+	if c.cmd.Process != nil {
+		_ = c.cmd.Process.Kill()
+	}
+	// drain remaining responses, so the reader can exit
+	go func(responses chan CoprocessResponse) {
+		for range responses {
+		}
+	}(c.responses)
+
+	c.cmd = nil
+	c.stdin = nil
+	c.responses = nil
+}
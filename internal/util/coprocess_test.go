@@ -0,0 +1,124 @@
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const coprocessHelperEnv = "FAN2GO_COPROCESS_HELPER"
+
+// TestCoprocessHelper is not a real test, it is executed as the coprocess by the other tests
+func TestCoprocessHelper(t *testing.T) {
+	if os.Getenv(coprocessHelperEnv) != "1" {
+		return
+	}
+
+	values := map[string]float64{}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		request := CoprocessRequest{}
+		_ = json.Unmarshal(scanner.Bytes(), &request)
+
+		response := CoprocessResponse{Id: request.Id}
+		switch request.Method {
+		case "setPwm":
+			values[request.Target] = *request.Value
+			response.Value = request.Value
+		case "getPwm":
+			value := values[request.Target]
+			response.Value = &value
+		case "hang":
+			time.Sleep(time.Minute)
+		case "crash":
+			os.Exit(1)
+		default:
+			response.Error = fmt.Sprintf("unknown method: %s", request.Method)
+		}
+
+		data, _ := json.Marshal(response)
+		fmt.Println(string(data))
+	}
+	os.Exit(0)
+}
+
+func createHelperCoprocess(t *testing.T) *Coprocess {
+	t.Setenv(coprocessHelperEnv, "1")
+	c := NewCoprocess(os.Args[0], []string{"-test.run=TestCoprocessHelper"})
+	c.skipPermissionCheck = true
+	c.minBackoff = 10 * time.Millisecond
+	c.maxBackoff = 20 * time.Millisecond
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func TestCoprocess_Request(t *testing.T) {
+	// GIVEN
+	c := createHelperCoprocess(t)
+	value := 128.0
+
+	// WHEN
+	_, err := c.Request("setPwm", "fan1", &value, time.Second)
+	assert.NoError(t, err)
+	result, err := c.Request("getPwm", "fan1", nil, time.Second)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 128.0, result)
+	assert.Equal(t, 2, c.nextId)
+}
+
+func TestCoprocess_Request_Error(t *testing.T) {
+	// GIVEN
+	c := createHelperCoprocess(t)
+
+	// WHEN
+	_, err := c.Request("getRpm", "fan1", nil, time.Second)
+
+	// THEN
+	assert.EqualError(t, err, fmt.Sprintf("coprocess %s: unknown method: getRpm", os.Args[0]))
+}
+
+func TestCoprocess_Request_Timeout(t *testing.T) {
+	// GIVEN
+	c := createHelperCoprocess(t)
+
+	// WHEN
+	_, err := c.Request("hang", "fan1", nil, 100*time.Millisecond)
+
+	// THEN
+	assert.ErrorContains(t, err, "timeout")
+	assert.Nil(t, c.cmd)
+}
+
+func TestCoprocess_Restart(t *testing.T) {
+	// GIVEN
+	c := createHelperCoprocess(t)
+	value := 42.0
+
+	// WHEN
+	_, err := c.Request("crash", "fan1", nil, time.Second)
+
+	// THEN
+	assert.ErrorContains(t, err, "exited unexpectedly")
+
+	// WHEN
+	_, err = c.Request("setPwm", "fan1", &value, time.Second)
+
+	// THEN
+	assert.ErrorContains(t, err, "before restarting")
+
+	// WHEN
+	time.Sleep(c.minBackoff)
+	result, err := c.Request("setPwm", "fan1", &value, time.Second)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 42.0, result)
+	assert.Equal(t, 0, c.failures)
+}
@@ -18,13 +18,15 @@ func CheckFilePermissionsForExecution(filePath string) (bool, error) {
 	var file = filePath
 
 	file, err := filepath.EvalSymlinks(file)
-	if err != nil {
-		panic(err)
+	if os.IsNotExist(err) {
+		return false, errors.New("file not found")
+	} else if err != nil {
+		return false, err
 	}
 
 	info, err := os.Stat(file)
-	if os.IsNotExist(err) {
-		return false, errors.New("file not found")
+	if err != nil {
+		return false, err
 	}
 
 	stat := info.Sys().(*syscall.Stat_t)
@@ -48,6 +50,38 @@ func CheckFilePermissionsForExecution(filePath string) (bool, error) {
 	return true, nil
 }
 
+// access modes of syscall.Access
+const (
+	accessRead  = 0x4
+	accessWrite = 0x2
+)
+
+// CheckFileAccess checks whether the given file exists and can be read, and written if write is true, by fan2go
+func CheckFileAccess(filePath string, write bool) error {
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return errors.New("file not found")
+	} else if err != nil {
+		return err
+	}
+
+	mode := uint32(accessRead)
+	if write {
+		// root may open any file for writing, but sysfs rejects writes to attributes without write permission
+		if info.Mode().Perm()&0o222 == 0 {
+			return errors.New("file is read-only")
+		}
+		mode |= accessWrite
+	}
+	if err = syscall.Access(filePath, mode); err != nil {
+		if write {
+			return fmt.Errorf("no read/write permission: %v", err)
+		}
+		return fmt.Errorf("no read permission: %v", err)
+	}
+	return nil
+}
+
 func ReadIntFromFile(path string) (value int, err error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -3,6 +3,7 @@ package util
 import (
 	"github.com/stretchr/testify/assert"
 	"os"
+	"path"
 	"testing"
 )
 
@@ -113,3 +114,27 @@ func TestFileHasPermissionsOtherHasWritePermission(t *testing.T) {
 	assert.Equal(t, false, result)
 	assert.Error(t, err)
 }
+
+func TestFileHasPermissionsFileNotFound(t *testing.T) {
+	// WHEN
+	result, err := CheckFilePermissionsForExecution(path.Join(t.TempDir(), "missing"))
+
+	// THEN
+	assert.Equal(t, false, result)
+	assert.EqualError(t, err, "file not found")
+}
+
+func TestCheckFileAccess(t *testing.T) {
+	// GIVEN
+	dir := t.TempDir()
+	writable := path.Join(dir, "pwm1")
+	readOnly := path.Join(dir, "fan1_input")
+	assert.NoError(t, os.WriteFile(writable, []byte("128"), 0o644))
+	assert.NoError(t, os.WriteFile(readOnly, []byte("1200"), 0o444))
+
+	// THEN
+	assert.NoError(t, CheckFileAccess(writable, true))
+	assert.NoError(t, CheckFileAccess(readOnly, false))
+	assert.EqualError(t, CheckFileAccess(readOnly, true), "file is read-only")
+	assert.EqualError(t, CheckFileAccess(path.Join(dir, "missing"), false), "file not found")
+}
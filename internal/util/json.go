@@ -0,0 +1,82 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExtractJsonPath walks the given (unmarshalled) JSON data along a simple dot separated path,
+// f.ex. "sensors[0].temperature" or "$.room.value", and returns the value at its end.
+func ExtractJsonPath(data interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+
+	current := data
+	if len(path) <= 0 {
+		return current, nil
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		key := segment
+		var indices []int
+		if idx := strings.Index(segment, "["); idx >= 0 {
+			key = segment[:idx]
+			rest := segment[idx:]
+			for len(rest) > 0 {
+				end := strings.Index(rest, "]")
+				if !strings.HasPrefix(rest, "[") || end < 0 {
+					return nil, fmt.Errorf("invalid path segment: %s", segment)
+				}
+				index, err := strconv.Atoi(rest[1:end])
+				if err != nil {
+					return nil, fmt.Errorf("invalid index in path segment: %s", segment)
+				}
+				indices = append(indices, index)
+				rest = rest[end+1:]
+			}
+		}
+
+		if len(key) > 0 {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot access key '%s' of a non-object value", key)
+			}
+			value, exists := object[key]
+			if !exists {
+				return nil, fmt.Errorf("key not found: %s", key)
+			}
+			current = value
+		}
+
+		for _, index := range indices {
+			array, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot access index %d of a non-array value", index)
+			}
+			if index < 0 || index >= len(array) {
+				return nil, fmt.Errorf("index out of range: %d", index)
+			}
+			current = array[index]
+		}
+	}
+
+	return current, nil
+}
+
+// JsonValueToFloat converts a JSON number, numeric string or boolean to a float64
+func JsonValueToFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("value is not a number: %v", value)
+	}
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractJsonPath(t *testing.T) {
+	// GIVEN
+	var data interface{}
+	err := json.Unmarshal([]byte(`{"room": {"sensors": [{"value": 21.5}, {"value": "23.0"}]}}`), &data)
+	assert.NoError(t, err)
+
+	// WHEN
+	first, err := ExtractJsonPath(data, "room.sensors[0].value")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 21.5, first)
+
+	// WHEN
+	second, err := ExtractJsonPath(data, "$.room.sensors[1].value")
+
+	// THEN
+	assert.NoError(t, err)
+	value, err := JsonValueToFloat(second)
+	assert.NoError(t, err)
+	assert.Equal(t, 23.0, value)
+}
+
+func TestExtractJsonPath_NestedArray(t *testing.T) {
+	// GIVEN
+	var data interface{}
+	err := json.Unmarshal([]byte(`[[1, 2], [3, 4]]`), &data)
+	assert.NoError(t, err)
+
+	// WHEN
+	result, err := ExtractJsonPath(data, "[1][0]")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, result)
+}
+
+func TestExtractJsonPath_Invalid(t *testing.T) {
+	// GIVEN
+	var data interface{}
+	err := json.Unmarshal([]byte(`{"room": {"sensors": [{"value": 21.5}]}}`), &data)
+	assert.NoError(t, err)
+
+	// WHEN
+	_, missingKeyErr := ExtractJsonPath(data, "room.missing")
+	_, outOfRangeErr := ExtractJsonPath(data, "room.sensors[3].value")
+	_, notAnArrayErr := ExtractJsonPath(data, "room[0]")
+
+	// THEN
+	assert.EqualError(t, missingKeyErr, "key not found: missing")
+	assert.EqualError(t, outOfRangeErr, "index out of range: 3")
+	assert.EqualError(t, notAnArrayErr, "cannot access index 0 of a non-array value")
+}
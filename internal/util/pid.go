@@ -1,6 +1,9 @@
 package util
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type PidLoop struct {
 	// Proptional Constant
@@ -10,12 +13,31 @@ type PidLoop struct {
 	// Derivative Constant
 	d float64
 
+	// limits of the output, only applied if outputLimited is set
+	outputLimited bool
+	outputMin     float64
+	outputMax     float64
+	// limits of the integral term, only applied if integralLimited is set.
+	// Defaults to the output limits, which prevents integral windup while the output is saturated.
+	integralLimited bool
+	integralMin     float64
+	integralMax     float64
+	// time constant of the low pass filter applied to the derivative term, 0 disables the filter
+	derivativeFilter time.Duration
+	// clock used to measure the time between two loops, nil uses the global clock
+	clock Clock
+
 	// error from previous loop
 	error float64
-	// integral from previous loop + error, i.e. integral error
+	// measured value from previous loop
+	measured float64
+	// integral term, i.e. the integral error already multiplied with the integral constant.
+	// Storing the term instead of the error allows changing the gains without a jump of the output.
 	integral float64
-	// error - error from previous loop, i.e. differential error
-	//differentialError float64
+	// (filtered) derivative of the measured value
+	derivative float64
+	// output of the previous loop
+	output float64
 	// last execution time of the loop
 	lastTime time.Time
 }
@@ -28,25 +50,109 @@ func NewPidLoop(p float64, i float64, d float64) *PidLoop {
 	}
 }
 
-// Loop advances the pid loop
+// WithOutputLimits clamps the output of the loop to the given range.
+// Unless integral limits are set explicitly, the integral term is clamped to the same range.
+func (p *PidLoop) WithOutputLimits(min float64, max float64) *PidLoop {
+	p.outputLimited = true
+	p.outputMin = min
+	p.outputMax = max
+	if !p.integralLimited {
+		p.integralMin = min
+		p.integralMax = max
+	}
+	return p
+}
+
+// WithIntegralLimits clamps the integral term of the loop to the given range
+func (p *PidLoop) WithIntegralLimits(min float64, max float64) *PidLoop {
+	p.integralLimited = true
+	p.integralMin = min
+	p.integralMax = max
+	return p
+}
+
+// WithDerivativeFilter applies a first order low pass filter with the given time constant to the derivative term
+func (p *PidLoop) WithDerivativeFilter(timeConstant time.Duration) *PidLoop {
+	p.derivativeFilter = timeConstant
+	return p
+}
+
+// WithClock uses the given clock instead of the global one
+func (p *PidLoop) WithClock(clock Clock) *PidLoop {
+	p.clock = clock
+	return p
+}
+
+// SetGains changes the constants of the loop without a jump of the output (bumpless transfer)
+func (p *PidLoop) SetGains(proportional float64, integral float64, derivative float64) {
+	if !p.lastTime.IsZero() {
+		// compensate the change of the proportional term using the integral term
+		p.integral = p.clampIntegral(p.integral + (p.p-proportional)*p.error)
+	}
+	p.p = proportional
+	p.i = integral
+	p.d = derivative
+}
+
+// Reset clears the state of the loop, the next loop behaves like the first one
+func (p *PidLoop) Reset() {
+	p.error = 0
+	p.measured = 0
+	p.integral = 0
+	p.derivative = 0
+	p.output = 0
+	p.lastTime = time.Time{}
+}
+
+// Loop advances the pid loop.
+// If the clock has not advanced since the previous loop, the previous output is returned unchanged.
 func (p *PidLoop) Loop(target float64, measured float64) float64 {
 	output := 0.0
 	err := target - measured
 
-	loopTime := time.Now()
-	if p.lastTime.IsZero() {
-		p.lastTime = loopTime
-	} else {
+	loopTime := p.now()
+	if !p.lastTime.IsZero() {
 		dt := loopTime.Sub(p.lastTime).Seconds()
+		if dt <= 0 {
+			return p.output
+		}
+
+		proportional := p.p * err
+		p.integral = p.clampIntegral(p.integral + p.i*err*dt)
+
+		// the derivative of the measured value is used instead of the derivative of the error,
+		// so a change of the target does not cause a spike of the output
+		derivative := -(measured - p.measured) / dt
+		if p.derivativeFilter > 0 {
+			alpha := dt / (p.derivativeFilter.Seconds() + dt)
+			derivative = p.derivative + alpha*(derivative-p.derivative)
+		}
+		p.derivative = derivative
 
-		proportional := err
-		p.integral = p.integral + err*dt
-		derivative := (err - p.error) / dt
-		output = p.p*proportional + p.i*p.integral + p.d*derivative
+		output = proportional + p.integral + p.d*derivative
+	}
+
+	if p.outputLimited {
+		output = math.Max(p.outputMin, math.Min(p.outputMax, output))
 	}
 
 	p.error = err
+	p.measured = measured
+	p.output = output
 	p.lastTime = loopTime
-
 	return output
 }
+
+func (p *PidLoop) clampIntegral(value float64) float64 {
+	if p.integralLimited || p.outputLimited {
+		return math.Max(p.integralMin, math.Min(p.integralMax, value))
+	}
+	return value
+}
+
+func (p *PidLoop) now() time.Time {
+	if p.clock != nil {
+		return p.clock.Now()
+	}
+	return Now()
+}
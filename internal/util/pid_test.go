@@ -0,0 +1,168 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPidLoop_OutputLimits(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	pid := NewPidLoop(1, 0, 0).WithOutputLimits(0, 1).WithClock(clock)
+
+	// WHEN
+	pid.Loop(10, 0)
+	clock.Advance(time.Second)
+	above := pid.Loop(10, 0)
+	clock.Advance(time.Second)
+	below := pid.Loop(0, 10)
+
+	// THEN
+	assert.Equal(t, 1.0, above)
+	assert.Equal(t, 0.0, below)
+}
+
+func TestPidLoop_AntiWindup(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	pid := NewPidLoop(0, 1, 0).WithOutputLimits(0, 1).WithClock(clock)
+
+	// WHEN
+	// saturate the output for a long time
+	pid.Loop(10, 0)
+	for i := 0; i < 100; i++ {
+		clock.Advance(time.Second)
+		pid.Loop(10, 0)
+	}
+	// the error changes its sign
+	clock.Advance(500 * time.Millisecond)
+	result := pid.Loop(0, 1)
+
+	// THEN
+	// the integral did not wind up beyond the output limit, so the output reacts immediately
+	assert.InDelta(t, 0.5, result, 0.0001)
+}
+
+func TestPidLoop_IntegralLimits(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	pid := NewPidLoop(0, 1, 0).WithIntegralLimits(-2, 2).WithClock(clock)
+
+	// WHEN
+	pid.Loop(10, 0)
+	clock.Advance(time.Second)
+	result := pid.Loop(10, 0)
+
+	// THEN
+	assert.Equal(t, 2.0, result)
+}
+
+func TestPidLoop_NoDerivativeKick(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	pid := NewPidLoop(0, 0, 1).WithClock(clock)
+
+	// WHEN
+	pid.Loop(10, 5)
+	clock.Advance(time.Second)
+	// the target changes, the measured value does not
+	result := pid.Loop(50, 5)
+
+	// THEN
+	assert.Equal(t, 0.0, result)
+}
+
+func TestPidLoop_DerivativeOnMeasurement(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	pid := NewPidLoop(0, 0, 1).WithClock(clock)
+
+	// WHEN
+	pid.Loop(10, 5)
+	clock.Advance(time.Second)
+	result := pid.Loop(10, 7)
+
+	// THEN
+	// the measured value rises by 2/s, which reduces the error
+	assert.Equal(t, -2.0, result)
+}
+
+func TestPidLoop_DerivativeFilter(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	pid := NewPidLoop(0, 0, 1).WithDerivativeFilter(time.Second).WithClock(clock)
+
+	// WHEN
+	pid.Loop(10, 5)
+	clock.Advance(time.Second)
+	first := pid.Loop(10, 7)
+	clock.Advance(time.Second)
+	second := pid.Loop(10, 9)
+
+	// THEN
+	// alpha = dt / (tau + dt) = 0.5
+	assert.Equal(t, -1.0, first)
+	assert.Equal(t, -1.5, second)
+}
+
+func TestPidLoop_SetGainsIsBumpless(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	pid := NewPidLoop(1, 0.5, 0).WithClock(clock)
+	pid.Loop(10, 8)
+	clock.Advance(time.Second)
+	before := pid.Loop(10, 8)
+
+	// WHEN
+	pid.SetGains(2, 0.5, 0)
+	clock.Advance(time.Millisecond)
+	after := pid.Loop(10, 8)
+
+	// THEN
+	assert.InDelta(t, before, after, 0.01)
+}
+
+func TestPidLoop_Reset(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	pid := NewPidLoop(1, 1, 0).WithClock(clock)
+	pid.Loop(10, 0)
+	clock.Advance(time.Second)
+	pid.Loop(10, 0)
+
+	// WHEN
+	pid.Reset()
+	first := pid.Loop(10, 0)
+	clock.Advance(time.Second)
+	second := pid.Loop(10, 0)
+
+	// THEN
+	assert.Equal(t, 0.0, first)
+	// p * 10 + i * (10 * 1s), without the integral of the previous loops
+	assert.Equal(t, 20.0, second)
+}
+
+func TestPidLoop_ClockNotAdvanced(t *testing.T) {
+	// GIVEN
+	clock := NewManualClock(time.Now())
+	pid := NewPidLoop(1, 0, 0).WithOutputLimits(0, 100).WithClock(clock)
+	pid.Loop(10, 0)
+	clock.Advance(time.Second)
+	expected := pid.Loop(10, 0)
+
+	// WHEN
+	result := pid.Loop(20, 0)
+
+	// THEN
+	assert.Equal(t, 10.0, expected)
+	assert.Equal(t, expected, result)
+
+	// WHEN
+	clock.Advance(time.Second)
+	result = pid.Loop(20, 0)
+
+	// THEN
+	assert.Equal(t, 20.0, result)
+}
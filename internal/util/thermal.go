@@ -0,0 +1,63 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultSysfsRoot is the directory sysfs is usually mounted at
+	DefaultSysfsRoot = "/sys"
+
+	ThermalZonePrefix     = "thermal_zone"
+	CoolingDevicePrefix   = "cooling_device"
+	thermalDeviceTypeFile = "type"
+)
+
+// ThermalClassPath returns the directory containing all thermal zones and cooling devices below the given
+// sysfs root, which defaults to DefaultSysfsRoot
+func ThermalClassPath(sysfsRoot string) string {
+	if len(sysfsRoot) <= 0 {
+		sysfsRoot = DefaultSysfsRoot
+	}
+	return filepath.Join(sysfsRoot, "class", "thermal")
+}
+
+// FindThermalDevice returns the path of the first device in classPath, whose directory name starts with prefix
+// (f.ex. "thermal_zone") and whose "type" file matches deviceType. The numbering of these devices is not
+// stable between boots, so devices should always be looked up by their type.
+func FindThermalDevice(classPath string, prefix string, deviceType string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(classPath, prefix+"*"))
+	if err != nil {
+		return "", err
+	}
+
+	// sort numerically, so thermal_zone10 comes after thermal_zone2
+	sort.Slice(matches, func(i, j int) bool {
+		return thermalDeviceNumber(matches[i], prefix) < thermalDeviceNumber(matches[j], prefix)
+	})
+
+	for _, devicePath := range matches {
+		data, err := os.ReadFile(filepath.Join(devicePath, thermalDeviceTypeFile))
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(data)) == deviceType {
+			return devicePath, nil
+		}
+	}
+
+	return "", fmt.Errorf("no %s with type '%s' found in %s", prefix, deviceType, classPath)
+}
+
+func thermalDeviceNumber(devicePath string, prefix string) int {
+	number, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(devicePath), prefix))
+	if err != nil {
+		return -1
+	}
+	return number
+}
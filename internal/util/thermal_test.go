@@ -0,0 +1,46 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createThermalDevice(t *testing.T, classPath string, name string, deviceType string) string {
+	devicePath := filepath.Join(classPath, name)
+	err := os.MkdirAll(devicePath, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(devicePath, "type"), []byte(deviceType+"\n"), 0644)
+	assert.NoError(t, err)
+	return devicePath
+}
+
+func TestFindThermalDevice(t *testing.T) {
+	// GIVEN
+	classPath := t.TempDir()
+	createThermalDevice(t, classPath, "thermal_zone0", "acpitz")
+	expected := createThermalDevice(t, classPath, "thermal_zone2", "x86_pkg_temp")
+	createThermalDevice(t, classPath, "thermal_zone10", "x86_pkg_temp")
+	createThermalDevice(t, classPath, "cooling_device0", "x86_pkg_temp")
+
+	// WHEN
+	result, err := FindThermalDevice(classPath, ThermalZonePrefix, "x86_pkg_temp")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestFindThermalDevice_NotFound(t *testing.T) {
+	// GIVEN
+	classPath := t.TempDir()
+	createThermalDevice(t, classPath, "thermal_zone0", "acpitz")
+
+	// WHEN
+	_, err := FindThermalDevice(classPath, CoolingDevicePrefix, "acpitz")
+
+	// THEN
+	assert.Error(t, err)
+}